@@ -2,28 +2,112 @@ package main
 
 import (
 	"context"
-	redisCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/redis"
+	"crypto/tls"
+	"encoding/base64"
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	cacheBackend "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/backend"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/breaker"
+	cacheDelivery "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/delivery/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/invalidation"
+	memoryCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/memory"
+	noopCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/noop"
+	tieredCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/tiered"
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	invalidatingRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/invalidating"
+	bannerMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/memory"
 	bannerRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/pgx"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/background"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
 
 	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	pHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher"
+	argon2idHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher/argon2id"
+	bcryptHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher/bcrypt"
+	multiHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher/multi"
 	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/storage"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/storage/postgres"
 
 	bannerDelivery "github.com/SlavaShagalov/avito-intern-task/internal/banner/delivery/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/snapshot"
 	bannerUsecase "github.com/SlavaShagalov/avito-intern-task/internal/banner/usecase"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/warmup"
 
+	"github.com/SlavaShagalov/avito-intern-task/internal/health"
+	healthDelivery "github.com/SlavaShagalov/avito-intern-task/internal/health/delivery/http"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/attempts"
+	memoryAttempts "github.com/SlavaShagalov/avito-intern-task/internal/auth/attempts/memory"
+	redisAttempts "github.com/SlavaShagalov/avito-intern-task/internal/auth/attempts/redis"
 	authDelivery "github.com/SlavaShagalov/avito-intern-task/internal/auth/delivery/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist"
+	cachedDenyList "github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist/cached"
+	memoryDenyList "github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist/memory"
+	redisDenyList "github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist/redis"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+	keyringDelivery "github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring/delivery/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/mtls"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/policy"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/totp"
 	authUsecase "github.com/SlavaShagalov/avito-intern-task/internal/auth/usecase"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pUser "github.com/SlavaShagalov/avito-intern-task/internal/user"
+	userDelivery "github.com/SlavaShagalov/avito-intern-task/internal/user/delivery/http"
+	userMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/user/repository/memory"
 	userRepository "github.com/SlavaShagalov/avito-intern-task/internal/user/repository/pgx"
+	userUsecase "github.com/SlavaShagalov/avito-intern-task/internal/user/usecase"
+
+	pSession "github.com/SlavaShagalov/avito-intern-task/internal/session"
+	sessionMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/session/repository/memory"
+	sessionRepository "github.com/SlavaShagalov/avito-intern-task/internal/session/repository/pgx"
+
+	pResetToken "github.com/SlavaShagalov/avito-intern-task/internal/resettoken"
+	resetTokenMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/resettoken/repository/memory"
+	resetTokenRepository "github.com/SlavaShagalov/avito-intern-task/internal/resettoken/repository/pgx"
+
+	pSigningKey "github.com/SlavaShagalov/avito-intern-task/internal/signingkey"
+	signingKeyMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/signingkey/repository/memory"
+	signingKeyRepository "github.com/SlavaShagalov/avito-intern-task/internal/signingkey/repository/pgx"
+
+	pInvite "github.com/SlavaShagalov/avito-intern-task/internal/invite"
+	inviteMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/invite/repository/memory"
+	inviteRepository "github.com/SlavaShagalov/avito-intern-task/internal/invite/repository/pgx"
+
+	pTwoFactor "github.com/SlavaShagalov/avito-intern-task/internal/twofactor"
+	twoFactorMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/twofactor/repository/memory"
+	twoFactorRepository "github.com/SlavaShagalov/avito-intern-task/internal/twofactor/repository/pgx"
+
+	pTeam "github.com/SlavaShagalov/avito-intern-task/internal/team"
+	teamDelivery "github.com/SlavaShagalov/avito-intern-task/internal/team/delivery/http"
+	teamMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/team/repository/memory"
+	teamRepository "github.com/SlavaShagalov/avito-intern-task/internal/team/repository/pgx"
+	teamUsecase "github.com/SlavaShagalov/avito-intern-task/internal/team/usecase"
+
+	pAPIKey "github.com/SlavaShagalov/avito-intern-task/internal/apikey"
+	apiKeyDelivery "github.com/SlavaShagalov/avito-intern-task/internal/apikey/delivery/http"
+	apiKeyMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/apikey/repository/memory"
+	apiKeyRepository "github.com/SlavaShagalov/avito-intern-task/internal/apikey/repository/pgx"
+	apiKeyUsecase "github.com/SlavaShagalov/avito-intern-task/internal/apikey/usecase"
+
+	pTenant "github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	tenantDelivery "github.com/SlavaShagalov/avito-intern-task/internal/tenant/delivery/http"
+	tenantMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/tenant/repository/memory"
+	tenantRepository "github.com/SlavaShagalov/avito-intern-task/internal/tenant/repository/pgx"
+	tenantUsecase "github.com/SlavaShagalov/avito-intern-task/internal/tenant/usecase"
 )
 
 func main() {
@@ -38,6 +122,7 @@ func main() {
 	logger.Info("API server starting...")
 
 	// ===== Configuration =====
+	config.SetAPIDefaults()
 	viper.SetConfigName("api")
 	viper.SetConfigType("yaml")
 	viper.AddConfigPath("/config")
@@ -46,58 +131,377 @@ func main() {
 		logger.Error("Failed to read configuration", zap.Error(err))
 		os.Exit(1)
 	}
+	// Secrets may be kept out of the config file.
+	_ = viper.BindEnv(config.AuthSigningKeysEncryptionKey)
 	logger.Info("Configuration read successfully")
 
-	// ===== Database =====
-	pgxPool, err := postgres.NewPgx(logger)
-	if err != nil {
+	// ctx stops background loops once the server is drained. Storages are closed
+	// only after the loops return.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	loops := new(background.Group)
+
+	// ===== Password hasher =====
+	bcryptFormat := multiHasher.Format{
+		Prefix: bcryptHasher.Prefix,
+		Hasher: bcryptHasher.NewWithCost(viper.GetInt(config.AuthBcryptCost)),
+	}
+	argon2idFormat := multiHasher.Format{
+		Prefix: argon2idHasher.Prefix,
+		Hasher: argon2idHasher.New(argon2idHasher.Params{
+			Memory:      viper.GetUint32(config.AuthArgon2idMemory),
+			Iterations:  viper.GetUint32(config.AuthArgon2idIterations),
+			Parallelism: uint8(viper.GetUint(config.AuthArgon2idParallelism)),
+		}),
+	}
+	var passwordHasher pHasher.Hasher
+	switch viper.GetString(config.AuthPasswordHasher) {
+	case config.HasherBcrypt:
+		passwordHasher = multiHasher.New(bcryptFormat, argon2idFormat)
+	case config.HasherArgon2id:
+		passwordHasher = multiHasher.New(argon2idFormat, bcryptFormat)
+	default:
+		logger.Error("Unknown password hasher", zap.String("hasher", viper.GetString(config.AuthPasswordHasher)))
 		os.Exit(1)
 	}
-	defer func() {
-		pgxPool.Close()
-		logger.Info("Postgres connection closed")
-	}()
+
+	// ===== Database =====
+	var pgxPool *pgxpool.Pool
+	var usersRepo pUser.Repository
+	var sessionsRepo pSession.Repository
+	var resetTokensRepo pResetToken.Repository
+	var invitesRepo pInvite.Repository
+	var twoFactorRepo pTwoFactor.Repository
+	var signingKeysRepo pSigningKey.Repository
+	var teamsRepo pTeam.Repository
+	var apiKeysRepo pAPIKey.Repository
+	var bannerRepo pBannerRepo.Repository
+	var tenantsRepo pTenant.Repository
+	switch viper.GetString(config.StorageBackend) {
+	case config.BackendMemory:
+		logger.Info("Using in-memory storage")
+		usersRepo = userMemoryRepository.New(logger)
+		sessionsRepo = sessionMemoryRepository.New(logger)
+		resetTokensRepo = resetTokenMemoryRepository.New(logger)
+		invitesRepo = inviteMemoryRepository.New(logger)
+		twoFactorRepo = twoFactorMemoryRepository.New(logger)
+		signingKeysRepo = signingKeyMemoryRepository.New(logger)
+		teamsRepo = teamMemoryRepository.New(logger)
+		apiKeysRepo = apiKeyMemoryRepository.New(logger)
+		bannerRepo = bannerMemoryRepository.New(logger)
+		tenantsRepo = tenantMemoryRepository.New(logger)
+		if viper.GetString(config.MemoryAdminPassword) == "" {
+			logger.Error("MEMORY_ADMIN_PASSWORD must be set with the memory storage backend")
+			os.Exit(1)
+		}
+		if err = createMemoryAdmin(ctx, usersRepo, passwordHasher); err != nil {
+			logger.Error("Failed to create admin", zap.Error(err))
+			os.Exit(1)
+		}
+	default:
+		pgxPool, err = postgres.NewPgx(logger)
+		if err != nil {
+			os.Exit(1)
+		}
+		defer func() {
+			pgxPool.Close()
+			logger.Info("Postgres connection closed")
+		}()
+
+		usersRepo = userRepository.New(pgxPool, logger)
+		sessionsRepo = sessionRepository.New(pgxPool, logger)
+		resetTokensRepo = resetTokenRepository.New(pgxPool, logger)
+		invitesRepo = inviteRepository.New(pgxPool, logger)
+		twoFactorRepo = twoFactorRepository.New(pgxPool, logger)
+		signingKeysRepo = signingKeyRepository.New(pgxPool, logger)
+		teamsRepo = teamRepository.New(pgxPool, logger)
+		apiKeysRepo = apiKeyRepository.New(pgxPool, logger)
+		bannerRepo = bannerRepository.New(pgxPool, logger)
+		tenantsRepo = tenantRepository.New(pgxPool, logger)
+	}
+
+	// ===== Redis =====
+	// Used by the Redis cache and the access tokens deny list.
+	var redisClient redis.UniversalClient
+	if viper.GetString(config.CacheBackend) != config.BackendMemory {
+		redisClient = storage.NewRedisClient(logger)
+		defer func() {
+			err = redisClient.Close()
+			if err != nil {
+				logger.Error("Failed to close Redis connection", zap.Error(err))
+			} else {
+				logger.Info("Redis connection closed")
+			}
+		}()
+	}
 
 	// ===== Cache =====
-	ctx := context.Background()
-	redisClient, err := storage.NewRedis(logger, ctx)
+	cacheCodec, err := pCache.NewCodec(viper.GetString(config.CacheCompression), viper.GetInt(config.CacheCompressionThreshold))
 	if err != nil {
+		logger.Error("Failed to create cache codec", zap.Error(err))
 		os.Exit(1)
 	}
-	defer func() {
-		err = redisClient.Close()
+
+	var cache pCache.Cache
+	var remote *cacheBackend.Backend
+	switch {
+	case viper.GetBool(config.SnapshotEnabled):
+		logger.Info("Cache disabled, banners are served from snapshot")
+		cache = noopCache.New()
+	case viper.GetString(config.CacheBackend) == config.BackendMemory:
+		logger.Info("Using in-memory cache")
+		cache = memoryCache.New(logger)
+	default:
+		remote, err = cacheBackend.New(viper.GetString(config.CacheBackend), redisClient, cacheCodec, logger)
 		if err != nil {
-			logger.Error("Failed to close Redis connection", zap.Error(err))
+			logger.Error("Failed to create cache", zap.Error(err))
+			os.Exit(1)
+		}
+		defer func() {
+			if err = remote.Close(); err != nil {
+				logger.Error("Failed to close cache connections", zap.Error(err))
+			}
+		}()
+
+		cacheBreaker := breaker.New(
+			remote.Cache,
+			remote.Ping,
+			viper.GetInt(config.CacheBreakerThreshold),
+			viper.GetDuration(config.CacheBreakerProbeInterval),
+			logger,
+		)
+		if err = remote.Ping(ctx); err != nil {
+			logger.Warn("Cache is unavailable, starting without cache", zap.String("backend", remote.Name), zap.Error(err))
+			cacheBreaker.Open()
 		} else {
-			logger.Info("Redis connection closed")
+			logger.Info("Cache connected", zap.String("backend", remote.Name))
 		}
-	}()
+		loops.Go(func() { cacheBreaker.Run(ctx) })
+		cache = cacheBreaker
 
-	cache := redisCache.New(redisClient, logger)
-	usersRepo := userRepository.New(pgxPool, logger)
-	bannerRepo := bannerRepository.New(pgxPool, logger)
+		if remote.Name == config.BackendRedis && viper.GetBool(config.CacheLocalEnabled) {
+			logger.Info("Using local cache in front of Redis")
+			localCache := memoryCache.NewWithExpiration(viper.GetDuration(config.CacheLocalTTL), logger)
+			bus := invalidation.New(redisClient, localCache, logger)
+			loops.Go(func() { bus.Run(ctx) })
 
-	authUC := authUsecase.New(usersRepo, logger)
-	bannerUC := bannerUsecase.New(bannerRepo, logger)
+			cache = tieredCache.New(localCache, cache, bus, logger)
+			bannerRepo = invalidatingRepository.New(bannerRepo, cache, logger)
+		}
+	}
+
+	// ===== Health =====
+	// Without Postgres banners can't be served, without the cache they are served
+	// uncached. Redis also keeps the deny list when the cache is in Memcached.
+	var dependencies []health.Dependency
+	if pgxPool != nil {
+		dependencies = append(dependencies, health.Dependency{Name: "postgres", Hard: true, Ping: pgxPool.Ping})
+	}
+	if remote != nil {
+		dependencies = append(dependencies, health.Dependency{Name: remote.Name, Ping: remote.Ping})
+	}
+	if redisClient != nil && (remote == nil || remote.Name != config.BackendRedis) {
+		dependencies = append(dependencies, health.Dependency{
+			Name: "redis",
+			Ping: func(ctx context.Context) error { return redisClient.Ping(ctx).Err() },
+		})
+	}
+	readiness := health.NewReadiness(viper.GetDuration(config.ServerHealthCheckTimeout), dependencies...)
+
+	// ===== Snapshot / Cache warm-up =====
+	if viper.GetBool(config.SnapshotEnabled) {
+		snap := snapshot.New(bannerRepo, pgxPool, viper.GetDuration(config.SnapshotReloadInterval), logger)
+		loops.Go(func() { snap.Run(ctx) })
+		go func() {
+			<-snap.Loaded()
+			readiness.SetReady(true)
+		}()
+		bannerRepo = snap
+	} else if viper.GetBool(config.CacheWarmupEnabled) {
+		warmer := warmup.New(bannerRepo, cache, viper.GetInt(config.CacheWarmupConcurrency), logger)
+		loops.Go(func() {
+			warmer.WarmUp(ctx, viper.GetDuration(config.CacheWarmupTimeout), readiness)
+		})
+	} else {
+		readiness.SetReady(true)
+	}
+
+	// ===== Deny list =====
+	var denyList denylist.DenyList
+	var signInAttempts attempts.Counter
+	if redisClient != nil {
+		denyList = cachedDenyList.New(redisDenyList.New(redisClient, logger),
+			viper.GetDuration(config.AuthDenyListCacheTTL), viper.GetDuration(config.AuthDenyListMaxStaleness), logger)
+		signInAttempts = redisAttempts.New(redisClient, logger)
+	} else {
+		denyList = memoryDenyList.New()
+		signInAttempts = memoryAttempts.New()
+	}
+
+	// ===== Credential policy =====
+	usernamePattern, err := regexp.Compile(viper.GetString(config.AuthUsernamePattern))
+	if err != nil {
+		logger.Error("Invalid username pattern", zap.Error(err))
+		os.Exit(1)
+	}
+	credentials := policy.New(policy.Config{
+		UsernameMinLength:     viper.GetInt(config.AuthUsernameMinLength),
+		UsernameMaxLength:     viper.GetInt(config.AuthUsernameMaxLength),
+		UsernamePattern:       usernamePattern,
+		ReservedUsernames:     viper.GetStringSlice(config.AuthReservedUsernames),
+		PasswordMinLength:     viper.GetInt(config.AuthPasswordMinLength),
+		PasswordMinClasses:    viper.GetInt(config.AuthPasswordMinClasses),
+		RejectCommonPasswords: viper.GetBool(config.AuthRejectCommonPasswords),
+	})
+
+	// ===== Signing keys =====
+	encryptionKey, err := base64.StdEncoding.DecodeString(viper.GetString(config.AuthSigningKeysEncryptionKey))
+	if err != nil || len(encryptionKey) == 0 {
+		logger.Error("AUTH_SIGNING_KEYS_ENCRYPTION_KEY must be set to a base64-encoded 32-byte key", zap.Error(err))
+		os.Exit(1)
+	}
+	keys, err := keyring.New(signingKeysRepo, tenantsRepo, keyring.Config{
+		Algorithm:        viper.GetString(config.AuthSigningAlgorithm),
+		EncryptionKey:    encryptionKey,
+		RotationInterval: viper.GetDuration(config.AuthSigningKeyRotation),
+		ReloadInterval:   viper.GetDuration(config.AuthSigningKeysReload),
+		TokenTTL:         viper.GetDuration(config.AuthAccessTokenTTL),
+	}, logger)
+	if err != nil {
+		logger.Error("Failed to create keyring", zap.Error(err))
+		os.Exit(1)
+	}
+	if err = keys.Rotate(ctx); err != nil {
+		logger.Error("Failed to load signing keys", zap.Error(err))
+		os.Exit(1)
+	}
+	loops.Go(func() { keys.Run(ctx) })
+
+	otp := totp.New(totp.Config{
+		Issuer: viper.GetString(config.AuthTwoFactorIssuer),
+		Skew:   viper.GetInt(config.AuthTwoFactorSkew),
+	})
+
+	authUC := authUsecase.New(usersRepo, sessionsRepo, resetTokensRepo, invitesRepo, twoFactorRepo, denyList,
+		signInAttempts, credentials, passwordHasher, keys, otp, logger)
+	bannerUC := bannerUsecase.New(bannerRepo, teamsRepo, logger)
+	teamUC := teamUsecase.New(teamsRepo, logger)
+	apiKeyUC := apiKeyUsecase.New(apiKeysRepo, viper.GetDuration(config.AuthAPIKeyCacheTTL), logger)
+	userUC := userUsecase.New(usersRepo, authUC, logger)
+	tenantUC := tenantUsecase.New(tenantsRepo, usersRepo, credentials, passwordHasher, keys, logger)
+
+	// ===== TLS =====
+	var tlsConfig *tls.Config
+	var services *mtls.Services
+	if viper.GetString(config.ServerTLSCertFile) != "" {
+		tlsConfig, err = mtls.ServerTLSConfig(mtls.Config{
+			CertFile:          viper.GetString(config.ServerTLSCertFile),
+			KeyFile:           viper.GetString(config.ServerTLSKeyFile),
+			ClientCAFile:      viper.GetString(config.ServerTLSClientCAFile),
+			RequireClientCert: viper.GetBool(config.ServerTLSRequireClientCert),
+		})
+		if err != nil {
+			logger.Error("Failed to configure TLS", zap.Error(err))
+			os.Exit(1)
+		}
+	}
+	if tlsConfig != nil && tlsConfig.ClientCAs != nil {
+		var serviceConfigs []mtls.Service
+		if err = viper.UnmarshalKey(config.ServerTLSClientServices, &serviceConfigs); err == nil {
+			services, err = mtls.NewServices(serviceConfigs)
+		}
+		if err != nil {
+			logger.Error("Failed to read client certificate services", zap.Error(err))
+			os.Exit(1)
+		}
+		logger.Info("Client certificate authentication enabled", zap.Int("services", len(serviceConfigs)))
+	}
 
 	// ===== Server =====
-	checkAuth := mw.NewCheckAuth(logger)
-	checkAdminAccess := mw.NewCheckAdminAccess(logger)
+	trustedProxies, err := pHTTP.ParseTrustedProxies(viper.GetStringSlice(config.ServerTrustedProxies))
+	if err != nil {
+		logger.Error("Failed to read trusted proxies", zap.Error(err))
+		os.Exit(1)
+	}
+	checkAuth := mw.NewCheckAuth(denyList, apiKeyUC, keys, services, logger)
+	checkPermission := mw.NewCheckPermission(logger)
 	accessLog := mw.NewAccessLog(logger)
 	panicCatch := mw.NewPanicCatch(logger)
+	tenantScope := mw.NewTenant()
+
+	cacheStats := pCache.NewStats()
+	cacheWrites := new(background.Group)
 
 	router := mux.NewRouter()
 
-	authDelivery.RegisterHandlers(router, authUC, logger)
-	bannerDelivery.RegisterHandlers(router, bannerUC, cache, logger, checkAuth, checkAdminAccess)
+	healthDelivery.RegisterHandlers(router, readiness, logger)
+	authDelivery.RegisterHandlers(router, authUC, trustedProxies, logger, checkAuth)
+	keyringDelivery.RegisterHandlers(router, keys, logger)
+	bannerDelivery.RegisterHandlers(router, bannerUC, cache, cacheStats, cacheWrites, logger, checkAuth, checkPermission)
+	cacheDelivery.RegisterHandlers(router, cache, cacheStats, logger, checkAuth, checkPermission)
+	teamDelivery.RegisterHandlers(router, teamUC, logger, checkAuth, checkPermission)
+	apiKeyDelivery.RegisterHandlers(router, apiKeyUC, logger, checkAuth, checkPermission)
+	userDelivery.RegisterHandlers(router, userUC, logger, checkAuth, checkPermission)
+	tenantDelivery.RegisterHandlers(router, tenantUC, logger, checkAuth, checkPermission)
 
 	server := http.Server{
-		Addr:    ":" + viper.GetString(config.ServerPort),
-		Handler: panicCatch(accessLog(router)),
+		Addr:      ":" + viper.GetString(config.ServerPort),
+		Handler:   panicCatch(accessLog(tenantScope(router))),
+		TLSConfig: tlsConfig,
 	}
 
-	logger.Info("API server started", zap.String("port", viper.GetString(config.ServerPort)))
-	if err = server.ListenAndServe(); err != nil {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if tlsConfig != nil {
+			// Certificates are taken from TLSConfig.
+			serverErr <- server.ListenAndServeTLS("", "")
+		} else {
+			serverErr <- server.ListenAndServe()
+		}
+	}()
+	logger.Info("API server started", zap.String("port", viper.GetString(config.ServerPort)),
+		zap.Bool("tls", tlsConfig != nil))
+
+	select {
+	case err = <-serverErr:
 		logger.Error("API server stopped", zap.Error(err))
+		return
+	case sig := <-signals:
+		logger.Info("Shutting down API server", zap.String("signal", sig.String()))
+	}
+
+	// Load balancers stop routing to the instance while it still serves requests.
+	readiness.Drain()
+	time.Sleep(viper.GetDuration(config.ServerShutdownDelay))
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), viper.GetDuration(config.ServerShutdownTimeout))
+	defer cancelShutdown()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to drain in-flight requests", zap.Error(err))
+	}
+	if err = cacheWrites.Wait(shutdownCtx); err != nil {
+		logger.Error("Failed to finish cache writes", zap.Error(err))
+	}
+	cancel()
+	if err = loops.Wait(shutdownCtx); err != nil {
+		logger.Error("Failed to stop background loops", zap.Error(err))
+	}
+	// The pgx pool and Redis client are closed by the deferred calls above.
+	logger.Info("API server stopped")
+}
+
+func createMemoryAdmin(ctx context.Context, usersRepo pUser.Repository, hasher pHasher.Hasher) error {
+	password, err := hasher.GetHashedPassword(ctx, viper.GetString(config.MemoryAdminPassword))
+	if err != nil {
+		return err
 	}
+	_, err = usersRepo.Create(ctx, &pUser.CreateParams{
+		Username: viper.GetString(config.MemoryAdminUsername),
+		Password: password,
+		Role:     models.RoleSuperAdmin,
+	})
+	return err
 }
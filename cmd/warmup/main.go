@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	cacheBackend "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/backend"
+	bannerRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/pgx"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/warmup"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/storage"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/storage/postgres"
+)
+
+// Pre-populates the banners cache and exits. Intended to be run as a deploy hook.
+func main() {
+	// ===== Logger =====
+	logger := pLog.NewDev()
+	defer func() {
+		err := logger.Sync()
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+
+	// ===== Configuration =====
+	config.SetAPIDefaults()
+	viper.SetConfigName("api")
+	viper.SetConfigType("yaml")
+	viper.AddConfigPath("/config")
+	err := viper.ReadInConfig()
+	if err != nil {
+		logger.Error("Failed to read configuration", zap.Error(err))
+		os.Exit(1)
+	}
+
+	// ===== Database =====
+	pgxPool, err := postgres.NewPgx(logger)
+	if err != nil {
+		os.Exit(1)
+	}
+	defer pgxPool.Close()
+
+	// ===== Cache =====
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration(config.CacheWarmupTimeout))
+	defer cancel()
+	cacheCodec, err := pCache.NewCodec(viper.GetString(config.CacheCompression), viper.GetInt(config.CacheCompressionThreshold))
+	if err != nil {
+		logger.Error("Failed to create cache codec", zap.Error(err))
+		os.Exit(1) // nolint
+	}
+
+	// The memory cache belongs to the api process, there is nothing to warm up from here.
+	var redisClient redis.UniversalClient
+	if viper.GetString(config.CacheBackend) == config.BackendRedis {
+		redisClient = storage.NewRedisClient(logger)
+		defer redisClient.Close() // nolint
+	}
+	remote, err := cacheBackend.New(viper.GetString(config.CacheBackend), redisClient, cacheCodec, logger)
+	if err != nil {
+		logger.Error("Failed to create cache", zap.Error(err))
+		os.Exit(1) // nolint
+	}
+	defer remote.Close() // nolint
+	if err = remote.Ping(ctx); err != nil {
+		logger.Error("Cache is unavailable", zap.String("backend", remote.Name), zap.Error(err))
+		os.Exit(1) // nolint
+	}
+
+	bannerRepo := bannerRepository.New(pgxPool, logger)
+
+	warmer := warmup.New(bannerRepo, remote.Cache, viper.GetInt(config.CacheWarmupConcurrency), logger)
+	if err = warmer.Run(ctx); err != nil {
+		os.Exit(1) // nolint
+	}
+}
@@ -3,10 +3,12 @@ module github.com/SlavaShagalov/avito-intern-task
 go 1.20
 
 require (
+	github.com/bradfitz/gomemcache v0.0.0-20230905024940-24af94b03874
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/gorilla/mux v1.8.1
 	github.com/jackc/pgerrcode v0.0.0-20240316143900-6e2875d9b438
 	github.com/jackc/pgx/v5 v5.5.5
+	github.com/klauspost/compress v1.17.4
 	github.com/ozontech/allure-go/pkg/framework v0.6.30-0.20240320124242-dd7f2ab15350
 	github.com/ozontech/cute v0.1.19
 	github.com/pkg/errors v0.9.1
@@ -15,6 +17,7 @@ require (
 	github.com/stretchr/testify v1.8.4
 	go.uber.org/zap v1.27.0
 	golang.org/x/crypto v0.17.0
+	golang.org/x/sync v0.5.0
 )
 
 require (
@@ -46,7 +49,6 @@ require (
 	github.com/xeipuuv/gojsonschema v1.2.0 // indirect
 	go.uber.org/multierr v1.10.0 // indirect
 	golang.org/x/exp v0.0.0-20230905200255-921286631fa9 // indirect
-	golang.org/x/sync v0.5.0 // indirect
 	golang.org/x/sys v0.16.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/ini.v1 v1.67.0 // indirect
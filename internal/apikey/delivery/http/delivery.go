@@ -0,0 +1,87 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	pAPIKey "github.com/SlavaShagalov/avito-intern-task/internal/apikey"
+	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type delivery struct {
+	uc  pAPIKey.Usecase
+	log *zap.Logger
+}
+
+func RegisterHandlers(mux *mux.Router, uc pAPIKey.Usecase, log *zap.Logger, checkAuth mw.Middleware, checkPermission mw.PermissionMiddleware) {
+	dlv := delivery{
+		uc:  uc,
+		log: log,
+	}
+
+	const (
+		apiKeysPath = constants.ApiPrefix + "/api_keys"
+		apiKeyPath  = apiKeysPath + "/{id}"
+	)
+
+	manage := checkPermission(models.PermissionAPIKeyManage)
+	mux.HandleFunc(apiKeysPath, checkAuth(manage(dlv.create))).Methods(http.MethodPost)
+	mux.HandleFunc(apiKeysPath, checkAuth(manage(dlv.list))).Methods(http.MethodGet)
+	mux.HandleFunc(apiKeyPath, checkAuth(manage(dlv.revoke))).Methods(http.MethodDelete)
+}
+
+func (d *delivery) create(w http.ResponseWriter, r *http.Request) {
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request createRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	params := pAPIKey.CreateKeyParams{
+		Name:      request.Name,
+		Scopes:    request.Scopes,
+		ExpiresAt: request.ExpiresAt,
+	}
+	key, secret, err := d.uc.Create(r.Context(), mw.GetPrincipal(r.Context()), &params)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusCreated, newCreateResponse(key, secret))
+}
+
+func (d *delivery) list(w http.ResponseWriter, r *http.Request) {
+	keys, err := d.uc.List(r.Context())
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusOK, newListResponse(keys))
+}
+
+func (d *delivery) revoke(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrBadAPIKeyIDParam)
+		return
+	}
+
+	if err = d.uc.Revoke(r.Context(), id); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
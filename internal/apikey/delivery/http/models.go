@@ -0,0 +1,60 @@
+package http
+
+import (
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"time"
+)
+
+// API requests
+type createRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// API responses
+type apiKey struct {
+	ID         int64      `json:"api_key_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  int64      `json:"created_by,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type createResponse struct {
+	apiKey
+	Key string `json:"key"`
+}
+
+func newAPIKey(key *models.APIKey) apiKey {
+	return apiKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedBy:  key.CreatedBy,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func newCreateResponse(key *models.APIKey, secret string) *createResponse {
+	return &createResponse{
+		apiKey: newAPIKey(key),
+		Key:    secret,
+	}
+}
+
+func newListResponse(keys []models.APIKey) []apiKey {
+	response := make([]apiKey, 0, len(keys))
+	for i := range keys {
+		response = append(response, newAPIKey(&keys[i]))
+	}
+	return response
+}
@@ -0,0 +1,25 @@
+package apikey
+
+import (
+	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"time"
+)
+
+type CreateParams struct {
+	Name      string
+	KeyHash   string
+	Prefix    string
+	Scopes    []string
+	CreatedBy int64
+	ExpiresAt *time.Time
+}
+
+type Repository interface {
+	Create(ctx context.Context, params *CreateParams) (*models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	// GetByHash finds keys of all tenants: the key tells its tenant.
+	GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+	Touch(ctx context.Context, id int64, usedAt time.Time) error
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	pAPIKey "github.com/SlavaShagalov/avito-intern-task/internal/apikey"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	mu     sync.RWMutex
+	lastID int64
+	keys   map[int64]*models.APIKey
+	// ids maps key hashes to key ids.
+	ids map[string]int64
+	log *zap.Logger
+}
+
+func New(log *zap.Logger) pAPIKey.Repository {
+	return &repository{
+		keys: make(map[int64]*models.APIKey),
+		ids:  make(map[string]int64),
+		log:  log,
+	}
+}
+
+func (repo *repository) Create(ctx context.Context, params *pAPIKey.CreateParams) (*models.APIKey, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.ids[params.KeyHash]; exists {
+		// Violates the unique constraint in Postgres.
+		return nil, pErrors.ErrDb
+	}
+
+	repo.lastID++
+	key := &models.APIKey{
+		ID:        repo.lastID,
+		TenantID:  tenant.ID(ctx),
+		Name:      params.Name,
+		Prefix:    params.Prefix,
+		Scopes:    append([]string{}, params.Scopes...),
+		CreatedBy: params.CreatedBy,
+		ExpiresAt: params.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	repo.keys[key.ID] = key
+	repo.ids[params.KeyHash] = key.ID
+
+	repo.log.Debug("API key created", zap.Int64("api_key_id", key.ID))
+	return copyKey(key), nil
+}
+
+func (repo *repository) List(ctx context.Context) ([]models.APIKey, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	keys := make([]models.APIKey, 0, len(repo.keys))
+	for _, key := range repo.keys {
+		if key.TenantID == tenant.ID(ctx) {
+			keys = append(keys, *copyKey(key))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (repo *repository) GetByHash(_ context.Context, keyHash string) (*models.APIKey, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	id, exists := repo.ids[keyHash]
+	if !exists {
+		return nil, pErrors.ErrAPIKeyNotFound
+	}
+	return copyKey(repo.keys[id]), nil
+}
+
+func (repo *repository) Revoke(ctx context.Context, id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key, exists := repo.keys[id]
+	if !exists || key.TenantID != tenant.ID(ctx) || key.RevokedAt != nil {
+		return pErrors.ErrAPIKeyNotFound
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+
+	repo.log.Debug("API key revoked", zap.Int64("api_key_id", id))
+	return nil
+}
+
+func (repo *repository) Touch(_ context.Context, id int64, usedAt time.Time) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if key, exists := repo.keys[id]; exists {
+		key.LastUsedAt = &usedAt
+	}
+	return nil
+}
+
+func copyKey(key *models.APIKey) *models.APIKey {
+	c := *key
+	c.Scopes = append([]string{}, key.Scopes...)
+	return &c
+}
@@ -0,0 +1,153 @@
+package pgx
+
+import (
+	"context"
+	"time"
+
+	pAPIKey "github.com/SlavaShagalov/avito-intern-task/internal/apikey"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	pool *pgxpool.Pool
+	log  *zap.Logger
+}
+
+func New(pool *pgxpool.Pool, log *zap.Logger) pAPIKey.Repository {
+	return &repository{
+		pool: pool,
+		log:  log,
+	}
+}
+
+const returningPart = `
+	RETURNING id, tenant_id, name, prefix, scopes, COALESCE(created_by, 0), expires_at, last_used_at, revoked_at, created_at;`
+
+const createCmd = `
+	INSERT INTO api_keys (tenant_id, name, key_hash, prefix, scopes, created_by, expires_at)
+	VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), $7)` + returningPart
+
+func (repo *repository) Create(ctx context.Context, params *pAPIKey.CreateParams) (*models.APIKey, error) {
+	row := repo.pool.QueryRow(ctx, createCmd,
+		tenant.ID(ctx),
+		params.Name,
+		params.KeyHash,
+		params.Prefix,
+		params.Scopes,
+		params.CreatedBy,
+		params.ExpiresAt,
+	)
+	key, err := scanKey(row)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	repo.log.Debug("API key created", zap.Int64("api_key_id", key.ID))
+	return key, nil
+}
+
+const selectKeysPart = `
+	SELECT id, tenant_id, name, prefix, scopes, COALESCE(created_by, 0), expires_at, last_used_at, revoked_at, created_at
+	FROM api_keys`
+
+const listCmd = selectKeysPart + `
+	WHERE tenant_id = $1
+	ORDER BY id;`
+
+func (repo *repository) List(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := repo.pool.Query(ctx, listCmd, tenant.ID(ctx))
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0, 4)
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			repo.log.Error(constants.DBError, zap.Error(err))
+			return nil, pErrors.ErrDb
+		}
+		keys = append(keys, *key)
+	}
+	if err = rows.Err(); err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return keys, nil
+}
+
+const getByHashCmd = selectKeysPart + `
+	WHERE key_hash = $1;`
+
+func (repo *repository) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	key, err := scanKey(repo.pool.QueryRow(ctx, getByHashCmd, keyHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pErrors.ErrAPIKeyNotFound
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return key, nil
+}
+
+const revokeCmd = `
+	UPDATE api_keys
+	SET revoked_at = now()
+	WHERE id = $1
+	  AND tenant_id = $2
+	  AND revoked_at IS NULL;`
+
+func (repo *repository) Revoke(ctx context.Context, id int64) error {
+	res, err := repo.pool.Exec(ctx, revokeCmd, id, tenant.ID(ctx))
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if res.RowsAffected() == 0 {
+		return pErrors.ErrAPIKeyNotFound
+	}
+	repo.log.Debug("API key revoked", zap.Int64("api_key_id", id))
+	return nil
+}
+
+const touchCmd = `
+	UPDATE api_keys
+	SET last_used_at = $2
+	WHERE id = $1;`
+
+func (repo *repository) Touch(ctx context.Context, id int64, usedAt time.Time) error {
+	_, err := repo.pool.Exec(ctx, touchCmd, id, usedAt)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	return nil
+}
+
+func scanKey(row pgx.Row) (*models.APIKey, error) {
+	key := new(models.APIKey)
+	err := row.Scan(
+		&key.ID,
+		&key.TenantID,
+		&key.Name,
+		&key.Prefix,
+		&key.Scopes,
+		&key.CreatedBy,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+		&key.CreatedAt,
+	)
+	return key, err
+}
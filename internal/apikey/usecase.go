@@ -0,0 +1,21 @@
+package apikey
+
+import (
+	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"time"
+)
+
+type CreateKeyParams struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type Usecase interface {
+	// Create returns the key itself only once, it can't be restored later.
+	Create(ctx context.Context, principal *models.Principal, params *CreateKeyParams) (*models.APIKey, string, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id int64) error
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+
+	pAPIKey "github.com/SlavaShagalov/avito-intern-task/internal/apikey"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	keyPrefix  = "ak_"
+	keySize    = 32
+	prefixSize = len(keyPrefix) + 8
+
+	// lastUsedResolution limits writes made to track key usage.
+	lastUsedResolution = time.Minute
+)
+
+type cachedKey struct {
+	apiKey   *models.APIKey
+	cachedAt time.Time
+}
+
+// usecase keeps authenticated keys in memory for cacheTTL, so that requests
+// made with them don't touch the repository. Keys revoked on other instances
+// stay valid there for up to cacheTTL.
+type usecase struct {
+	repo     pAPIKey.Repository
+	cacheTTL time.Duration
+	log      *zap.Logger
+
+	mu        sync.Mutex
+	cache     map[string]cachedKey
+	lastSweep time.Time
+}
+
+func New(repo pAPIKey.Repository, cacheTTL time.Duration, log *zap.Logger) pAPIKey.Usecase {
+	return &usecase{
+		repo:      repo,
+		cacheTTL:  cacheTTL,
+		log:       log,
+		cache:     make(map[string]cachedKey),
+		lastSweep: time.Now(),
+	}
+}
+
+func (uc *usecase) Create(ctx context.Context, principal *models.Principal, params *pAPIKey.CreateKeyParams) (*models.APIKey, string, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return nil, "", pErrors.ErrBadNameField
+	}
+	if len(params.Scopes) == 0 {
+		return nil, "", pErrors.ErrBadScopesField
+	}
+	for _, scope := range params.Scopes {
+		if !knownPermission(scope) {
+			return nil, "", errors.Wrapf(pErrors.ErrBadScopesField, "unknown scope %q", scope)
+		}
+		// Nobody can hand out more than they have.
+		if !principal.Can(scope) {
+			return nil, "", pErrors.ErrPermissionDenied
+		}
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(time.Now()) {
+		return nil, "", pErrors.ErrBadExpiresAtField
+	}
+
+	b := make([]byte, keySize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	apiKey, err := uc.repo.Create(ctx, &pAPIKey.CreateParams{
+		Name:      name,
+		KeyHash:   hashKey(key),
+		Prefix:    key[:prefixSize],
+		Scopes:    params.Scopes,
+		CreatedBy: principal.UserID,
+		ExpiresAt: params.ExpiresAt,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	uc.log.Info("API key created", zap.Int64("api_key_id", apiKey.ID), zap.Int64("user_id", principal.UserID))
+	return apiKey, key, nil
+}
+
+func (uc *usecase) List(ctx context.Context) ([]models.APIKey, error) {
+	return uc.repo.List(ctx)
+}
+
+func (uc *usecase) Revoke(ctx context.Context, id int64) error {
+	if err := uc.repo.Revoke(ctx, id); err != nil {
+		return err
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	for hash, cached := range uc.cache {
+		if cached.apiKey.ID == id {
+			delete(uc.cache, hash)
+		}
+	}
+	return nil
+}
+
+func (uc *usecase) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, pErrors.ErrInvalidAPIKey
+	}
+
+	hash := hashKey(key)
+	now := time.Now()
+	apiKey := uc.cached(hash, now)
+	if apiKey == nil {
+		var err error
+		apiKey, err = uc.repo.GetByHash(ctx, hash)
+		if err != nil {
+			if errors.Is(err, pErrors.ErrAPIKeyNotFound) {
+				return nil, pErrors.ErrInvalidAPIKey
+			}
+			return nil, err
+		}
+		uc.store(hash, apiKey, now)
+		if valid(apiKey, now) && (apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= lastUsedResolution) {
+			if err = uc.repo.Touch(ctx, apiKey.ID, now); err != nil {
+				uc.log.Warn("Failed to update API key last usage", zap.Int64("api_key_id", apiKey.ID), zap.Error(err))
+			}
+		}
+	}
+
+	if !valid(apiKey, now) {
+		return nil, pErrors.ErrInvalidAPIKey
+	}
+	return apiKey, nil
+}
+
+func valid(apiKey *models.APIKey, now time.Time) bool {
+	return apiKey.RevokedAt == nil && (apiKey.ExpiresAt == nil || apiKey.ExpiresAt.After(now))
+}
+
+func (uc *usecase) cached(hash string, now time.Time) *models.APIKey {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	cached, ok := uc.cache[hash]
+	if !ok || now.Sub(cached.cachedAt) >= uc.cacheTTL {
+		return nil
+	}
+	return cached.apiKey
+}
+
+// store also drops expired entries, at most once per cacheTTL.
+func (uc *usecase) store(hash string, apiKey *models.APIKey, now time.Time) {
+	if uc.cacheTTL <= 0 {
+		return
+	}
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.cache[hash] = cachedKey{apiKey: apiKey, cachedAt: now}
+	if now.Sub(uc.lastSweep) < uc.cacheTTL {
+		return
+	}
+	uc.lastSweep = now
+	for h, cached := range uc.cache {
+		if now.Sub(cached.cachedAt) >= uc.cacheTTL {
+			delete(uc.cache, h)
+		}
+	}
+}
+
+func knownPermission(permission string) bool {
+	for _, permissions := range models.DefaultRolePermissions {
+		if models.HasPermission(permissions, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashKey is enough for API keys: unlike passwords they have full entropy.
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,39 @@
+package attempts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const keyPrefix = "signin:"
+
+// Counter tracks failed sign-in attempts.
+type Counter interface {
+	// Fail counts a failed attempt and returns the number of failures since
+	// the key was reset. Failures are forgotten after window without new ones.
+	Fail(ctx context.Context, key string, window time.Duration) (int64, error)
+	// Block rejects attempts for the key during ttl.
+	Block(ctx context.Context, key string, ttl time.Duration) error
+	// Blocked returns the longest remaining block of the keys.
+	Blocked(ctx context.Context, keys ...string) (time.Duration, error)
+	// Reset forgets failures of the key and lifts its block.
+	Reset(ctx context.Context, key string) error
+}
+
+// UserKey counts attempts to sign in as the user of the tenant, existing or not.
+func UserKey(tenantID int64, username string) string {
+	return fmt.Sprintf("%suser:%d:%s", keyPrefix, tenantID, strings.ToLower(username))
+}
+
+// IPKey counts attempts made from the address.
+func IPKey(ip string) string {
+	return fmt.Sprintf("%sip:%s", keyPrefix, ip)
+}
+
+// TwoFactorKey counts wrong second factor codes of the user. Unlike UserKey
+// it isn't reset by a right password.
+func TwoFactorKey(userID int64) string {
+	return fmt.Sprintf("%stwo-factor:%d", keyPrefix, userID)
+}
@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/attempts"
+)
+
+type entry struct {
+	failures     int64
+	expiresAt    time.Time
+	blockedUntil time.Time
+}
+
+type counter struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func New() attempts.Counter {
+	return &counter{
+		entries: make(map[string]*entry),
+	}
+}
+
+func (c *counter) Fail(_ context.Context, key string, window time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	c.cleanup(now)
+	e, ok := c.entries[key]
+	if !ok {
+		e = &entry{}
+		c.entries[key] = e
+	}
+	if now.After(e.expiresAt) {
+		e.failures = 0
+	}
+	e.failures++
+	e.expiresAt = now.Add(window)
+	return e.failures, nil
+}
+
+func (c *counter) Block(_ context.Context, key string, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		e = &entry{}
+		c.entries[key] = e
+	}
+	e.blockedUntil = time.Now().Add(ttl)
+	return nil
+}
+
+func (c *counter) Blocked(_ context.Context, keys ...string) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var blocked time.Duration
+	now := time.Now()
+	for _, key := range keys {
+		if e, ok := c.entries[key]; ok {
+			if left := e.blockedUntil.Sub(now); left > blocked {
+				blocked = left
+			}
+		}
+	}
+	return blocked, nil
+}
+
+func (c *counter) Reset(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+	return nil
+}
+
+func (c *counter) cleanup(now time.Time) {
+	for key, e := range c.entries {
+		if now.After(e.expiresAt) && now.After(e.blockedUntil) {
+			delete(c.entries, key)
+		}
+	}
+}
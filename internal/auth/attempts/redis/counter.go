@@ -0,0 +1,84 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/attempts"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	failuresSuffix = ":failures"
+	blockedSuffix  = ":blocked"
+)
+
+type counter struct {
+	rdb redis.UniversalClient
+	log *zap.Logger
+}
+
+func New(rdb redis.UniversalClient, log *zap.Logger) attempts.Counter {
+	return &counter{
+		rdb: rdb,
+		log: log,
+	}
+}
+
+func (c *counter) Fail(ctx context.Context, key string, window time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key+failuresSuffix)
+		pipe.PExpire(ctx, key+failuresSuffix, window)
+		return nil
+	})
+	if err != nil {
+		c.log.Error("Attempts: failed to count failure", zap.String("key", key), zap.Error(err))
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (c *counter) Block(ctx context.Context, key string, ttl time.Duration) error {
+	if err := c.rdb.Set(ctx, key+blockedSuffix, 1, ttl).Err(); err != nil {
+		c.log.Error("Attempts: failed to block key", zap.String("key", key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (c *counter) Blocked(ctx context.Context, keys ...string) (time.Duration, error) {
+	// Keys may be in different slots in cluster mode.
+	cmds, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.PTTL(ctx, key+blockedSuffix)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var blocked time.Duration
+	for _, cmd := range cmds {
+		// PTTL of a missing key is negative.
+		if ttl := cmd.(*redis.DurationCmd).Val(); ttl > blocked {
+			blocked = ttl
+		}
+	}
+	return blocked, nil
+}
+
+func (c *counter) Reset(ctx context.Context, key string) error {
+	_, err := c.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key+failuresSuffix)
+		pipe.Del(ctx, key+blockedSuffix)
+		return nil
+	})
+	if err != nil {
+		c.log.Error("Attempts: failed to reset key", zap.String("key", key), zap.Error(err))
+		return err
+	}
+	return nil
+}
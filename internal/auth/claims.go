@@ -0,0 +1,66 @@
+package auth
+
+import (
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultTokenIssuer   = "avito-banner-service"
+	DefaultTokenAudience = "avito-banner-service"
+)
+
+// Claims of access tokens. The expiration, issuer and audience are checked by
+// the parser.
+type Claims struct {
+	jwt.RegisteredClaims
+	UserID      int64    `json:"user_id"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+	SessionID   int64    `json:"sid"`
+	// TenantID is missing in tokens issued before tenants.
+	TenantID int64 `json:"tenant_id,omitempty"`
+}
+
+func (c *Claims) GetTenantID() int64 {
+	if c.TenantID == 0 {
+		return tenant.DefaultID
+	}
+	return c.TenantID
+}
+
+// Validate rejects tokens which can't be revoked: without an id or a session.
+func (c *Claims) Validate() error {
+	if c.ID == "" || c.UserID == 0 || c.SessionID == 0 {
+		return pErrors.ErrInvalidAuthToken
+	}
+	return nil
+}
+
+// ChallengeClaims of the tokens issued by sign in when the second factor is
+// required. Their audience differs from the one of access tokens, so they
+// aren't accepted instead of them.
+type ChallengeClaims struct {
+	jwt.RegisteredClaims
+	UserID   int64 `json:"user_id"`
+	TenantID int64 `json:"tenant_id"`
+	// Enroll allows to enroll the second factor with the token.
+	Enroll bool `json:"enroll,omitempty"`
+}
+
+func (c *ChallengeClaims) GetTenantID() int64 {
+	return c.TenantID
+}
+
+func (c *ChallengeClaims) Validate() error {
+	if c.UserID == 0 {
+		return pErrors.ErrInvalidChallengeToken
+	}
+	return nil
+}
+
+// ChallengeAudience is the audience of challenge tokens of the service.
+func ChallengeAudience(tokenAudience string) string {
+	return tokenAudience + "/two-factor"
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"github.com/SlavaShagalov/avito-intern-task/internal/auth"
+	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
 	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
 	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
@@ -12,24 +13,51 @@ import (
 )
 
 const (
-	authPrefix = "/auth"
-	signInPath = constants.ApiPrefix + authPrefix + "/signin"
-	signUpPath = constants.ApiPrefix + authPrefix + "/signup"
+	authPrefix  = "/auth"
+	signInPath  = constants.ApiPrefix + authPrefix + "/signin"
+	signUpPath  = constants.ApiPrefix + authPrefix + "/signup"
+	refreshPath = constants.ApiPrefix + authPrefix + "/refresh"
+	logoutPath  = constants.ApiPrefix + authPrefix + "/logout"
+	mePath      = constants.ApiPrefix + authPrefix + "/me"
+
+	passwordPath      = constants.ApiPrefix + authPrefix + "/password"
+	passwordResetPath = passwordPath + "/reset"
+
+	twoFactorPath        = constants.ApiPrefix + authPrefix + "/2fa"
+	twoFactorEnrollPath  = twoFactorPath + "/enroll"
+	twoFactorConfirmPath = twoFactorPath + "/confirm"
+	signInTwoFactorPath  = signInPath + "/2fa"
+	signInEnrollPath     = signInTwoFactorPath + "/enroll"
 )
 
 type delivery struct {
-	uc  auth.Usecase
-	log *zap.Logger
+	uc      auth.Usecase
+	proxies pHTTP.TrustedProxies
+	log     *zap.Logger
 }
 
-func RegisterHandlers(mux *mux.Router, uc auth.Usecase, log *zap.Logger) {
+// RegisterHandlers limits sign in attempts by the client address, which is
+// taken from forwarding headers only on connections from proxies.
+func RegisterHandlers(mux *mux.Router, uc auth.Usecase, proxies pHTTP.TrustedProxies, log *zap.Logger,
+	checkAuth mw.Middleware) {
 	del := delivery{
-		uc:  uc,
-		log: log,
+		uc:      uc,
+		proxies: proxies,
+		log:     log,
 	}
 
 	mux.HandleFunc(signUpPath, del.signup).Methods(http.MethodPost)
 	mux.HandleFunc(signInPath, del.signin).Methods(http.MethodPost)
+	mux.HandleFunc(refreshPath, del.refresh).Methods(http.MethodPost)
+	mux.HandleFunc(logoutPath, checkAuth(del.logout)).Methods(http.MethodPost)
+	mux.HandleFunc(mePath, checkAuth(del.me)).Methods(http.MethodGet)
+	mux.HandleFunc(passwordPath, checkAuth(del.changePassword)).Methods(http.MethodPost)
+	mux.HandleFunc(passwordResetPath, del.resetPassword).Methods(http.MethodPost)
+	mux.HandleFunc(twoFactorEnrollPath, checkAuth(del.enrollTwoFactor)).Methods(http.MethodPost)
+	mux.HandleFunc(twoFactorConfirmPath, checkAuth(del.confirmTwoFactor)).Methods(http.MethodPost)
+	mux.HandleFunc(twoFactorPath, checkAuth(del.disableTwoFactor)).Methods(http.MethodDelete)
+	mux.HandleFunc(signInTwoFactorPath, del.verifyTwoFactor).Methods(http.MethodPost)
+	mux.HandleFunc(signInEnrollPath, del.enrollTwoFactorOnSignIn).Methods(http.MethodPost)
 }
 
 // signup godoc
@@ -42,6 +70,7 @@ func RegisterHandlers(mux *mux.Router, uc auth.Usecase, log *zap.Logger) {
 //	@Param			signUpParams	body		SignUpRequest	true	"Sign up params."
 //	@Success		200				{object}	SignUpResponse	"Successfully created user."
 //	@Failure		400				{object}	http.JSONError
+//	@Failure		403				{object}	http.JSONError
 //	@Failure		405
 //	@Failure		500
 //	@Router			/auth/signup [post]
@@ -61,32 +90,36 @@ func (d *delivery) signup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	params := auth.SignUpParams{
-		Username: request.Username,
-		Password: request.Password,
+		Username:   request.Username,
+		Password:   request.Password,
+		InviteCode: request.InviteCode,
 	}
 
-	user, authToken, err := d.uc.SignUp(ctx, &params)
+	user, tokens, err := d.uc.SignUp(ctx, &params)
 	if err != nil {
 		pHTTP.HandleError(w, r, err)
 		return
 	}
-	w.Header().Add("token", authToken)
+	w.Header().Add("token", tokens.AccessToken)
 
-	response := newSignUpResponse(user)
+	response := newSignUpResponse(user, tokens)
 	pHTTP.SendJSON(w, r, http.StatusOK, response)
 }
 
 // signin godoc
 //
 //	@Summary		Logs in and returns the authentication cookie
-//	@Description	Logs in and returns the authentication cookie
+//	@Description	Logs in and returns the authentication cookie. When the second factor is required, a challenge
+//	@Description	token is returned instead, it's exchanged for tokens at /auth/signin/2fa.
 //	@Tags			auth
 //	@Accept			json
 //	@Produce		json
-//	@Param			signInParams	body		SignInRequest	true	"Successfully authenticated."
-//	@Success		200				{object}	SignInResponse	"successfully auth"
+//	@Param			signInParams	body		SignInRequest		true	"Successfully authenticated."
+//	@Success		200				{object}	SignInResponse		"successfully auth"
+//	@Success		202				{object}	ChallengeResponse	"Second factor required."
 //	@Failure		400				{object}	http.JSONError
-//	@Failure		404				{object}	http.JSONError
+//	@Failure		403				{object}	http.JSONError
+//	@Failure		429				{object}	http.JSONError
 //	@Failure		405
 //	@Failure		500
 //	@Router			/auth/signin [post]
@@ -107,15 +140,439 @@ func (d *delivery) signin(w http.ResponseWriter, r *http.Request) {
 	params := auth.SignInParams{
 		Username: request.Username,
 		Password: request.Password,
+		IP:       d.proxies.ClientIP(r),
+	}
+
+	user, tokens, challenge, err := d.uc.SignIn(r.Context(), &params)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	if challenge != nil {
+		pHTTP.SendJSON(w, r, http.StatusAccepted, newChallengeResponse(challenge))
+		return
+	}
+	w.Header().Add("token", tokens.AccessToken)
+
+	response := newSignInResponse(user, tokens)
+	pHTTP.SendJSON(w, r, http.StatusOK, response)
+}
+
+// refresh godoc
+//
+//	@Summary		Exchanges a refresh token for a new pair of tokens
+//	@Description	Exchanges a refresh token for a new pair of tokens. Every refresh token can be used once,
+//	@Description	reusing it revokes the whole session.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			refreshParams	body		RefreshRequest	true	"Refresh token."
+//	@Success		200				{object}	TokensResponse	"New tokens."
+//	@Failure		400				{object}	http.JSONError
+//	@Failure		401				{object}	http.JSONError
+//	@Failure		405
+//	@Failure		500
+//	@Router			/auth/refresh [post]
+func (d *delivery) refresh(w http.ResponseWriter, r *http.Request) {
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request RefreshRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil || request.RefreshToken == "" {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	tokens, err := d.uc.Refresh(r.Context(), request.RefreshToken)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.Header().Add("token", tokens.AccessToken)
+
+	response := newTokensResponse(tokens)
+	pHTTP.SendJSON(w, r, http.StatusOK, response)
+}
+
+// logout godoc
+//
+//	@Summary		Revokes the current session
+//	@Description	Revokes the access token and the refresh tokens of the current session.
+//	@Tags			auth
+//	@Success		204
+//	@Failure		401
+//	@Failure		405
+//	@Failure		500
+//	@Router			/auth/logout [post]
+func (d *delivery) logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	token, ok := mw.GetToken(ctx)
+	if !ok {
+		// API keys have no session to revoke.
+		pHTTP.HandleError(w, r, pErrors.ErrInvalidAuthToken)
+		return
+	}
+
+	params := auth.LogoutParams{
+		SessionID: token.SessionID,
+		TokenID:   token.ID,
+		ExpiresAt: token.ExpiresAt,
+	}
+	if err := d.uc.Logout(ctx, &params); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// me godoc
+//
+//	@Summary		Returns the caller
+//	@Description	Returns the user, the API key or the service the request is authenticated with and its permissions.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		200	{object}	MeResponse	"Caller."
+//	@Failure		401
+//	@Failure		405
+//	@Failure		500
+//	@Router			/auth/me [get]
+func (d *delivery) me(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if service, ok := mw.GetService(ctx); ok {
+		pHTTP.SendJSON(w, r, http.StatusOK, &MeResponse{
+			Service:     service,
+			Permissions: mw.GetPermissions(ctx),
+		})
+		return
+	}
+	if apiKeyID, ok := mw.GetAPIKeyID(ctx); ok {
+		pHTTP.SendJSON(w, r, http.StatusOK, &MeResponse{
+			APIKeyID:    apiKeyID,
+			Permissions: mw.GetPermissions(ctx),
+		})
+		return
+	}
+
+	userID, ok1 := mw.GetUserID(ctx)
+	token, ok2 := mw.GetToken(ctx)
+	if !ok1 || !ok2 {
+		pHTTP.HandleError(w, r, pErrors.ErrInvalidAuthToken)
+		return
+	}
+
+	user, err := d.uc.GetUser(ctx, userID)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	pHTTP.SendJSON(w, r, http.StatusOK, &MeResponse{
+		UserID:      user.ID,
+		Username:    user.Username,
+		Role:        mw.GetRole(ctx),
+		Permissions: mw.GetPermissions(ctx),
+		SessionID:   token.SessionID,
+		ExpiresAt:   &token.ExpiresAt,
+	})
+}
+
+// changePassword godoc
+//
+//	@Summary		Changes the password of the current user
+//	@Description	Changes the password of the current user. Other sessions of the user are revoked.
+//	@Tags			auth
+//	@Accept			json
+//	@Param			changePasswordParams	body	ChangePasswordRequest	true	"Old and new passwords."
+//	@Success		204
+//	@Failure		400	{object}	http.JSONError
+//	@Failure		401
+//	@Failure		403	{object}	http.JSONError
+//	@Failure		405
+//	@Failure		500
+//	@Router			/auth/password [post]
+func (d *delivery) changePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok1 := mw.GetUserID(ctx)
+	token, ok2 := mw.GetToken(ctx)
+	if !ok1 || !ok2 {
+		// API keys have no password.
+		pHTTP.HandleError(w, r, pErrors.ErrInvalidAuthToken)
+		return
+	}
+
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request ChangePasswordRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	params := auth.ChangePasswordParams{
+		UserID:      userID,
+		SessionID:   token.SessionID,
+		OldPassword: request.OldPassword,
+		NewPassword: request.NewPassword,
+	}
+	if err = d.uc.ChangePassword(ctx, &params); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resetPassword godoc
+//
+//	@Summary		Sets a new password with a reset token
+//	@Description	Sets a new password with a one-time reset token issued by an admin. All sessions of the user are revoked.
+//	@Tags			auth
+//	@Accept			json
+//	@Param			resetPasswordParams	body	ResetPasswordRequest	true	"Reset token and new password."
+//	@Success		204
+//	@Failure		400	{object}	http.JSONError
+//	@Failure		405
+//	@Failure		500
+//	@Router			/auth/password/reset [post]
+func (d *delivery) resetPassword(w http.ResponseWriter, r *http.Request) {
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request ResetPasswordRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil || request.ResetToken == "" {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
 	}
 
-	user, authToken, err := d.uc.SignIn(r.Context(), &params)
+	params := auth.ResetPasswordParams{
+		ResetToken:  request.ResetToken,
+		NewPassword: request.NewPassword,
+	}
+	if err = d.uc.ResetPassword(r.Context(), &params); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// enrollTwoFactor godoc
+//
+//	@Summary		Enrolls the second factor of the current user
+//	@Description	Generates a TOTP secret and recovery codes, a pending factor is replaced. The factor is enabled
+//	@Description	by /auth/2fa/confirm with the first code.
+//	@Tags			auth
+//	@Produce		json
+//	@Success		201	{object}	TwoFactorEnrollmentResponse	"Secret and recovery codes, shown once."
+//	@Failure		401
+//	@Failure		405
+//	@Failure		409	{object}	http.JSONError
+//	@Failure		500
+//	@Router			/auth/2fa/enroll [post]
+func (d *delivery) enrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := mw.GetUserID(ctx)
+	if !ok {
+		// API keys have no second factor.
+		pHTTP.HandleError(w, r, pErrors.ErrInvalidAuthToken)
+		return
+	}
+
+	enrollment, err := d.uc.EnrollTwoFactor(ctx, &auth.EnrollTwoFactorParams{UserID: userID})
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusCreated, newTwoFactorEnrollmentResponse(enrollment))
+}
+
+// confirmTwoFactor godoc
+//
+//	@Summary		Enables the enrolled second factor
+//	@Description	Enables the second factor enrolled by /auth/2fa/enroll with the first TOTP code.
+//	@Tags			auth
+//	@Accept			json
+//	@Param			confirmParams	body	TwoFactorCodeRequest	true	"TOTP code."
+//	@Success		204
+//	@Failure		400	{object}	http.JSONError
+//	@Failure		401
+//	@Failure		405
+//	@Failure		409	{object}	http.JSONError
+//	@Failure		429	{object}	http.JSONError
+//	@Failure		500
+//	@Router			/auth/2fa/confirm [post]
+func (d *delivery) confirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := mw.GetUserID(ctx)
+	if !ok {
+		pHTTP.HandleError(w, r, pErrors.ErrInvalidAuthToken)
+		return
+	}
+
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request TwoFactorCodeRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	if err = d.uc.ConfirmTwoFactor(ctx, userID, request.Code); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// disableTwoFactor godoc
+//
+//	@Summary		Disables the second factor of the current user
+//	@Description	Disables the second factor with a TOTP or a recovery code. Admins can't disable it when
+//	@Description	it's enforced for them.
+//	@Tags			auth
+//	@Accept			json
+//	@Param			disableParams	body	TwoFactorCodeRequest	true	"TOTP or recovery code."
+//	@Success		204
+//	@Failure		400	{object}	http.JSONError
+//	@Failure		401
+//	@Failure		403	{object}	http.JSONError
+//	@Failure		405
+//	@Failure		429	{object}	http.JSONError
+//	@Failure		500
+//	@Router			/auth/2fa [delete]
+func (d *delivery) disableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, ok := mw.GetUserID(ctx)
+	if !ok {
+		pHTTP.HandleError(w, r, pErrors.ErrInvalidAuthToken)
+		return
+	}
+
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request TwoFactorCodeRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	code := auth.TwoFactorCode{
+		Code:         request.Code,
+		RecoveryCode: request.RecoveryCode,
+	}
+	if err = d.uc.DisableTwoFactor(ctx, userID, &code); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifyTwoFactor godoc
+//
+//	@Summary		Completes the sign in with the second factor
+//	@Description	Exchanges the challenge token returned by /auth/signin and a TOTP or a recovery code for tokens.
+//	@Description	Every recovery code can be used once.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			verifyParams	body		VerifyTwoFactorRequest	true	"Challenge token and code."
+//	@Success		200				{object}	SignInResponse			"successfully auth"
+//	@Failure		400				{object}	http.JSONError
+//	@Failure		401				{object}	http.JSONError
+//	@Failure		403				{object}	http.JSONError
+//	@Failure		429				{object}	http.JSONError
+//	@Failure		405
+//	@Failure		500
+//	@Router			/auth/signin/2fa [post]
+func (d *delivery) verifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	body, err := pHTTP.ReadBody(r, d.log)
 	if err != nil {
 		pHTTP.HandleError(w, r, err)
 		return
 	}
-	w.Header().Add("token", authToken)
 
-	response := newSignInResponse(user)
+	var request VerifyTwoFactorRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil || request.ChallengeToken == "" {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	params := auth.VerifyTwoFactorParams{
+		ChallengeToken: request.ChallengeToken,
+		TwoFactorCode: auth.TwoFactorCode{
+			Code:         request.Code,
+			RecoveryCode: request.RecoveryCode,
+		},
+	}
+
+	user, tokens, err := d.uc.VerifyTwoFactor(r.Context(), &params)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.Header().Add("token", tokens.AccessToken)
+
+	response := newSignInResponse(user, tokens)
 	pHTTP.SendJSON(w, r, http.StatusOK, response)
 }
+
+// enrollTwoFactorOnSignIn godoc
+//
+//	@Summary		Enrolls the second factor required to sign in
+//	@Description	Enrolls the second factor with the challenge token returned by /auth/signin when the factor is
+//	@Description	enforced, but not enrolled yet. The sign in is completed at /auth/signin/2fa with the first code.
+//	@Tags			auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			enrollParams	body		ChallengeRequest			true	"Challenge token."
+//	@Success		201				{object}	TwoFactorEnrollmentResponse	"Secret and recovery codes, shown once."
+//	@Failure		400				{object}	http.JSONError
+//	@Failure		401				{object}	http.JSONError
+//	@Failure		405
+//	@Failure		409				{object}	http.JSONError
+//	@Failure		500
+//	@Router			/auth/signin/2fa/enroll [post]
+func (d *delivery) enrollTwoFactorOnSignIn(w http.ResponseWriter, r *http.Request) {
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request ChallengeRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil || request.ChallengeToken == "" {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	params := auth.EnrollTwoFactorParams{ChallengeToken: request.ChallengeToken}
+	enrollment, err := d.uc.EnrollTwoFactor(r.Context(), &params)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusCreated, newTwoFactorEnrollmentResponse(enrollment))
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth"
 	"github.com/SlavaShagalov/avito-intern-task/internal/models"
 	"time"
 )
@@ -8,8 +9,9 @@ import (
 // API requests
 
 type SignUpRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	InviteCode string `json:"invite_code"`
 }
 
 type SignInRequest struct {
@@ -17,21 +19,53 @@ type SignInRequest struct {
 	Password string `json:"password"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type ResetPasswordRequest struct {
+	ResetToken  string `json:"reset_token"`
+	NewPassword string `json:"new_password"`
+}
+
+type TwoFactorCodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type ChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+type VerifyTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	TwoFactorCodeRequest
+}
+
 // API responses
 
 type SignInResponse struct {
 	ID        int64     `json:"id"`
 	Username  string    `json:"username"`
 	IsAdmin   bool      `json:"is_admin"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
+	TokensResponse
 }
 
-func newSignInResponse(user *models.User) *SignInResponse {
+func newSignInResponse(user *models.User, tokens *auth.Tokens) *SignInResponse {
 	return &SignInResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		IsAdmin:   user.IsAdmin,
-		CreatedAt: user.CreatedAt,
+		ID:             user.ID,
+		Username:       user.Username,
+		IsAdmin:        user.IsAdmin(),
+		Role:           user.Role,
+		CreatedAt:      user.CreatedAt,
+		TokensResponse: *newTokensResponse(tokens),
 	}
 }
 
@@ -39,14 +73,73 @@ type SignUpResponse struct {
 	ID        int64     `json:"id"`
 	Username  string    `json:"username"`
 	IsAdmin   bool      `json:"is_admin"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
+	TokensResponse
 }
 
-func newSignUpResponse(user *models.User) *SignUpResponse {
+func newSignUpResponse(user *models.User, tokens *auth.Tokens) *SignUpResponse {
 	return &SignUpResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		IsAdmin:   user.IsAdmin,
-		CreatedAt: user.CreatedAt,
+		ID:             user.ID,
+		Username:       user.Username,
+		IsAdmin:        user.IsAdmin(),
+		Role:           user.Role,
+		CreatedAt:      user.CreatedAt,
+		TokensResponse: *newTokensResponse(tokens),
+	}
+}
+
+type TokensResponse struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func newTokensResponse(tokens *auth.Tokens) *TokensResponse {
+	return &TokensResponse{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresAt:    tokens.ExpiresAt,
+	}
+}
+
+// MeResponse describes either a user, an API key or a service. Permissions
+// of users are the ones of the access token, they are updated on refresh.
+type MeResponse struct {
+	UserID      int64      `json:"user_id,omitempty"`
+	Username    string     `json:"username,omitempty"`
+	Role        string     `json:"role,omitempty"`
+	Permissions []string   `json:"permissions"`
+	SessionID   int64      `json:"session_id,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	APIKeyID    int64      `json:"api_key_id,omitempty"`
+	Service     string     `json:"service,omitempty"`
+}
+
+type ChallengeResponse struct {
+	ChallengeToken     string    `json:"challenge_token"`
+	ExpiresAt          time.Time `json:"expires_at"`
+	EnrollmentRequired bool      `json:"enrollment_required"`
+}
+
+func newChallengeResponse(challenge *auth.Challenge) *ChallengeResponse {
+	return &ChallengeResponse{
+		ChallengeToken:     challenge.Token,
+		ExpiresAt:          challenge.ExpiresAt,
+		EnrollmentRequired: challenge.EnrollmentRequired,
+	}
+}
+
+type TwoFactorEnrollmentResponse struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+func newTwoFactorEnrollmentResponse(enrollment *auth.TwoFactorEnrollment) *TwoFactorEnrollmentResponse {
+	return &TwoFactorEnrollmentResponse{
+		Secret:        enrollment.Secret,
+		URI:           enrollment.URI,
+		RecoveryCodes: enrollment.RecoveryCodes,
 	}
 }
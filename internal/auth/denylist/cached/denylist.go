@@ -0,0 +1,107 @@
+package cached
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist"
+	"go.uber.org/zap"
+)
+
+type answer struct {
+	revoked   bool
+	checkedAt time.Time
+}
+
+// denyList keeps a local replica of answers of the shared deny list. Answers
+// younger than ttl are served without asking it. If it fails, answers younger
+// than maxStale are served instead, so an outage is only noticed once the
+// replica is older than that. Keys revoked by this instance are revoked
+// locally at once.
+type denyList struct {
+	shared   denylist.DenyList
+	ttl      time.Duration
+	maxStale time.Duration
+	log      *zap.Logger
+
+	mu        sync.Mutex
+	answers   map[string]answer
+	revoked   map[string]time.Time
+	lastSweep time.Time
+}
+
+func New(shared denylist.DenyList, ttl, maxStale time.Duration, log *zap.Logger) denylist.DenyList {
+	return &denyList{
+		shared:    shared,
+		ttl:       ttl,
+		maxStale:  maxStale,
+		log:       log,
+		answers:   make(map[string]answer),
+		revoked:   make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+func (d *denyList) Add(ctx context.Context, key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	d.mu.Lock()
+	d.revoked[key] = time.Now().Add(ttl)
+	d.mu.Unlock()
+	return d.shared.Add(ctx, key, ttl)
+}
+
+func (d *denyList) Contains(ctx context.Context, keys ...string) (bool, error) {
+	query := strings.Join(keys, " ")
+	now := time.Now()
+
+	d.mu.Lock()
+	for _, key := range keys {
+		if expiresAt, ok := d.revoked[key]; ok && now.Before(expiresAt) {
+			d.mu.Unlock()
+			return true, nil
+		}
+	}
+	cached, found := d.answers[query]
+	d.mu.Unlock()
+	if found && now.Sub(cached.checkedAt) < d.ttl {
+		return cached.revoked, nil
+	}
+
+	revoked, err := d.shared.Contains(ctx, keys...)
+	if err != nil {
+		if found && now.Sub(cached.checkedAt) < d.maxStale {
+			d.log.Warn("Deny list: unavailable, using local replica", zap.Error(err))
+			return cached.revoked, nil
+		}
+		return false, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.answers[query] = answer{revoked: revoked, checkedAt: now}
+	d.sweep(now)
+	return revoked, nil
+}
+
+// sweep drops answers that can't be served anymore. It runs at most once per
+// maxStale, so that the cost is spread over many calls.
+func (d *denyList) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < d.maxStale {
+		return
+	}
+	d.lastSweep = now
+	for query, a := range d.answers {
+		if now.Sub(a.checkedAt) >= d.maxStale {
+			delete(d.answers, query)
+		}
+	}
+	for key, expiresAt := range d.revoked {
+		if !now.Before(expiresAt) {
+			delete(d.revoked, key)
+		}
+	}
+}
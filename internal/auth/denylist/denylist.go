@@ -0,0 +1,26 @@
+package denylist
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const keyPrefix = "revoked:"
+
+// DenyList holds revoked access tokens until they expire on their own.
+type DenyList interface {
+	Add(ctx context.Context, key string, ttl time.Duration) error
+	// Contains reports whether any of the keys is revoked.
+	Contains(ctx context.Context, keys ...string) (bool, error)
+}
+
+// TokenKey revokes a single access token.
+func TokenKey(jti string) string {
+	return fmt.Sprintf("%sjti:%s", keyPrefix, jti)
+}
+
+// SessionKey revokes all access tokens issued within a session.
+func SessionKey(sessionID int64) string {
+	return fmt.Sprintf("%ssid:%d", keyPrefix, sessionID)
+}
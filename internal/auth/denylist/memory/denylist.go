@@ -0,0 +1,48 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist"
+)
+
+type denyList struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func New() denylist.DenyList {
+	return &denyList{
+		entries: make(map[string]time.Time),
+	}
+}
+
+func (d *denyList) Add(_ context.Context, key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for k, expiresAt := range d.entries {
+		if now.After(expiresAt) {
+			delete(d.entries, k)
+		}
+	}
+	d.entries[key] = now.Add(ttl)
+	return nil
+}
+
+func (d *denyList) Contains(_ context.Context, keys ...string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, key := range keys {
+		if expiresAt, ok := d.entries[key]; ok && time.Now().Before(expiresAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
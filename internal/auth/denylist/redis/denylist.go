@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+type denyList struct {
+	rdb redis.UniversalClient
+	log *zap.Logger
+}
+
+func New(rdb redis.UniversalClient, log *zap.Logger) denylist.DenyList {
+	return &denyList{
+		rdb: rdb,
+		log: log,
+	}
+}
+
+func (d *denyList) Add(ctx context.Context, key string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	if err := d.rdb.Set(ctx, key, 1, ttl).Err(); err != nil {
+		d.log.Error("Deny list: failed to add key", zap.String("key", key), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (d *denyList) Contains(ctx context.Context, keys ...string) (bool, error) {
+	// EXISTS with several keys fails in cluster mode if they are in different slots.
+	cmds, err := d.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Exists(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, cmd := range cmds {
+		if cmd.(*redis.IntCmd).Val() > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,34 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const jwksPath = "/.well-known/jwks.json"
+
+type delivery struct {
+	keys *keyring.Keyring
+	log  *zap.Logger
+}
+
+func RegisterHandlers(mux *mux.Router, keys *keyring.Keyring, log *zap.Logger) {
+	dlv := delivery{
+		keys: keys,
+		log:  log,
+	}
+
+	mux.HandleFunc(jwksPath, dlv.jwks).Methods(http.MethodGet)
+}
+
+// jwks lets other services verify access tokens offline. Tokens name their
+// key in the kid header.
+func (d *delivery) jwks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(d.keys.CacheMaxAge().Seconds())))
+	pHTTP.SendJSON(w, r, http.StatusOK, JWKSResponse{Keys: d.keys.JWKS()})
+}
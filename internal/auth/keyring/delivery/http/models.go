@@ -0,0 +1,8 @@
+package http
+
+import "github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+
+// API responses
+type JWKSResponse struct {
+	Keys []keyring.JWK `json:"keys"`
+}
@@ -0,0 +1,456 @@
+package keyring
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pSigningKey "github.com/SlavaShagalov/avito-intern-task/internal/signingkey"
+	pTenant "github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	DefaultRotationInterval = 24 * time.Hour
+	DefaultReloadInterval   = time.Minute
+	DefaultTokenTTL         = 15 * time.Minute
+
+	rsaKeyBits = 2048
+	keyIDSize  = 16
+
+	// EncryptionKeySize is the size of the AES-256 key encrypting private keys.
+	EncryptionKeySize = 32
+
+	// unknownKeyReloadInterval limits reloads made by Keyfunc, so that tokens
+	// with made-up kids can't flood the repository.
+	unknownKeyReloadInterval = time.Second
+	unknownKeyReloadTimeout  = time.Second
+)
+
+var errNoSigningKeys = errors.New("no signing keys")
+
+// TenantClaims are claims of tokens issued in a tenant. Each tenant has its
+// own keys, a token signed with a key of another tenant isn't verified.
+type TenantClaims interface {
+	jwt.Claims
+	GetTenantID() int64
+}
+
+type Config struct {
+	Algorithm string
+	// EncryptionKey encrypts private keys kept in the repository with AES-GCM.
+	EncryptionKey []byte
+	// RotationInterval is for how long a key signs tokens.
+	RotationInterval time.Duration
+	// ReloadInterval is how often keys are reloaded from the repository, so
+	// that keys created by other replicas are known before they sign tokens.
+	ReloadInterval time.Duration
+	// TokenTTL is the lifetime of signed tokens. A key verifies tokens for
+	// this long after the rotation.
+	TokenTTL time.Duration
+}
+
+type key struct {
+	id        string
+	tenantID  int64
+	algorithm string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	createdAt time.Time
+}
+
+// keySet is immutable once published, a reload replaces it.
+type keySet struct {
+	// keys of all tenants are sorted from the newest.
+	keys []*key
+	byID map[string]*key
+}
+
+// newest returns the newest key of the tenant, nil if it has none.
+func (set *keySet) newest(tenantID int64) *key {
+	for _, k := range set.keys {
+		if k.tenantID == tenantID {
+			return k
+		}
+	}
+	return nil
+}
+
+// Keyring signs access tokens with asymmetric keys of their tenant and
+// verifies them by kid. Keys are kept in the repository, so all replicas
+// share them. Private keys are stored encrypted.
+type Keyring struct {
+	repo    pSigningKey.Repository
+	tenants pTenant.Repository
+	cfg     Config
+	aead    cipher.AEAD
+	set     atomic.Pointer[keySet]
+	// rotateMu keeps a single rotation per replica at a time.
+	rotateMu sync.Mutex
+	// unknownKeyMu guards reloads of keys unknown to Keyfunc.
+	unknownKeyMu     sync.Mutex
+	unknownKeyReload time.Time
+	log              *zap.Logger
+}
+
+func New(repo pSigningKey.Repository, tenants pTenant.Repository, cfg Config, log *zap.Logger) (*Keyring, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgorithmRS256
+	}
+	if signingMethod(cfg.Algorithm) == nil {
+		return nil, errors.Errorf("unsupported signing algorithm %q", cfg.Algorithm)
+	}
+	if cfg.RotationInterval <= 0 {
+		cfg.RotationInterval = DefaultRotationInterval
+	}
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = DefaultReloadInterval
+	}
+	if cfg.TokenTTL <= 0 {
+		cfg.TokenTTL = DefaultTokenTTL
+	}
+	if len(cfg.EncryptionKey) != EncryptionKeySize {
+		return nil, errors.Errorf("signing keys encryption key must be %d bytes", EncryptionKeySize)
+	}
+	block, err := aes.NewCipher(cfg.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	kr := &Keyring{
+		repo:    repo,
+		tenants: tenants,
+		cfg:     cfg,
+		aead:    aead,
+		log:     log,
+	}
+	kr.set.Store(&keySet{byID: map[string]*key{}})
+	return kr, nil
+}
+
+// Run rotates keys until the context is canceled.
+func (kr *Keyring) Run(ctx context.Context) {
+	ticker := time.NewTicker(kr.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := kr.Rotate(ctx); err != nil {
+				kr.log.Warn("Failed to rotate signing keys", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Rotate reloads keys and creates a new one for each tenant whose newest key
+// is due for rotation or uses another algorithm. Expired keys are deleted.
+func (kr *Keyring) Rotate(ctx context.Context) error {
+	kr.rotateMu.Lock()
+	defer kr.rotateMu.Unlock()
+
+	if err := kr.Reload(ctx); err != nil {
+		return err
+	}
+	tenants, err := kr.tenants.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	set := kr.set.Load()
+	created := false
+	for i := range tenants {
+		newest := set.newest(tenants[i].ID)
+		if newest != nil && !kr.dueForRotation(newest) {
+			continue
+		}
+
+		signingKey, err := kr.generate(tenants[i].ID)
+		if err != nil {
+			return err
+		}
+		if err = kr.repo.Create(ctx, signingKey); err != nil {
+			return err
+		}
+		kr.log.Info("Signing key created", zap.String("kid", signingKey.ID),
+			zap.Int64("tenant_id", signingKey.TenantID), zap.String("algorithm", signingKey.Algorithm))
+		created = true
+	}
+	if created {
+		if err = kr.Reload(ctx); err != nil {
+			return err
+		}
+	}
+
+	return kr.repo.DeleteExpired(ctx)
+}
+
+// Reload replaces the keys with unexpired keys of the repository. The
+// previous keys are kept on error.
+func (kr *Keyring) Reload(ctx context.Context) error {
+	signingKeys, err := kr.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	set := &keySet{
+		keys: make([]*key, 0, len(signingKeys)),
+		byID: make(map[string]*key, len(signingKeys)),
+	}
+	for i := range signingKeys {
+		k, err := kr.parseKey(&signingKeys[i])
+		if err != nil {
+			kr.log.Error("Invalid signing key", zap.String("kid", signingKeys[i].ID), zap.Error(err))
+			continue
+		}
+		set.keys = append(set.keys, k)
+		set.byID[k.id] = k
+	}
+
+	kr.set.Store(set)
+	return nil
+}
+
+// Sign signs the claims with the newest key of their tenant known to all
+// replicas.
+func (kr *Keyring) Sign(claims TenantClaims) (string, error) {
+	k := kr.signingKey(claims.GetTenantID())
+	if k == nil {
+		return "", errNoSigningKeys
+	}
+
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.id
+	return token.SignedString(k.private)
+}
+
+// Keyfunc returns the public key named by the kid header of the token if it
+// belongs to the tenant of the token. Unknown keys are reloaded once: a new
+// tenant signs tokens with its first key at once.
+func (kr *Keyring) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	k, ok := kr.set.Load().byID[kid]
+	if !ok {
+		k, ok = kr.reloadUnknown(kid)
+	}
+	if !ok {
+		return nil, pErrors.ErrInvalidAuthToken
+	}
+	if claims, ok := token.Claims.(TenantClaims); !ok || claims.GetTenantID() != k.tenantID {
+		kr.log.Warn("Signing key of another tenant", zap.String("kid", kid), zap.Int64("tenant_id", k.tenantID))
+		return nil, pErrors.ErrInvalidAuthToken
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		kr.log.Warn("Unexpected signing method", zap.String("kid", kid), zap.String("alg", token.Method.Alg()))
+		return nil, pErrors.ErrInvalidAuthToken
+	}
+	return k.private.Public(), nil
+}
+
+// reloadUnknown reloads keys unless it was done less than
+// unknownKeyReloadInterval ago, and looks up the kid again.
+func (kr *Keyring) reloadUnknown(kid string) (*key, bool) {
+	kr.unknownKeyMu.Lock()
+	defer kr.unknownKeyMu.Unlock()
+	if k, ok := kr.set.Load().byID[kid]; ok {
+		return k, true
+	}
+	if time.Since(kr.unknownKeyReload) < unknownKeyReloadInterval {
+		return nil, false
+	}
+	kr.unknownKeyReload = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), unknownKeyReloadTimeout)
+	defer cancel()
+	if err := kr.Reload(ctx); err != nil {
+		kr.log.Warn("Failed to reload signing keys", zap.String("kid", kid), zap.Error(err))
+		return nil, false
+	}
+	k, ok := kr.set.Load().byID[kid]
+	return k, ok
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS returns the public keys of all tenants verifying tokens, the newest
+// first.
+func (kr *Keyring) JWKS() []JWK {
+	set := kr.set.Load()
+	jwks := make([]JWK, 0, len(set.keys))
+	for _, k := range set.keys {
+		jwk := JWK{
+			KeyID:     k.id,
+			Use:       "sig",
+			Algorithm: k.algorithm,
+		}
+		switch public := k.private.Public().(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks
+}
+
+// CacheMaxAge is for how long clients may cache the JWKS: keys sign tokens
+// only after they have been published for this long.
+func (kr *Keyring) CacheMaxAge() time.Duration {
+	return kr.cfg.ReloadInterval
+}
+
+// signingKey skips keys created less than a reload interval ago: other
+// replicas may not know them yet.
+func (kr *Keyring) signingKey(tenantID int64) *key {
+	set := kr.set.Load()
+	publishedBefore := time.Now().Add(-kr.cfg.ReloadInterval)
+	for _, k := range set.keys {
+		if k.tenantID == tenantID && k.algorithm == kr.cfg.Algorithm && !k.createdAt.After(publishedBefore) {
+			return k
+		}
+	}
+	return set.newest(tenantID)
+}
+
+func (kr *Keyring) dueForRotation(newest *key) bool {
+	return newest.algorithm != kr.cfg.Algorithm || time.Since(newest.createdAt) >= kr.cfg.RotationInterval
+}
+
+// generate creates a key which outlives its rotation by the time the next key
+// takes to be published and the lifetime of the tokens it signed.
+func (kr *Keyring) generate(tenantID int64) (*models.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch kr.cfg.Algorithm {
+	case AlgorithmRS256:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmEdDSA:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "generate signing key")
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal signing key")
+	}
+
+	id := make([]byte, keyIDSize)
+	if _, err = rand.Read(id); err != nil {
+		return nil, err
+	}
+	kid := base64.RawURLEncoding.EncodeToString(id)
+
+	// The kid is authenticated with the key, so that rows can't be swapped.
+	nonce := make([]byte, kr.aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	encrypted := kr.aead.Seal(nonce, nonce, der, []byte(kid))
+
+	now := time.Now()
+	return &models.SigningKey{
+		ID:         kid,
+		TenantID:   tenantID,
+		Algorithm:  kr.cfg.Algorithm,
+		PrivateKey: encrypted,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(kr.cfg.RotationInterval + 2*kr.cfg.ReloadInterval + kr.cfg.TokenTTL),
+	}, nil
+}
+
+func (kr *Keyring) parseKey(signingKey *models.SigningKey) (*key, error) {
+	method := signingMethod(signingKey.Algorithm)
+	if method == nil {
+		return nil, errors.Errorf("unsupported signing algorithm %q", signingKey.Algorithm)
+	}
+
+	nonceSize := kr.aead.NonceSize()
+	if len(signingKey.PrivateKey) < nonceSize {
+		return nil, errors.New("encrypted key is too short")
+	}
+	der, err := kr.aead.Open(nil, signingKey.PrivateKey[:nonceSize], signingKey.PrivateKey[nonceSize:],
+		[]byte(signingKey.ID))
+	if err != nil {
+		return nil, errors.Wrap(err, "decrypt signing key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+
+	var private crypto.Signer
+	switch signingKey.Algorithm {
+	case AlgorithmRS256:
+		if rsaKey, ok := parsed.(*rsa.PrivateKey); ok {
+			private = rsaKey
+		}
+	case AlgorithmEdDSA:
+		if edKey, ok := parsed.(ed25519.PrivateKey); ok {
+			private = edKey
+		}
+	}
+	if private == nil {
+		return nil, errors.Errorf("key doesn't match algorithm %q", signingKey.Algorithm)
+	}
+
+	return &key{
+		id:        signingKey.ID,
+		tenantID:  signingKey.TenantID,
+		algorithm: signingKey.Algorithm,
+		method:    method,
+		private:   private,
+		createdAt: signingKey.CreatedAt,
+	}, nil
+}
+
+func signingMethod(algorithm string) jwt.SigningMethod {
+	switch algorithm {
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA
+	}
+	return nil
+}
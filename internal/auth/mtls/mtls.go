@@ -0,0 +1,128 @@
+package mtls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/pkg/errors"
+)
+
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile is a PEM bundle of CAs issuing client certificates.
+	ClientCAFile string
+	// RequireClientCert rejects connections without a client certificate.
+	// Otherwise a certificate is verified only when given, so that users
+	// keep authenticating with tokens.
+	RequireClientCert bool
+}
+
+// ServerTLSConfig verifies client certificates against the CAs of the config.
+func ServerTLSConfig(cfg Config) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load server certificate")
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.ClientCAFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "read client CA")
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.Errorf("no certificates in client CA file %q", cfg.ClientCAFile)
+	}
+
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if cfg.RequireClientCert {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// Service is an internal caller authenticated by a client certificate.
+type Service struct {
+	Name string `mapstructure:"name"`
+	// Subjects are matched with the URI, DNS and email SANs of the
+	// certificate, then with its common name.
+	Subjects    []string `mapstructure:"subjects"`
+	Permissions []string `mapstructure:"permissions"`
+	// TenantID defaults to the default tenant.
+	TenantID int64 `mapstructure:"tenant_id"`
+}
+
+// Services maps verified client certificates to services.
+type Services struct {
+	bySubject map[string]*Service
+}
+
+func NewServices(services []Service) (*Services, error) {
+	s := &Services{bySubject: make(map[string]*Service)}
+	for i := range services {
+		service := &services[i]
+		if service.Name == "" {
+			return nil, errors.New("service without name")
+		}
+		if len(service.Subjects) == 0 {
+			return nil, errors.Errorf("service %q without subjects", service.Name)
+		}
+		if service.TenantID < 0 {
+			return nil, errors.Errorf("service %q: bad tenant id %d", service.Name, service.TenantID)
+		}
+		if service.Permissions == nil {
+			service.Permissions = []string{}
+		}
+		for _, permission := range service.Permissions {
+			if !knownPermission(permission) {
+				return nil, errors.Errorf("service %q: unknown permission %q", service.Name, permission)
+			}
+		}
+		for _, subject := range service.Subjects {
+			if other, exists := s.bySubject[subject]; exists {
+				return nil, errors.Errorf("subject %q of service %q is taken by %q", subject, service.Name, other.Name)
+			}
+			s.bySubject[subject] = service
+		}
+	}
+	return s, nil
+}
+
+// Identify returns false for certificates of unknown services. The
+// certificate must be verified by the TLS handshake.
+func (s *Services) Identify(cert *x509.Certificate) (*Service, bool) {
+	subjects := make([]string, 0, len(cert.URIs)+len(cert.DNSNames)+len(cert.EmailAddresses)+1)
+	for _, uri := range cert.URIs {
+		subjects = append(subjects, uri.String())
+	}
+	subjects = append(subjects, cert.DNSNames...)
+	subjects = append(subjects, cert.EmailAddresses...)
+	subjects = append(subjects, cert.Subject.CommonName)
+
+	for _, subject := range subjects {
+		if service, exists := s.bySubject[subject]; exists && subject != "" {
+			return service, true
+		}
+	}
+	return nil, false
+}
+
+func knownPermission(permission string) bool {
+	for _, permissions := range models.DefaultRolePermissions {
+		if models.HasPermission(permissions, permission) {
+			return true
+		}
+	}
+	return false
+}
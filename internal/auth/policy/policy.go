@@ -0,0 +1,139 @@
+package policy
+
+import (
+	"bufio"
+	_ "embed"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+)
+
+const (
+	DefaultUsernameMinLength = 3
+	DefaultUsernameMaxLength = 32
+	DefaultPasswordMinLength = 8
+
+	// passwordMaxLength is the limit of bcrypt.
+	passwordMaxLength = 72
+)
+
+var DefaultUsernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+var commonPasswords = func() map[string]struct{} {
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(commonPasswordsList))
+	for scanner.Scan() {
+		if password := strings.TrimSpace(scanner.Text()); password != "" {
+			passwords[password] = struct{}{}
+		}
+	}
+	return passwords
+}()
+
+type Config struct {
+	UsernameMinLength int
+	UsernameMaxLength int
+	UsernamePattern   *regexp.Regexp
+	ReservedUsernames []string
+
+	PasswordMinLength int
+	// PasswordMinClasses is the number of character classes (lowercase and
+	// uppercase letters, digits, other symbols) a password must contain.
+	PasswordMinClasses    int
+	RejectCommonPasswords bool
+}
+
+// Policy validates credentials chosen by users.
+type Policy struct {
+	cfg      Config
+	reserved map[string]struct{}
+}
+
+// New replaces unset lengths and pattern with defaults.
+func New(cfg Config) *Policy {
+	if cfg.UsernameMinLength <= 0 {
+		cfg.UsernameMinLength = DefaultUsernameMinLength
+	}
+	if cfg.UsernameMaxLength <= 0 {
+		cfg.UsernameMaxLength = DefaultUsernameMaxLength
+	}
+	if cfg.UsernamePattern == nil {
+		cfg.UsernamePattern = DefaultUsernamePattern
+	}
+	if cfg.PasswordMinLength <= 0 {
+		cfg.PasswordMinLength = DefaultPasswordMinLength
+	}
+
+	reserved := make(map[string]struct{}, len(cfg.ReservedUsernames))
+	for _, username := range cfg.ReservedUsernames {
+		reserved[strings.ToLower(username)] = struct{}{}
+	}
+
+	return &Policy{
+		cfg:      cfg,
+		reserved: reserved,
+	}
+}
+
+func (p *Policy) ValidateUsername(username string) error {
+	length := utf8.RuneCountInString(username)
+	if length < p.cfg.UsernameMinLength || length > p.cfg.UsernameMaxLength {
+		return pErrors.ErrBadUsernameLength
+	}
+	if !p.cfg.UsernamePattern.MatchString(username) {
+		return pErrors.ErrBadUsernameCharset
+	}
+	if _, reserved := p.reserved[strings.ToLower(username)]; reserved {
+		return pErrors.ErrUsernameReserved
+	}
+	return nil
+}
+
+func (p *Policy) ValidatePassword(password string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.cfg.PasswordMinLength {
+		return pErrors.ErrPasswordTooShort
+	}
+	if len(password) > passwordMaxLength {
+		return pErrors.ErrPasswordTooLong
+	}
+	if classes(password) < p.cfg.PasswordMinClasses {
+		return pErrors.ErrPasswordTooSimple
+	}
+	if p.cfg.RejectCommonPasswords {
+		if _, common := commonPasswords[strings.ToLower(password)]; common {
+			return pErrors.ErrPasswordTooCommon
+		}
+	}
+	return nil
+}
+
+func classes(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	count := 0
+	for _, has := range []bool{lower, upper, digit, other} {
+		if has {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,127 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Codes are compatible with authenticator apps: RFC 6238 with HMAC-SHA1,
+// 6 digits and 30 seconds period.
+const (
+	Digits = 6
+	Period = 30 * time.Second
+
+	DefaultIssuer = "Avito Banners"
+	DefaultSkew   = 1
+
+	secretSize = 20
+)
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type Config struct {
+	// Issuer is shown by authenticator apps next to the account.
+	Issuer string
+	// Skew is the number of periods before and after the current one whose
+	// codes are accepted too, it makes up for clock drift.
+	Skew int
+	// Now replaces time.Now, tests fix the clock with it.
+	Now func() time.Time
+}
+
+type TOTP struct {
+	cfg Config
+}
+
+func New(cfg Config) *TOTP {
+	if cfg.Issuer == "" {
+		cfg.Issuer = DefaultIssuer
+	}
+	if cfg.Skew <= 0 {
+		cfg.Skew = DefaultSkew
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &TOTP{cfg: cfg}
+}
+
+// GenerateSecret returns a base32 encoded secret.
+func (t *TOTP) GenerateSecret() (string, error) {
+	b := make([]byte, secretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(b), nil
+}
+
+// URI is the otpauth URI authenticator apps import from a QR code.
+func (t *TOTP) URI(account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", t.cfg.Issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(Digits))
+	query.Set("period", fmt.Sprint(int(Period.Seconds())))
+	label := url.PathEscape(t.cfg.Issuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Validate returns the time step of the code. Steps of used codes are stored
+// to reject their reuse.
+func (t *TOTP) Validate(secret, code string) (int64, bool) {
+	if len(code) != Digits {
+		return 0, false
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return 0, false
+	}
+
+	current := Step(t.cfg.Now())
+	for step := current - int64(t.cfg.Skew); step <= current+int64(t.cfg.Skew); step++ {
+		if subtle.ConstantTimeCompare([]byte(generate(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// Code returns the code of the secret at the time.
+func Code(secret string, at time.Time) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return generate(key, Step(at)), nil
+}
+
+// Step is the number of periods since the Unix epoch.
+func Step(at time.Time) int64 {
+	return at.Unix() / int64(Period.Seconds())
+}
+
+func decodeSecret(secret string) ([]byte, error) {
+	return secretEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+}
+
+// generate is HOTP (RFC 4226) of the step.
+func generate(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, value%1_000_000)
+}
@@ -2,20 +2,126 @@ package auth
 
 import (
 	"context"
+	"time"
+
 	"github.com/SlavaShagalov/avito-intern-task/internal/models"
 )
 
 type SignInParams struct {
 	Username string
 	Password string
+	// IP is the address of the client, failed attempts are counted per IP too.
+	IP string
 }
 
 type SignUpParams struct {
 	Username string
 	Password string
+	// InviteCode is required with the invite sign up mode.
+	InviteCode string
+}
+
+type LogoutParams struct {
+	SessionID int64
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+type ChangePasswordParams struct {
+	UserID int64
+	// SessionID is the session kept after the change.
+	SessionID   int64
+	OldPassword string
+	NewPassword string
+}
+
+type ResetPasswordParams struct {
+	ResetToken  string
+	NewPassword string
+}
+
+// ResetToken is a one-time token which allows to set a new password without
+// the old one.
+type ResetToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// InviteCode is a one-time code which allows to sign up when open sign up is
+// disabled.
+type InviteCode struct {
+	Code      string
+	ExpiresAt time.Time
+}
+
+// Tokens are issued on sign-in and rotated on refresh. ExpiresAt is the
+// expiration time of the access token.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+}
+
+// Challenge is issued by sign in instead of tokens when the second factor is
+// required. The token is exchanged for tokens with a TOTP or a recovery code.
+type Challenge struct {
+	Token     string
+	ExpiresAt time.Time
+	// EnrollmentRequired is set when the second factor is enforced for the
+	// user, but not enrolled yet. The token allows to enroll it.
+	EnrollmentRequired bool
+}
+
+// EnrollTwoFactorParams name the user either by id or by a challenge token.
+type EnrollTwoFactorParams struct {
+	UserID         int64
+	ChallengeToken string
+}
+
+// TwoFactorEnrollment is shown to the user once. The secret is imported to an
+// authenticator app from the URI.
+type TwoFactorEnrollment struct {
+	Secret        string
+	URI           string
+	RecoveryCodes []string
+}
+
+// TwoFactorCode is either a TOTP code or a one-time recovery code.
+type TwoFactorCode struct {
+	Code         string
+	RecoveryCode string
+}
+
+type VerifyTwoFactorParams struct {
+	ChallengeToken string
+	TwoFactorCode
 }
 
 type Usecase interface {
-	SignIn(ctx context.Context, params *SignInParams) (*models.User, string, error)
-	SignUp(ctx context.Context, params *SignUpParams) (*models.User, string, error)
+	// SignIn returns a challenge instead of tokens when the second factor is
+	// required.
+	SignIn(ctx context.Context, params *SignInParams) (*models.User, *Tokens, *Challenge, error)
+	SignUp(ctx context.Context, params *SignUpParams) (*models.User, *Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*Tokens, error)
+	Logout(ctx context.Context, params *LogoutParams) error
+	// GetUser returns the signed in user.
+	GetUser(ctx context.Context, userID int64) (*models.User, error)
+	// RevokeSessions signs the user out everywhere except the given session.
+	RevokeSessions(ctx context.Context, userID, exceptSessionID int64) error
+	// ChangePassword signs the user out of other sessions.
+	ChangePassword(ctx context.Context, params *ChangePasswordParams) error
+	// IssueResetToken invalidates reset tokens issued to the user before.
+	IssueResetToken(ctx context.Context, userID int64) (*ResetToken, error)
+	// ResetPassword signs the user out of all sessions.
+	ResetPassword(ctx context.Context, params *ResetPasswordParams) error
+	IssueInviteCode(ctx context.Context, createdBy int64) (*InviteCode, error)
+	// Unlock lifts the sign in and the second factor lockouts of the username.
+	Unlock(ctx context.Context, username string) error
+	// EnrollTwoFactor replaces a pending second factor of the user. It's
+	// enabled by ConfirmTwoFactor or by the first VerifyTwoFactor.
+	EnrollTwoFactor(ctx context.Context, params *EnrollTwoFactorParams) (*TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, userID int64, code string) error
+	// VerifyTwoFactor completes the sign in started by SignIn.
+	VerifyTwoFactor(ctx context.Context, params *VerifyTwoFactorParams) (*models.User, *Tokens, error)
+	DisableTwoFactor(ctx context.Context, userID int64, code *TwoFactorCode) error
 }
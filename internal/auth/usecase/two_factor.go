@@ -0,0 +1,291 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/attempts"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	pTwoFactor "github.com/SlavaShagalov/avito-intern-task/internal/twofactor"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	recoveryCodesCount = 10
+	// recoveryCodeSize is in base32 characters, grouped by halves.
+	recoveryCodeSize = 10
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func (uc *usecase) EnrollTwoFactor(ctx context.Context,
+	params *auth.EnrollTwoFactorParams) (*auth.TwoFactorEnrollment, error) {
+	userID := params.UserID
+	if params.ChallengeToken != "" {
+		claims, err := uc.parseChallenge(params.ChallengeToken)
+		if err != nil {
+			return nil, err
+		}
+		if !claims.Enroll {
+			return nil, pErrors.ErrInvalidChallengeToken
+		}
+		userID = claims.UserID
+		ctx = tenant.WithID(ctx, claims.TenantID)
+	}
+
+	user, err := uc.usersRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := uc.totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	recoveryCodes := make([]string, recoveryCodesCount)
+	recoveryCodeHashes := make([]string, recoveryCodesCount)
+	for i := range recoveryCodes {
+		if recoveryCodes[i], err = randomRecoveryCode(); err != nil {
+			return nil, err
+		}
+		recoveryCodeHashes[i] = hashToken(normalizeRecoveryCode(recoveryCodes[i]))
+	}
+
+	err = uc.twoFactorRepo.Create(ctx, &pTwoFactor.CreateParams{
+		UserID:             user.ID,
+		Secret:             secret,
+		RecoveryCodeHashes: recoveryCodeHashes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.log.Info("Two-factor enrolled", zap.Int64("user_id", user.ID))
+	return &auth.TwoFactorEnrollment{
+		Secret:        secret,
+		URI:           uc.totp.URI(user.Username, secret),
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (uc *usecase) ConfirmTwoFactor(ctx context.Context, userID int64, code string) error {
+	factor, err := uc.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if factor.IsConfirmed() {
+		return pErrors.ErrTwoFactorAlreadyEnabled
+	}
+
+	if err = uc.verifyCode(ctx, factor, &auth.TwoFactorCode{Code: code}); err != nil {
+		return err
+	}
+	if err = uc.twoFactorRepo.Confirm(ctx, userID); err != nil {
+		return err
+	}
+
+	uc.log.Info("Two-factor enabled", zap.Int64("user_id", userID))
+	return nil
+}
+
+func (uc *usecase) VerifyTwoFactor(ctx context.Context,
+	params *auth.VerifyTwoFactorParams) (*models.User, *auth.Tokens, error) {
+	claims, err := uc.parseChallenge(params.ChallengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx = tenant.WithID(ctx, claims.TenantID)
+
+	user, err := uc.usersRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, pErrors.ErrUserNotFound) {
+			return nil, nil, pErrors.ErrInvalidChallengeToken
+		}
+		return nil, nil, err
+	}
+	if user.IsDisabled {
+		return nil, nil, pErrors.ErrUserDisabled
+	}
+
+	factor, err := uc.twoFactorRepo.Get(ctx, user.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = uc.verifyCode(ctx, factor, &params.TwoFactorCode); err != nil {
+		return nil, nil, err
+	}
+	// The factor enrolled during the sign in is confirmed by its first code.
+	if !factor.IsConfirmed() {
+		if err = uc.twoFactorRepo.Confirm(ctx, user.ID); err != nil {
+			return nil, nil, err
+		}
+		uc.log.Info("Two-factor enabled", zap.Int64("user_id", user.ID))
+	}
+
+	tokens, err := uc.startSession(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	uc.log.Debug("Sign In", zap.Int64("user_id", user.ID))
+	return user, tokens, nil
+}
+
+func (uc *usecase) DisableTwoFactor(ctx context.Context, userID int64, code *auth.TwoFactorCode) error {
+	user, err := uc.usersRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if uc.twoFactorRequired(user) {
+		return pErrors.ErrTwoFactorRequired
+	}
+
+	factor, err := uc.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if factor.IsConfirmed() {
+		if err = uc.verifyCode(ctx, factor, code); err != nil {
+			return err
+		}
+	}
+	if err = uc.twoFactorRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	uc.log.Info("Two-factor disabled", zap.Int64("user_id", userID))
+	return nil
+}
+
+// challenge returns nil if the user signs in with the password only.
+func (uc *usecase) challenge(ctx context.Context, user *models.User) (*auth.Challenge, error) {
+	factor, err := uc.twoFactorRepo.Get(ctx, user.ID)
+	if err != nil && !errors.Is(err, pErrors.ErrTwoFactorNotEnrolled) {
+		return nil, err
+	}
+	enrolled := err == nil && factor.IsConfirmed()
+	if !enrolled && !uc.twoFactorRequired(user) {
+		return nil, nil
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(uc.challengeTTL)
+	token, err := uc.keys.Sign(&auth.ChallengeClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    uc.tokenIssuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			Audience:  jwt.ClaimStrings{auth.ChallengeAudience(uc.tokenAudience)},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		UserID:   user.ID,
+		TenantID: user.TenantID,
+		Enroll:   !enrolled,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.Challenge{
+		Token:              token,
+		ExpiresAt:          expiresAt,
+		EnrollmentRequired: !enrolled,
+	}, nil
+}
+
+func (uc *usecase) twoFactorRequired(user *models.User) bool {
+	return uc.adminTwoFactor && user.IsAdmin()
+}
+
+func (uc *usecase) parseChallenge(token string) (*auth.ChallengeClaims, error) {
+	var claims auth.ChallengeClaims
+	if _, err := uc.challengeParser.ParseWithClaims(token, &claims, uc.keys.Keyfunc); err != nil {
+		return nil, errors.Wrap(pErrors.ErrInvalidChallengeToken, err.Error())
+	}
+	return &claims, nil
+}
+
+// verifyCode accepts a TOTP code of the factor or a recovery code of the
+// confirmed factor. Accepted codes can't be used again. Wrong codes are
+// counted per user and slow down the next attempts like wrong passwords.
+func (uc *usecase) verifyCode(ctx context.Context, factor *models.TwoFactor, code *auth.TwoFactorCode) error {
+	key := attempts.TwoFactorKey(factor.UserID)
+	blocked, err := uc.attempts.Blocked(ctx, key)
+	if err != nil {
+		uc.log.Warn("Failed to check two-factor attempts", zap.Error(err))
+	}
+	if blocked > 0 {
+		return errors.Wrapf(pErrors.ErrTooManyAttempts, "blocked for %s", blocked)
+	}
+
+	switch {
+	case code.RecoveryCode != "" && factor.IsConfirmed():
+		err = uc.twoFactorRepo.UseRecoveryCode(ctx, factor.UserID, hashToken(normalizeRecoveryCode(code.RecoveryCode)))
+		if err == nil {
+			uc.log.Info("Recovery code used", zap.Int64("user_id", factor.UserID))
+		}
+	case code.Code != "":
+		step, ok := uc.totp.Validate(factor.Secret, code.Code)
+		if !ok {
+			err = pErrors.ErrInvalidTwoFactorCode
+			break
+		}
+		err = uc.twoFactorRepo.UseStep(ctx, factor.UserID, step)
+	default:
+		err = pErrors.ErrInvalidTwoFactorCode
+	}
+
+	if errors.Is(err, pErrors.ErrInvalidTwoFactorCode) {
+		uc.failTwoFactor(ctx, factor.UserID)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if err = uc.attempts.Reset(ctx, key); err != nil {
+		uc.log.Warn("Failed to reset two-factor attempts", zap.Error(err))
+	}
+	return nil
+}
+
+func (uc *usecase) failTwoFactor(ctx context.Context, userID int64) {
+	limits := &uc.signInLimits
+
+	key := attempts.TwoFactorKey(userID)
+	failures, err := uc.attempts.Fail(ctx, key, limits.window)
+	if err == nil {
+		if delay := limits.userDelay(failures); delay > 0 {
+			err = uc.attempts.Block(ctx, key, delay)
+		}
+		if failures == limits.lockoutAttempts {
+			uc.log.Warn("Two-factor locked", zap.Int64("user_id", userID))
+		}
+	}
+	if err != nil {
+		uc.log.Warn("Failed to count two-factor attempt", zap.Error(err))
+	}
+}
+
+// randomRecoveryCode looks like "abcde-fghij".
+func randomRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeSize*5/8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+	return code[:recoveryCodeSize/2] + "-" + code[recoveryCodeSize/2:], nil
+}
+
+// normalizeRecoveryCode makes the code case- and separator-insensitive.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -2,73 +2,305 @@ package usecase
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"github.com/SlavaShagalov/avito-intern-task/internal/auth"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/attempts"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/policy"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/totp"
+	pInvite "github.com/SlavaShagalov/avito-intern-task/internal/invite"
 	"github.com/SlavaShagalov/avito-intern-task/internal/models"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
 	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
 	pHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher"
-	bcryptHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher/bcrypt"
+	pResetToken "github.com/SlavaShagalov/avito-intern-task/internal/resettoken"
+	pSession "github.com/SlavaShagalov/avito-intern-task/internal/session"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	pTwoFactor "github.com/SlavaShagalov/avito-intern-task/internal/twofactor"
 	"github.com/SlavaShagalov/avito-intern-task/internal/user"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"strconv"
+	"sync"
 	"time"
 )
 
-const tokenExpiration = 30 * 24 * time.Hour
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+	defaultResetTokenTTL   = 24 * time.Hour
+	defaultInviteCodeTTL   = 7 * 24 * time.Hour
+	defaultChallengeTTL    = 5 * time.Minute
+
+	defaultSignInWindow            = 15 * time.Minute
+	defaultSignInFreeAttempts      = 3
+	defaultSignInBaseDelay         = time.Second
+	defaultSignInMaxDelay          = 30 * time.Second
+	defaultSignInLockoutAttempts   = 10
+	defaultSignInIPLockoutAttempts = 100
+	defaultSignInLockoutDuration   = 15 * time.Minute
+
+	tokenIDSize      = 16
+	refreshTokenSize = 32
+	resetTokenSize   = 32
+	inviteCodeSize   = 16
+)
+
+// signInLimits slow down guessing of passwords. Failures are counted per
+// username and per IP.
+type signInLimits struct {
+	window time.Duration
+	// freeAttempts are failures allowed without delay for the username.
+	freeAttempts int64
+	// baseDelay doubles with every next failure for the username.
+	baseDelay         time.Duration
+	maxDelay          time.Duration
+	lockoutAttempts   int64
+	ipLockoutAttempts int64
+	lockoutDuration   time.Duration
+}
+
+func (l *signInLimits) setDefaults() {
+	if l.window <= 0 {
+		l.window = defaultSignInWindow
+	}
+	if l.freeAttempts <= 0 {
+		l.freeAttempts = defaultSignInFreeAttempts
+	}
+	if l.baseDelay <= 0 {
+		l.baseDelay = defaultSignInBaseDelay
+	}
+	if l.maxDelay <= 0 {
+		l.maxDelay = defaultSignInMaxDelay
+	}
+	if l.lockoutAttempts <= 0 {
+		l.lockoutAttempts = defaultSignInLockoutAttempts
+	}
+	if l.ipLockoutAttempts <= 0 {
+		l.ipLockoutAttempts = defaultSignInIPLockoutAttempts
+	}
+	if l.lockoutDuration <= 0 {
+		l.lockoutDuration = defaultSignInLockoutDuration
+	}
+}
+
+// userDelay returns for how long to block the username after the failure.
+func (l *signInLimits) userDelay(failures int64) time.Duration {
+	if failures >= l.lockoutAttempts {
+		return l.lockoutDuration
+	}
+	if failures <= l.freeAttempts {
+		return 0
+	}
+	delay := l.baseDelay
+	for i := l.freeAttempts + 1; i < failures && delay < l.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > l.maxDelay {
+		delay = l.maxDelay
+	}
+	return delay
+}
 
 type usecase struct {
-	usersRepo user.Repository
-	hasher    pHasher.Hasher
-	log       *zap.Logger
+	usersRepo       user.Repository
+	sessionsRepo    pSession.Repository
+	resetTokensRepo pResetToken.Repository
+	invitesRepo     pInvite.Repository
+	twoFactorRepo   pTwoFactor.Repository
+	denyList        denylist.DenyList
+	attempts        attempts.Counter
+	hasher          pHasher.Hasher
+	// dummyHash is compared with passwords of unknown users, so that they
+	// can't be told apart by the response time.
+	dummyHash       string
+	dummyHashOnce   sync.Once
+	credentials     *policy.Policy
+	keys            *keyring.Keyring
+	totp            *totp.TOTP
+	challengeParser *jwt.Parser
+	adminTwoFactor  bool
+	signUpMode      string
+	tokenIssuer     string
+	tokenAudience   string
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+	resetTokenTTL   time.Duration
+	inviteCodeTTL   time.Duration
+	challengeTTL    time.Duration
+	signInLimits    signInLimits
+	log             *zap.Logger
 }
 
-func New(usersRepo user.Repository, log *zap.Logger) auth.Usecase {
-	return &usecase{
-		usersRepo: usersRepo,
-		hasher:    bcryptHasher.New(),
-		log:       log,
+func New(usersRepo user.Repository, sessionsRepo pSession.Repository, resetTokensRepo pResetToken.Repository,
+	invitesRepo pInvite.Repository, twoFactorRepo pTwoFactor.Repository, denyList denylist.DenyList,
+	counter attempts.Counter, credentials *policy.Policy, hasher pHasher.Hasher, keys *keyring.Keyring,
+	otp *totp.TOTP, log *zap.Logger) auth.Usecase {
+	uc := &usecase{
+		usersRepo:       usersRepo,
+		sessionsRepo:    sessionsRepo,
+		resetTokensRepo: resetTokensRepo,
+		invitesRepo:     invitesRepo,
+		twoFactorRepo:   twoFactorRepo,
+		denyList:        denyList,
+		attempts:        counter,
+		hasher:          hasher,
+		credentials:     credentials,
+		keys:            keys,
+		totp:            otp,
+		adminTwoFactor:  viper.GetBool(config.AuthTwoFactorEnforceAdmins),
+		signUpMode:      viper.GetString(config.AuthSignUpMode),
+		tokenIssuer:     viper.GetString(config.AuthTokenIssuer),
+		tokenAudience:   viper.GetString(config.AuthTokenAudience),
+		accessTokenTTL:  viper.GetDuration(config.AuthAccessTokenTTL),
+		refreshTokenTTL: viper.GetDuration(config.AuthRefreshTokenTTL),
+		resetTokenTTL:   viper.GetDuration(config.AuthResetTokenTTL),
+		inviteCodeTTL:   viper.GetDuration(config.AuthInviteCodeTTL),
+		challengeTTL:    viper.GetDuration(config.AuthTwoFactorChallengeTTL),
+		signInLimits: signInLimits{
+			window:            viper.GetDuration(config.AuthSignInWindow),
+			freeAttempts:      viper.GetInt64(config.AuthSignInFreeAttempts),
+			baseDelay:         viper.GetDuration(config.AuthSignInBaseDelay),
+			maxDelay:          viper.GetDuration(config.AuthSignInMaxDelay),
+			lockoutAttempts:   viper.GetInt64(config.AuthSignInLockoutAttempts),
+			ipLockoutAttempts: viper.GetInt64(config.AuthSignInIPLockoutAttempts),
+			lockoutDuration:   viper.GetDuration(config.AuthSignInLockoutDuration),
+		},
+		log: log,
+	}
+	if uc.signUpMode == "" {
+		uc.signUpMode = config.SignUpOpen
+	}
+	if uc.tokenIssuer == "" {
+		uc.tokenIssuer = auth.DefaultTokenIssuer
+	}
+	if uc.tokenAudience == "" {
+		uc.tokenAudience = auth.DefaultTokenAudience
+	}
+	if uc.accessTokenTTL <= 0 {
+		uc.accessTokenTTL = defaultAccessTokenTTL
 	}
+	if uc.refreshTokenTTL <= 0 {
+		uc.refreshTokenTTL = defaultRefreshTokenTTL
+	}
+	if uc.resetTokenTTL <= 0 {
+		uc.resetTokenTTL = defaultResetTokenTTL
+	}
+	if uc.inviteCodeTTL <= 0 {
+		uc.inviteCodeTTL = defaultInviteCodeTTL
+	}
+	if uc.challengeTTL <= 0 {
+		uc.challengeTTL = defaultChallengeTTL
+	}
+	uc.challengeParser = jwt.NewParser(
+		jwt.WithValidMethods([]string{keyring.AlgorithmRS256, keyring.AlgorithmEdDSA}),
+		jwt.WithIssuer(uc.tokenIssuer),
+		jwt.WithAudience(auth.ChallengeAudience(uc.tokenAudience)),
+		jwt.WithExpirationRequired(),
+	)
+	uc.signInLimits.setDefaults()
+	return uc
 }
 
-func (uc *usecase) SignIn(ctx context.Context, params *auth.SignInParams) (*models.User, string, error) {
+func (uc *usecase) SignIn(ctx context.Context, params *auth.SignInParams) (*models.User, *auth.Tokens, *auth.Challenge, error) {
+	keys := []string{attempts.UserKey(tenant.ID(ctx), params.Username)}
+	if params.IP != "" {
+		keys = append(keys, attempts.IPKey(params.IP))
+	}
+	blocked, err := uc.attempts.Blocked(ctx, keys...)
+	if err != nil {
+		// Sign in isn't blocked by the counter being unavailable.
+		uc.log.Warn("Failed to check sign in attempts", zap.Error(err))
+	}
+	if blocked > 0 {
+		return nil, nil, nil, errors.Wrapf(pErrors.ErrTooManyAttempts, "blocked for %s", blocked)
+	}
+
 	user, err := uc.usersRepo.GetByUsername(ctx, params.Username)
 	if err != nil {
-		return nil, "", err
+		if !errors.Is(err, pErrors.ErrUserNotFound) {
+			return nil, nil, nil, err
+		}
+		// Unknown users get the same response after the same time.
+		_ = uc.hasher.CompareHashAndPassword(ctx, uc.getDummyHash(ctx), params.Password)
+		uc.failSignIn(ctx, params)
+		return nil, nil, nil, errors.Wrap(pErrors.ErrWrongLoginOrPassword, err.Error())
 	}
 
 	if err = uc.hasher.CompareHashAndPassword(ctx, user.Password, params.Password); err != nil {
-		return nil, "", errors.Wrap(pErrors.ErrWrongLoginOrPassword, err.Error())
+		uc.failSignIn(ctx, params)
+		return nil, nil, nil, errors.Wrap(pErrors.ErrWrongLoginOrPassword, err.Error())
+	}
+	if err = uc.attempts.Reset(ctx, attempts.UserKey(tenant.ID(ctx), params.Username)); err != nil {
+		uc.log.Warn("Failed to reset sign in attempts", zap.Error(err))
+	}
+	if user.IsDisabled {
+		return nil, nil, nil, pErrors.ErrUserDisabled
+	}
+	if uc.hasher.NeedsRehash(user.Password) {
+		uc.rehash(ctx, user.ID, params.Password)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id":  user.ID,
-		"is_admin": user.IsAdmin,
-		"exp":      time.Now().Add(tokenExpiration).Unix(),
-	})
+	challenge, err := uc.challenge(ctx, user)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if challenge != nil {
+		uc.log.Debug("Sign In challenged", zap.Int64("user_id", user.ID))
+		return nil, nil, challenge, nil
+	}
 
-	signedString, err := token.SignedString([]byte(viper.GetString(config.AuthKey)))
+	tokens, err := uc.startSession(ctx, user)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, nil, err
 	}
 
 	uc.log.Debug("Sign In", zap.Int64("user_id", user.ID))
-	return user, signedString, nil
+	return user, tokens, nil, nil
 }
 
-func (uc *usecase) SignUp(ctx context.Context, params *auth.SignUpParams) (*models.User, string, error) {
+func (uc *usecase) SignUp(ctx context.Context, params *auth.SignUpParams) (*models.User, *auth.Tokens, error) {
+	switch uc.signUpMode {
+	case config.SignUpOpen:
+	case config.SignUpInvite:
+		if params.InviteCode == "" {
+			return nil, nil, pErrors.ErrInvalidInviteCode
+		}
+	default:
+		return nil, nil, pErrors.ErrSignUpDisabled
+	}
+
+	if err := uc.credentials.ValidateUsername(params.Username); err != nil {
+		return nil, nil, err
+	}
+	if err := uc.credentials.ValidatePassword(params.Password); err != nil {
+		return nil, nil, err
+	}
+
 	_, err := uc.usersRepo.GetByUsername(ctx, params.Username)
 	if !errors.Is(err, pErrors.ErrUserNotFound) {
 		if err != nil {
-			return nil, "", err
+			return nil, nil, err
+		}
+		return nil, nil, pErrors.ErrUserAlreadyExists
+	}
+
+	// The code is spent only when nothing else prevents the sign up.
+	if uc.signUpMode == config.SignUpInvite {
+		if err = uc.invitesRepo.Use(ctx, hashToken(params.InviteCode)); err != nil {
+			return nil, nil, err
 		}
-		return nil, "", pErrors.ErrUserAlreadyExists
 	}
 
 	hashedPassword, err := uc.hasher.GetHashedPassword(ctx, params.Password)
 	if err != nil {
-		return nil, "", pErrors.ErrGetHashedPassword
+		return nil, nil, pErrors.ErrGetHashedPassword
 	}
 
 	repParams := user.CreateParams{
@@ -77,22 +309,362 @@ func (uc *usecase) SignUp(ctx context.Context, params *auth.SignUpParams) (*mode
 	}
 	user, err := uc.usersRepo.Create(ctx, &repParams)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	payload := jwt.MapClaims{
-		"user_id":  user.ID,
-		"is_admin": user.IsAdmin,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
+	tokens, err := uc.startSession(ctx, user)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	uc.log.Debug("Sign Up", zap.Int64("user_id", user.ID))
+	return user, tokens, nil
+}
 
-	signedString, err := token.SignedString([]byte(viper.GetString(config.AuthKey)))
+func (uc *usecase) Refresh(ctx context.Context, refreshToken string) (*auth.Tokens, error) {
+	newRefreshToken, err := randomToken(refreshTokenSize)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	uc.log.Debug("Sign Up", zap.Int64("user_id", user.ID))
-	return user, signedString, nil
+	session, err := uc.sessionsRepo.Rotate(ctx, &pSession.RotateParams{
+		RefreshTokenHash:    hashToken(refreshToken),
+		NewRefreshTokenHash: hashToken(newRefreshToken),
+		ExpiresAt:           time.Now().Add(uc.refreshTokenTTL),
+	})
+	if errors.Is(err, pErrors.ErrRefreshTokenReused) {
+		// The token was stolen either from the user or by the user, the whole
+		// session can't be trusted anymore.
+		uc.log.Warn("Refresh token reused, revoking session",
+			zap.Int64("session_id", session.ID),
+			zap.Int64("user_id", session.UserID))
+		if revokeErr := uc.revokeSession(ctx, session.ID); revokeErr != nil {
+			return nil, revokeErr
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh tokens are sent without a tenant, the session knows it.
+	ctx = tenant.WithID(ctx, session.TenantID)
+	user, err := uc.usersRepo.GetByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, pErrors.ErrUserNotFound) {
+			return nil, pErrors.ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+	if user.IsDisabled {
+		return nil, pErrors.ErrUserDisabled
+	}
+
+	accessToken, expiresAt, err := uc.accessToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.log.Debug("Tokens refreshed", zap.Int64("user_id", user.ID), zap.Int64("session_id", session.ID))
+	return &auth.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+func (uc *usecase) Logout(ctx context.Context, params *auth.LogoutParams) error {
+	if err := uc.denyList.Add(ctx, denylist.TokenKey(params.TokenID), time.Until(params.ExpiresAt)); err != nil {
+		return err
+	}
+	if err := uc.revokeSession(ctx, params.SessionID); err != nil {
+		return err
+	}
+
+	uc.log.Debug("Logout", zap.Int64("session_id", params.SessionID))
+	return nil
+}
+
+func (uc *usecase) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+	return uc.usersRepo.GetByID(ctx, userID)
+}
+
+func (uc *usecase) RevokeSessions(ctx context.Context, userID, exceptSessionID int64) error {
+	sessionIDs, err := uc.sessionsRepo.RevokeByUser(ctx, userID, exceptSessionID)
+	if err != nil {
+		return err
+	}
+	for _, sessionID := range sessionIDs {
+		if err = uc.denyList.Add(ctx, denylist.SessionKey(sessionID), uc.accessTokenTTL); err != nil {
+			return err
+		}
+	}
+
+	uc.log.Debug("Sessions revoked", zap.Int64("user_id", userID), zap.Int("count", len(sessionIDs)))
+	return nil
+}
+
+func (uc *usecase) ChangePassword(ctx context.Context, params *auth.ChangePasswordParams) error {
+	if err := uc.credentials.ValidatePassword(params.NewPassword); err != nil {
+		return err
+	}
+
+	user, err := uc.usersRepo.GetByID(ctx, params.UserID)
+	if err != nil {
+		return err
+	}
+	if err = uc.hasher.CompareHashAndPassword(ctx, user.Password, params.OldPassword); err != nil {
+		return errors.Wrap(pErrors.ErrWrongPassword, err.Error())
+	}
+
+	if err = uc.setPassword(ctx, user.ID, params.NewPassword, params.SessionID); err != nil {
+		return err
+	}
+
+	uc.log.Info("Password changed", zap.Int64("user_id", user.ID))
+	return nil
+}
+
+func (uc *usecase) IssueResetToken(ctx context.Context, userID int64) (*auth.ResetToken, error) {
+	token, err := randomToken(resetTokenSize)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(uc.resetTokenTTL)
+	err = uc.resetTokensRepo.Create(ctx, &pResetToken.CreateParams{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.log.Info("Reset token issued", zap.Int64("user_id", userID))
+	return &auth.ResetToken{
+		Token:     token,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (uc *usecase) ResetPassword(ctx context.Context, params *auth.ResetPasswordParams) error {
+	if err := uc.credentials.ValidatePassword(params.NewPassword); err != nil {
+		return err
+	}
+
+	userID, tenantID, err := uc.resetTokensRepo.Use(ctx, hashToken(params.ResetToken))
+	if err != nil {
+		return err
+	}
+	ctx = tenant.WithID(ctx, tenantID)
+	if err = uc.setPassword(ctx, userID, params.NewPassword, 0); err != nil {
+		if errors.Is(err, pErrors.ErrUserNotFound) {
+			return pErrors.ErrInvalidResetToken
+		}
+		return err
+	}
+
+	uc.log.Info("Password reset", zap.Int64("user_id", userID))
+	return nil
+}
+
+func (uc *usecase) IssueInviteCode(ctx context.Context, createdBy int64) (*auth.InviteCode, error) {
+	code, err := randomToken(inviteCodeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(uc.inviteCodeTTL)
+	err = uc.invitesRepo.Create(ctx, &pInvite.CreateParams{
+		CodeHash:  hashToken(code),
+		CreatedBy: createdBy,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	uc.log.Info("Invite code issued", zap.Int64("created_by", createdBy))
+	return &auth.InviteCode{
+		Code:      code,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func (uc *usecase) Unlock(ctx context.Context, username string) error {
+	if err := uc.attempts.Reset(ctx, attempts.UserKey(tenant.ID(ctx), username)); err != nil {
+		return err
+	}
+	user, err := uc.usersRepo.GetByUsername(ctx, username)
+	if err != nil && !errors.Is(err, pErrors.ErrUserNotFound) {
+		return err
+	}
+	if user != nil {
+		if err = uc.attempts.Reset(ctx, attempts.TwoFactorKey(user.ID)); err != nil {
+			return err
+		}
+	}
+
+	uc.log.Info("Sign in unlocked", zap.String("username", username))
+	return nil
+}
+
+// failSignIn counts the failure and blocks the next attempts if needed.
+// Failures of the counter are only logged.
+func (uc *usecase) failSignIn(ctx context.Context, params *auth.SignInParams) {
+	limits := &uc.signInLimits
+
+	userKey := attempts.UserKey(tenant.ID(ctx), params.Username)
+	failures, err := uc.attempts.Fail(ctx, userKey, limits.window)
+	if err == nil {
+		if delay := limits.userDelay(failures); delay > 0 {
+			err = uc.attempts.Block(ctx, userKey, delay)
+		}
+		if failures == limits.lockoutAttempts {
+			uc.log.Warn("Sign in locked", zap.String("username", params.Username), zap.String("ip", params.IP))
+		}
+	}
+	if err != nil {
+		uc.log.Warn("Failed to count sign in attempt", zap.Error(err))
+	}
+
+	if params.IP == "" {
+		return
+	}
+	ipKey := attempts.IPKey(params.IP)
+	failures, err = uc.attempts.Fail(ctx, ipKey, limits.window)
+	if err == nil && failures >= limits.ipLockoutAttempts {
+		err = uc.attempts.Block(ctx, ipKey, limits.lockoutDuration)
+		if failures == limits.ipLockoutAttempts {
+			uc.log.Warn("Sign in locked for IP", zap.String("ip", params.IP))
+		}
+	}
+	if err != nil {
+		uc.log.Warn("Failed to count sign in attempt", zap.Error(err))
+	}
+}
+
+func (uc *usecase) getDummyHash(ctx context.Context) string {
+	uc.dummyHashOnce.Do(func() {
+		hash, err := uc.hasher.GetHashedPassword(ctx, "dummy password")
+		if err != nil {
+			uc.log.Error("Failed to hash dummy password", zap.Error(err))
+		}
+		uc.dummyHash = hash
+	})
+	return uc.dummyHash
+}
+
+// setPassword stores the new password and revokes sessions which were started
+// with the old one.
+func (uc *usecase) setPassword(ctx context.Context, userID int64, password string, exceptSessionID int64) error {
+	hashedPassword, err := uc.hasher.GetHashedPassword(ctx, password)
+	if err != nil {
+		return pErrors.ErrGetHashedPassword
+	}
+
+	_, err = uc.usersRepo.Update(ctx, &user.UpdateParams{
+		ID:       userID,
+		Password: &hashedPassword,
+	})
+	if err != nil {
+		return err
+	}
+	return uc.RevokeSessions(ctx, userID, exceptSessionID)
+}
+
+// rehash replaces an outdated hash of the password. Sign in doesn't fail if
+// the hash can't be replaced, it's tried again on the next one.
+func (uc *usecase) rehash(ctx context.Context, userID int64, password string) {
+	hashedPassword, err := uc.hasher.GetHashedPassword(ctx, password)
+	if err != nil {
+		uc.log.Warn("Failed to rehash password", zap.Int64("user_id", userID), zap.Error(err))
+		return
+	}
+	_, err = uc.usersRepo.Update(ctx, &user.UpdateParams{ID: userID, Password: &hashedPassword})
+	if err != nil {
+		uc.log.Warn("Failed to rehash password", zap.Int64("user_id", userID), zap.Error(err))
+		return
+	}
+	uc.log.Info("Password rehashed", zap.Int64("user_id", userID))
+}
+
+// revokeSession forbids refreshing the session and rejects access tokens
+// already issued within it.
+func (uc *usecase) revokeSession(ctx context.Context, sessionID int64) error {
+	if err := uc.sessionsRepo.Revoke(ctx, sessionID); err != nil {
+		return err
+	}
+	return uc.denyList.Add(ctx, denylist.SessionKey(sessionID), uc.accessTokenTTL)
+}
+
+func (uc *usecase) startSession(ctx context.Context, user *models.User) (*auth.Tokens, error) {
+	refreshToken, err := randomToken(refreshTokenSize)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := uc.sessionsRepo.Create(ctx, &pSession.CreateParams{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(uc.refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, expiresAt, err := uc.accessToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+func (uc *usecase) accessToken(user *models.User, sessionID int64) (string, time.Time, error) {
+	tokenID, err := randomToken(tokenIDSize)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(uc.accessTokenTTL)
+	signedString, err := uc.keys.Sign(&auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    uc.tokenIssuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			Audience:  jwt.ClaimStrings{uc.tokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		UserID:      user.ID,
+		Role:        user.Role,
+		Permissions: user.Permissions,
+		SessionID:   sessionID,
+		TenantID:    user.TenantID,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signedString, expiresAt, nil
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken is enough for refresh tokens: unlike passwords they have full entropy.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
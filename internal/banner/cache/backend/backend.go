@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	memcachedCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/memcached"
+	redisCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/redis"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/storage"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// Backend is the cache shared by the replicas, selected by CACHE_BACKEND.
+type Backend struct {
+	Name  string
+	Cache cache.Cache
+	// Ping checks the connection to the cache servers.
+	Ping func(ctx context.Context) error
+	// Close closes the connections opened by New.
+	Close func() error
+}
+
+// New creates the Redis or Memcached cache. The Redis client is shared with
+// the deny list, so it is created and closed by the caller. The memory
+// backend is local to the process and is not supported.
+func New(name string, redisClient redis.UniversalClient, codec *cache.Codec, log *zap.Logger) (*Backend, error) {
+	switch name {
+	case config.BackendRedis:
+		if redisClient == nil {
+			return nil, errors.New("redis cache backend without redis client")
+		}
+		return &Backend{
+			Name:  name,
+			Cache: redisCache.New(redisClient, codec, log),
+			Ping:  func(ctx context.Context) error { return redisClient.Ping(ctx).Err() },
+			Close: func() error { return nil },
+		}, nil
+	case config.BackendMemcached:
+		client := storage.NewMemcachedClient(log)
+		return &Backend{
+			Name:  name,
+			Cache: memcachedCache.New(client, codec, log),
+			Ping:  func(ctx context.Context) error { return client.Ping() },
+			Close: client.Close,
+		}, nil
+	default:
+		return nil, errors.Errorf("unsupported cache backend %q", name)
+	}
+}
@@ -0,0 +1,154 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Breaker is a circuit breaker around a cache. After threshold consecutive
+// failures it stops calling the cache and probes it every probeInterval until
+// the probe succeeds.
+type Breaker struct {
+	cache         cache.Cache
+	probe         func(ctx context.Context) error
+	threshold     int
+	probeInterval time.Duration
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	opened   chan struct{}
+
+	log *zap.Logger
+}
+
+func New(cache cache.Cache, probe func(ctx context.Context) error, threshold int, probeInterval time.Duration, log *zap.Logger) *Breaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &Breaker{
+		cache:         cache,
+		probe:         probe,
+		threshold:     threshold,
+		probeInterval: probeInterval,
+		opened:        make(chan struct{}, 1),
+		log:           log,
+	}
+}
+
+func (b *Breaker) Set(ctx context.Context, key string, value *cache.Value) error {
+	if b.isOpen() {
+		return pErrors.ErrCacheUnavailable
+	}
+	err := b.cache.Set(ctx, key, value)
+	b.record(ctx, err)
+	return err
+}
+
+func (b *Breaker) Get(ctx context.Context, key string) (*cache.Value, error) {
+	if b.isOpen() {
+		return nil, pErrors.ErrCacheUnavailable
+	}
+	value, err := b.cache.Get(ctx, key)
+	b.record(ctx, err)
+	return value, err
+}
+
+func (b *Breaker) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if b.isOpen() {
+		return 0, pErrors.ErrCacheUnavailable
+	}
+	ttl, err := b.cache.TTL(ctx, key)
+	b.record(ctx, err)
+	return ttl, err
+}
+
+func (b *Breaker) Delete(ctx context.Context, pattern string) (int64, error) {
+	if b.isOpen() {
+		return 0, pErrors.ErrCacheUnavailable
+	}
+	deleted, err := b.cache.Delete(ctx, pattern)
+	b.record(ctx, err)
+	return deleted, err
+}
+
+// Open stops calls to the cache until the next successful probe.
+func (b *Breaker) Open() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trip()
+}
+
+// Run probes the cache while the breaker is open, until ctx is done.
+func (b *Breaker) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.probeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-b.opened:
+		}
+
+		for b.isOpen() {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			probeCtx, cancel := context.WithTimeout(ctx, b.probeInterval)
+			err := b.probe(probeCtx)
+			cancel()
+			if err != nil {
+				b.log.Debug("Cache breaker: probe failed", zap.Error(err))
+				continue
+			}
+
+			b.mu.Lock()
+			b.open = false
+			b.failures = 0
+			b.mu.Unlock()
+			b.log.Info("Cache breaker: closed, cache is available again")
+		}
+	}
+}
+
+func (b *Breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.open
+}
+
+func (b *Breaker) record(ctx context.Context, err error) {
+	// The caller gave up waiting, which says nothing about the cache.
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil || errors.Is(err, pErrors.ErrCacheMiss) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold && !b.open {
+		b.log.Warn("Cache breaker: opened", zap.Int("failures", b.failures), zap.Error(err))
+		b.trip()
+	}
+}
+
+func (b *Breaker) trip() {
+	b.open = true
+	select {
+	case b.opened <- struct{}{}:
+	default:
+	}
+}
@@ -1,13 +1,69 @@
 package cache
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
 
+const keyPrefix = "banner:"
+
+// Value is a cached response. Body holds the serialized JSON, nil for an empty body.
 type Value struct {
-	Code int `json:"code"`
-	Body any `json:"body"`
+	Code int
+	Body []byte
+}
+
+func NewJSONValue(code int, body any) (*Value, error) {
+	value := &Value{Code: code}
+	if body == nil {
+		return value, nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	value.Body = data
+	return value, nil
 }
 
 type Cache interface {
 	Set(ctx context.Context, key string, value *Value) error
 	Get(ctx context.Context, key string) (*Value, error)
+	TTL(ctx context.Context, key string) (time.Duration, error)
+	// Delete removes all keys matching the glob-style pattern and returns their
+	// number, or -1 if the backend can't count them.
+	Delete(ctx context.Context, pattern string) (int64, error)
+}
+
+// Invalidator evicts keys matching the pattern on every instance of the service.
+type Invalidator interface {
+	Invalidate(ctx context.Context, pattern string) error
+}
+
+// Key is "banner:<tenant>:<feature>:<tag>:<is_admin>", so that tenants never
+// share cached banners.
+func Key(tenantID, featureID, tagID int64, isAdmin bool) string {
+	return fmt.Sprintf("%s%d:%d:%d:%t", keyPrefix, tenantID, featureID, tagID, isAdmin)
+}
+
+func AllPattern() string {
+	return keyPrefix + "*"
+}
+
+func TenantPattern(tenantID int64) string {
+	return fmt.Sprintf("%s%d:*:*:*", keyPrefix, tenantID)
+}
+
+func FeaturePattern(tenantID, featureID int64) string {
+	return fmt.Sprintf("%s%d:%d:*:*", keyPrefix, tenantID, featureID)
+}
+
+func TagPattern(tenantID, tagID int64) string {
+	return fmt.Sprintf("%s%d:*:%d:*", keyPrefix, tenantID, tagID)
+}
+
+func FeatureTagPattern(tenantID, featureID, tagID int64) string {
+	return fmt.Sprintf("%s%d:%d:%d:*", keyPrefix, tenantID, featureID, tagID)
 }
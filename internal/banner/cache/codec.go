@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"encoding/binary"
+	"sync"
+
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Encoded value layout:
+//
+//	version     1 byte
+//	compression 1 byte
+//	code        2 bytes, big endian
+//	body        the rest, compressed as stated above
+const (
+	encodingVersion = 1
+	headerSize      = 4
+)
+
+const (
+	CompressionNone   = "none"
+	CompressionZstd   = "zstd"
+	CompressionSnappy = "snappy"
+)
+
+const (
+	compressionNone byte = iota
+	compressionZstd
+	compressionSnappy
+)
+
+// Codec converts values to the binary form stored by remote caches.
+// Bodies of at least threshold bytes are compressed.
+//
+// The zstd encoder is only created for zstd compression. The decoder is
+// created on the first zstd entry, which may be written by an instance with
+// other settings.
+type Codec struct {
+	compression byte
+	threshold   int
+	zstdEncoder *zstd.Encoder
+
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+	zstdDecoderErr  error
+}
+
+func NewCodec(compression string, threshold int) (*Codec, error) {
+	c := &Codec{threshold: threshold}
+	switch compression {
+	case CompressionNone, "":
+		c.compression = compressionNone
+	case CompressionZstd:
+		c.compression = compressionZstd
+	case CompressionSnappy:
+		c.compression = compressionSnappy
+	default:
+		return nil, errors.Errorf("unknown cache compression %q", compression)
+	}
+
+	if c.compression == compressionZstd {
+		var err error
+		c.zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *Codec) Encode(value *Value) []byte {
+	compression := compressionNone
+	body := value.Body
+	if c.compression != compressionNone && len(body) >= c.threshold {
+		compression = c.compression
+	}
+
+	data := make([]byte, headerSize, headerSize+len(body))
+	data[0] = encodingVersion
+	data[1] = compression
+	binary.BigEndian.PutUint16(data[2:headerSize], uint16(value.Code))
+
+	switch compression {
+	case compressionZstd:
+		return c.zstdEncoder.EncodeAll(body, data)
+	case compressionSnappy:
+		return append(data, s2.EncodeSnappy(nil, body)...)
+	default:
+		return append(data, body...)
+	}
+}
+
+// Decode returns ErrCacheMiss for data written in another format or corrupted,
+// so that such entries are overwritten instead of being reported as failures.
+func (c *Codec) Decode(data []byte) (*Value, error) {
+	if len(data) < headerSize || data[0] != encodingVersion {
+		return nil, errors.Wrap(pErrors.ErrCacheMiss, "unsupported encoding")
+	}
+
+	value := &Value{Code: int(binary.BigEndian.Uint16(data[2:headerSize]))}
+	body := data[headerSize:]
+	if len(body) == 0 {
+		return value, nil
+	}
+
+	var err error
+	switch data[1] {
+	case compressionNone:
+		value.Body = body
+	case compressionZstd:
+		var decoder *zstd.Decoder
+		if decoder, err = c.decoder(); err != nil {
+			return nil, err
+		}
+		value.Body, err = decoder.DecodeAll(body, nil)
+	case compressionSnappy:
+		value.Body, err = s2.Decode(nil, body)
+	default:
+		return nil, errors.Wrap(pErrors.ErrCacheMiss, "unsupported compression")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(pErrors.ErrCacheMiss, "failed to decompress value: %v", err)
+	}
+	return value, nil
+}
+
+func (c *Codec) decoder() (*zstd.Decoder, error) {
+	c.zstdDecoderOnce.Do(func() {
+		c.zstdDecoder, c.zstdDecoderErr = zstd.NewReader(nil)
+	})
+	return c.zstdDecoder, c.zstdDecoderErr
+}
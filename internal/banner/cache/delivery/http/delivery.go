@@ -0,0 +1,129 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	FeatureIDKey = "feature_id"
+	TagIDKey     = "tag_id"
+	IsAdminKey   = "is_admin"
+)
+
+type delivery struct {
+	cache cache.Cache
+	stats *cache.Stats
+	log   *zap.Logger
+}
+
+func RegisterHandlers(mux *mux.Router, cache cache.Cache, stats *cache.Stats, log *zap.Logger, checkAuth mw.Middleware, checkPermission mw.PermissionMiddleware) {
+	dlv := delivery{
+		cache: cache,
+		stats: stats,
+		log:   log,
+	}
+
+	const (
+		cachePath      = constants.ApiPrefix + "/cache"
+		cacheEntryPath = cachePath + "/entry"
+		cacheStatsPath = cachePath + "/stats"
+	)
+
+	adminAccess := checkPermission(models.PermissionCacheManage)
+	mux.HandleFunc(cacheEntryPath, checkAuth(adminAccess(dlv.getEntry))).Methods(http.MethodGet)
+	mux.HandleFunc(cacheStatsPath, checkAuth(adminAccess(dlv.getStats))).Methods(http.MethodGet)
+	mux.HandleFunc(cachePath, checkAuth(adminAccess(dlv.flush))).Methods(http.MethodDelete)
+}
+
+func (d *delivery) getEntry(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	featureID, err := strconv.ParseInt(queryParams.Get(FeatureIDKey), 10, 64)
+	if err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrBadFeatureIDParam)
+		return
+	}
+	tagID, err := strconv.ParseInt(queryParams.Get(TagIDKey), 10, 64)
+	if err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrBadTagIDParam)
+		return
+	}
+	isAdmin, err := strconv.ParseBool(queryParams.Get(IsAdminKey))
+	if queryParams.Get(IsAdminKey) != "" && err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrBadIsAdminParam)
+		return
+	}
+
+	key := cache.Key(tenant.ID(r.Context()), featureID, tagID, isAdmin)
+	value, err := d.cache.Get(r.Context(), key)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	ttl, err := d.cache.TTL(r.Context(), key)
+	switch {
+	case errors.Is(err, pErrors.ErrCacheMiss):
+		// The entry expired or was evicted right after it was read.
+		ttl = 0
+	case err != nil:
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	response := newEntryResponse(key, value, ttl)
+	pHTTP.SendJSON(w, r, http.StatusOK, response)
+}
+
+func (d *delivery) flush(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	featureID, err := strconv.ParseInt(queryParams.Get(FeatureIDKey), 10, 64)
+	if queryParams.Get(FeatureIDKey) != "" && err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrBadFeatureIDParam)
+		return
+	}
+	tagID, err := strconv.ParseInt(queryParams.Get(TagIDKey), 10, 64)
+	if queryParams.Get(TagIDKey) != "" && err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrBadTagIDParam)
+		return
+	}
+
+	// Admins only flush banners of their tenant.
+	tenantID := tenant.ID(r.Context())
+	var pattern string
+	switch {
+	case featureID > 0 && tagID > 0:
+		pattern = cache.FeatureTagPattern(tenantID, featureID, tagID)
+	case featureID > 0:
+		pattern = cache.FeaturePattern(tenantID, featureID)
+	case tagID > 0:
+		pattern = cache.TagPattern(tenantID, tagID)
+	default:
+		pattern = cache.TenantPattern(tenantID)
+	}
+
+	deleted, err := d.cache.Delete(r.Context(), pattern)
+	if err != nil {
+		d.log.Error("Failed to flush cache", zap.String("pattern", pattern), zap.Error(err))
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	d.log.Info("Cache flushed", zap.String("pattern", pattern), zap.Int64("deleted", deleted))
+	pHTTP.SendJSON(w, r, http.StatusOK, newFlushResponse(deleted))
+}
+
+func (d *delivery) getStats(w http.ResponseWriter, r *http.Request) {
+	response := newStatsResponse(d.stats.Snapshot())
+	pHTTP.SendJSON(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+)
+
+// API responses
+type entryResponse struct {
+	Key        string          `json:"key"`
+	Code       int             `json:"code"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	TTLSeconds float64         `json:"ttl_seconds"`
+}
+
+func newEntryResponse(key string, value *cache.Value, ttl time.Duration) *entryResponse {
+	return &entryResponse{
+		Key:        key,
+		Code:       value.Code,
+		Body:       value.Body,
+		TTLSeconds: ttl.Seconds(),
+	}
+}
+
+// flushResponse omits the number of deleted keys if the cache can't count them.
+type flushResponse struct {
+	Deleted *int64 `json:"deleted,omitempty"`
+}
+
+func newFlushResponse(deleted int64) *flushResponse {
+	if deleted < 0 {
+		return &flushResponse{}
+	}
+	return &flushResponse{
+		Deleted: &deleted,
+	}
+}
+
+type statsResponse struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	Errors   int64   `json:"errors"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+func newStatsResponse(stats cache.StatsSnapshot) *statsResponse {
+	return &statsResponse{
+		Hits:     stats.Hits,
+		Misses:   stats.Misses,
+		Errors:   stats.Errors,
+		HitRatio: stats.HitRatio,
+	}
+}
@@ -0,0 +1,119 @@
+package invalidation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	channel = "banner:invalidation"
+
+	pingInterval = 30 * time.Second
+	minBackoff   = 100 * time.Millisecond
+	maxBackoff   = 10 * time.Second
+)
+
+type message struct {
+	Pattern string `json:"pattern"`
+}
+
+// Bus propagates evictions of the local cache between instances over Redis pub/sub.
+type Bus struct {
+	rdb   redis.UniversalClient
+	local cache.Cache
+	log   *zap.Logger
+}
+
+func New(rdb redis.UniversalClient, local cache.Cache, log *zap.Logger) *Bus {
+	return &Bus{
+		rdb:   rdb,
+		local: local,
+		log:   log,
+	}
+}
+
+// Invalidate evicts matching local entries and asks other instances to do the same.
+func (b *Bus) Invalidate(ctx context.Context, pattern string) error {
+	b.evict(ctx, pattern)
+
+	payload, err := json.Marshal(message{Pattern: pattern})
+	if err != nil {
+		return err
+	}
+	if err = b.rdb.Publish(ctx, channel, payload).Err(); err != nil {
+		b.log.Error("Invalidation: failed to publish", zap.String("pattern", pattern), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Run listens for invalidation messages until ctx is done. Whenever the
+// subscription is (re)established the local cache is flushed, because messages
+// published while the instance was disconnected are lost.
+func (b *Bus) Run(ctx context.Context) {
+	pubSub := b.rdb.Subscribe(ctx, channel)
+	go func() {
+		<-ctx.Done()
+		_ = pubSub.Close()
+	}()
+
+	backoff := minBackoff
+	for {
+		msg, err := pubSub.ReceiveTimeout(ctx, pingInterval)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if err = pubSub.Ping(ctx); err == nil {
+					continue
+				}
+			}
+
+			b.log.Warn("Invalidation: subscription failed", zap.Error(err), zap.Duration("retry_in", backoff))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+			continue
+		}
+		backoff = minBackoff
+
+		switch msg := msg.(type) {
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				b.log.Info("Invalidation: subscribed, flushing local cache")
+				b.evict(ctx, cache.AllPattern())
+			}
+		case *redis.Message:
+			var m message
+			if err = json.Unmarshal([]byte(msg.Payload), &m); err != nil || m.Pattern == "" {
+				b.log.Warn("Invalidation: bad message, flushing local cache", zap.String("payload", msg.Payload))
+				m.Pattern = cache.AllPattern()
+			}
+			b.evict(ctx, m.Pattern)
+		}
+	}
+}
+
+func (b *Bus) evict(ctx context.Context, pattern string) {
+	deleted, err := b.local.Delete(ctx, pattern)
+	if err != nil {
+		b.log.Error("Invalidation: failed to evict local entries", zap.String("pattern", pattern), zap.Error(err))
+		return
+	}
+	b.log.Debug("Invalidation: local entries evicted", zap.String("pattern", pattern), zap.Int64("deleted", deleted))
+}
@@ -0,0 +1,217 @@
+package memcached
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	expiration = 5 * time.Minute
+
+	genPrefix = "gen:"
+	anyPart   = "*"
+)
+
+var keyPrefix = strings.TrimSuffix(cache.AllPattern(), anyPart)
+
+// memcachedCache stores values under versioned keys. Memcached can't scan
+// keys, so Delete bumps the generation of the matching namespace (all keys, a
+// tenant, a feature, a tag or a feature-tag pair of a tenant) and the old
+// entries are never read again and expire on their own.
+//
+// The expiration time is kept in the item flags to answer TTL.
+type memcachedCache struct {
+	client *memcache.Client
+	codec  *cache.Codec
+	log    *zap.Logger
+}
+
+func New(client *memcache.Client, codec *cache.Codec, log *zap.Logger) cache.Cache {
+	return &memcachedCache{
+		client: client,
+		codec:  codec,
+		log:    log,
+	}
+}
+
+func (c *memcachedCache) Set(_ context.Context, key string, value *cache.Value) error {
+	versioned, err := c.versionedKey(key)
+	if err != nil {
+		return err
+	}
+	err = c.client.Set(&memcache.Item{
+		Key:        versioned,
+		Value:      c.codec.Encode(value),
+		Flags:      uint32(time.Now().Add(expiration).Unix()),
+		Expiration: int32(expiration.Seconds()),
+	})
+	if err != nil {
+		c.log.Error("Cache: failed to set key-value", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (c *memcachedCache) Get(_ context.Context, key string) (*cache.Value, error) {
+	item, err := c.get(key)
+	if err != nil {
+		return nil, err
+	}
+	value, err := c.codec.Decode(item.Value)
+	if err != nil {
+		// A corrupted entry is overwritten like a missing one.
+		c.log.Warn("Cache: failed to decode value", zap.String("key", key), zap.Error(err))
+		return nil, pErrors.ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (c *memcachedCache) TTL(_ context.Context, key string) (time.Duration, error) {
+	item, err := c.get(key)
+	if err != nil {
+		return 0, err
+	}
+	ttl := time.Until(time.Unix(int64(item.Flags), 0)).Truncate(time.Second)
+	if ttl <= 0 {
+		return 0, pErrors.ErrCacheMiss
+	}
+	return ttl, nil
+}
+
+// Delete invalidates the namespace described by pattern. Patterns other than
+// the ones built by the cache package invalidate all keys. The number of
+// removed keys is unknown, so it is always -1.
+func (c *memcachedCache) Delete(_ context.Context, pattern string) (int64, error) {
+	genKey := namespace(pattern)
+	_, err := c.client.Increment(genKey, 1)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		// A new counter is already different from the one used by stored keys.
+		_, err = c.initGeneration(genKey)
+	}
+	if err != nil {
+		c.log.Error("Cache: failed to delete keys", zap.String("pattern", pattern), zap.Error(err))
+		return 0, err
+	}
+
+	c.log.Debug("Cache: namespace invalidated", zap.String("pattern", pattern), zap.String("generation", genKey))
+	return -1, nil
+}
+
+func (c *memcachedCache) get(key string) (*memcache.Item, error) {
+	versioned, err := c.versionedKey(key)
+	if err != nil {
+		return nil, err
+	}
+	item, err := c.client.Get(versioned)
+	if err != nil {
+		if errors.Is(err, memcache.ErrCacheMiss) {
+			return nil, pErrors.ErrCacheMiss
+		}
+		c.log.Debug("Cache: failed to get value", zap.Error(err))
+		return nil, err
+	}
+	return item, nil
+}
+
+// versionedKey appends the current generations of all namespaces the key
+// belongs to.
+func (c *memcachedCache) versionedKey(key string) (string, error) {
+	tenantID, featureID, tagID, ok := parse(strings.TrimPrefix(key, keyPrefix))
+	genKeys := []string{namespace(cache.AllPattern())}
+	if ok {
+		genKeys = append(genKeys,
+			genPrefix+"tn:"+tenantID,
+			genPrefix+"f:"+tenantID+":"+featureID,
+			genPrefix+"t:"+tenantID+":"+tagID,
+			genPrefix+"ft:"+tenantID+":"+featureID+":"+tagID,
+		)
+	}
+
+	items, err := c.client.GetMulti(genKeys)
+	if err != nil {
+		c.log.Error("Cache: failed to get generations", zap.Error(err))
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(key)
+	for i, genKey := range genKeys {
+		item, found := items[genKey]
+		if !found {
+			if item, err = c.initGeneration(genKey); err != nil {
+				c.log.Error("Cache: failed to init generation", zap.Error(err))
+				return "", err
+			}
+		}
+		gen := string(item.Value)
+		if i == 0 {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('.')
+		}
+		b.WriteString(gen)
+	}
+	return b.String(), nil
+}
+
+// initGeneration creates a missing counter or reads the one created concurrently.
+func (c *memcachedCache) initGeneration(genKey string) (*memcache.Item, error) {
+	item := &memcache.Item{Key: genKey, Value: initialGeneration()}
+	err := c.client.Add(item)
+	if errors.Is(err, memcache.ErrNotStored) {
+		return c.client.Get(genKey)
+	}
+	return item, err
+}
+
+// namespace returns the generation key invalidated by pattern.
+func namespace(pattern string) string {
+	if !strings.HasPrefix(pattern, keyPrefix) {
+		return genPrefix + "all"
+	}
+	tenantID, featureID, tagID, ok := parse(strings.TrimPrefix(pattern, keyPrefix))
+	switch {
+	case !ok, tenantID == anyPart:
+		return genPrefix + "all"
+	case featureID == anyPart && tagID == anyPart:
+		return genPrefix + "tn:" + tenantID
+	case tagID == anyPart:
+		return genPrefix + "f:" + tenantID + ":" + featureID
+	case featureID == anyPart:
+		return genPrefix + "t:" + tenantID + ":" + tagID
+	default:
+		return genPrefix + "ft:" + tenantID + ":" + featureID + ":" + tagID
+	}
+}
+
+// parse splits "<tenant>:<feature>:<tag>:<is_admin>", where tenant, feature
+// and tag are ids or "*".
+func parse(s string) (tenantID, featureID, tagID string, ok bool) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 4 {
+		return "", "", "", false
+	}
+	for _, part := range parts[:3] {
+		if part == anyPart {
+			continue
+		}
+		if _, err := strconv.ParseInt(part, 10, 64); err != nil {
+			return "", "", "", false
+		}
+	}
+	return parts[0], parts[1], parts[2], true
+}
+
+// initialGeneration starts a counter from the current time, so that a counter
+// evicted by memcached never returns to a value used before.
+func initialGeneration() []byte {
+	return []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+}
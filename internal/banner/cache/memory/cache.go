@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultExpiration = 5 * time.Minute
+)
+
+type entry struct {
+	value     *cache.Value
+	expiresAt time.Time
+}
+
+// memoryCache drops expired entries on access and sweeps the ones that are
+// never read again on writes.
+type memoryCache struct {
+	mu         sync.RWMutex
+	entries    map[string]entry
+	expiration time.Duration
+	lastSweep  time.Time
+	log        *zap.Logger
+}
+
+func New(log *zap.Logger) cache.Cache {
+	return NewWithExpiration(DefaultExpiration, log)
+}
+
+func NewWithExpiration(expiration time.Duration, log *zap.Logger) cache.Cache {
+	return &memoryCache{
+		entries:    make(map[string]entry),
+		expiration: expiration,
+		lastSweep:  time.Now(),
+		log:        log,
+	}
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value *cache.Value) error {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry{
+		value:     value,
+		expiresAt: now.Add(c.expiration),
+	}
+	c.sweep(now)
+	return nil
+}
+
+// sweep drops expired entries. It runs at most once per expiration, so that
+// the cost is spread over many writes.
+func (c *memoryCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.expiration {
+		return
+	}
+	c.lastSweep = now
+	for key, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) (*cache.Value, error) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, pErrors.ErrCacheMiss
+	}
+	if time.Now().After(e.expiresAt) {
+		c.mu.Lock()
+		if e, ok = c.entries[key]; ok && time.Now().After(e.expiresAt) {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+		return nil, pErrors.ErrCacheMiss
+	}
+	return e.value, nil
+}
+
+func (c *memoryCache) TTL(_ context.Context, key string) (time.Duration, error) {
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	ttl := time.Until(e.expiresAt)
+	if !ok || ttl <= 0 {
+		return 0, pErrors.ErrCacheMiss
+	}
+	return ttl, nil
+}
+
+func (c *memoryCache) Delete(_ context.Context, pattern string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var deleted int64
+	for key := range c.entries {
+		matched, err := path.Match(pattern, key)
+		if err != nil {
+			return deleted, err
+		}
+		if matched {
+			delete(c.entries, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,32 @@
+package noop
+
+import (
+	"context"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+)
+
+// noopCache is used when banners are served without a cache, e.g. from the snapshot.
+type noopCache struct{}
+
+func New() cache.Cache {
+	return noopCache{}
+}
+
+func (noopCache) Set(context.Context, string, *cache.Value) error {
+	return nil
+}
+
+func (noopCache) Get(context.Context, string) (*cache.Value, error) {
+	return nil, pErrors.ErrCacheMiss
+}
+
+func (noopCache) TTL(context.Context, string) (time.Duration, error) {
+	return 0, pErrors.ErrCacheMiss
+}
+
+func (noopCache) Delete(context.Context, string) (int64, error) {
+	return 0, nil
+}
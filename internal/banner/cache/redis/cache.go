@@ -2,36 +2,40 @@ package redis
 
 import (
 	"context"
-	"encoding/json"
 	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/pkg/errors"
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
+	"sync/atomic"
 	"time"
 )
 
 const (
 	expiration = 5 * time.Minute
+	scanCount  = 100
+
+	// Returned by TTL for a missing key.
+	keyNotExistTTL = -2
 )
 
 type redisCache struct {
-	rdb *redis.Client
-	log *zap.Logger
+	rdb   redis.UniversalClient
+	codec *cache.Codec
+	log   *zap.Logger
 }
 
-func New(rdb *redis.Client, log *zap.Logger) cache.Cache {
+// New works on top of a standalone, sentinel or cluster client.
+func New(rdb redis.UniversalClient, codec *cache.Codec, log *zap.Logger) cache.Cache {
 	return &redisCache{
-		rdb: rdb,
-		log: log,
+		rdb:   rdb,
+		codec: codec,
+		log:   log,
 	}
 }
 
 func (c *redisCache) Set(ctx context.Context, key string, value *cache.Value) error {
-	jsonValue, err := json.Marshal(value)
-	if err != nil {
-		c.log.Error("Cache: failed to marshal value", zap.Error(err))
-		return err
-	}
-	err = c.rdb.Set(ctx, key, jsonValue, expiration).Err()
+	err := c.rdb.Set(ctx, key, c.codec.Encode(value), expiration).Err()
 	if err != nil {
 		c.log.Error("Cache: failed to set key-value", zap.Error(err))
 		return err
@@ -40,17 +44,100 @@ func (c *redisCache) Set(ctx context.Context, key string, value *cache.Value) er
 }
 
 func (c *redisCache) Get(ctx context.Context, key string) (*cache.Value, error) {
-	var jsonValue []byte
-	err := c.rdb.Get(ctx, key).Scan(&jsonValue)
+	data, err := c.rdb.Get(ctx, key).Bytes()
 	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, pErrors.ErrCacheMiss
+		}
 		c.log.Debug("Cache: failed to get value", zap.Error(err))
 		return nil, err
 	}
-	value := new(cache.Value)
-	err = json.Unmarshal(jsonValue, value)
+	value, err := c.codec.Decode(data)
 	if err != nil {
-		c.log.Error("Cache: failed to unmarshal value", zap.Error(err))
-		return nil, err
+		// A corrupted entry is overwritten like a missing one.
+		c.log.Warn("Cache: failed to decode value", zap.String("key", key), zap.Error(err))
+		return nil, pErrors.ErrCacheMiss
 	}
 	return value, nil
 }
+
+func (c *redisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := c.rdb.TTL(ctx, key).Result()
+	if err != nil {
+		c.log.Error("Cache: failed to get ttl", zap.Error(err))
+		return 0, err
+	}
+	if ttl == keyNotExistTTL {
+		return 0, pErrors.ErrCacheMiss
+	}
+	return ttl, nil
+}
+
+// Delete removes keys matching pattern. In cluster mode every master is scanned.
+func (c *redisCache) Delete(ctx context.Context, pattern string) (int64, error) {
+	cluster, ok := c.rdb.(*redis.ClusterClient)
+	if !ok {
+		return c.deleteMatching(ctx, c.rdb, pattern)
+	}
+
+	var deleted atomic.Int64
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		n, err := c.deleteMatching(ctx, node, pattern)
+		deleted.Add(n)
+		return err
+	})
+	return deleted.Load(), err
+}
+
+func (c *redisCache) deleteMatching(ctx context.Context, rdb redis.Cmdable, pattern string) (int64, error) {
+	var deleted int64
+	iter := rdb.Scan(ctx, 0, pattern, scanCount).Iterator()
+	keys := make([]string, 0, scanCount)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == scanCount {
+			n, err := c.unlink(ctx, rdb, keys)
+			deleted += n
+			if err != nil {
+				return deleted, err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		c.log.Error("Cache: failed to scan keys", zap.Error(err))
+		return deleted, err
+	}
+	if len(keys) > 0 {
+		n, err := c.unlink(ctx, rdb, keys)
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+
+	c.log.Debug("Cache: keys deleted", zap.String("pattern", pattern), zap.Int64("deleted", deleted))
+	return deleted, nil
+}
+
+// unlink deletes keys one per command in a pipeline, since in cluster mode
+// keys of a batch may belong to different hash slots.
+func (c *redisCache) unlink(ctx context.Context, rdb redis.Cmdable, keys []string) (int64, error) {
+	var deleted int64
+	cmds, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Unlink(ctx, key)
+		}
+		return nil
+	})
+	for _, cmd := range cmds {
+		if n, cmdErr := cmd.(*redis.IntCmd).Result(); cmdErr == nil {
+			deleted += n
+		}
+	}
+	if err != nil {
+		c.log.Error("Cache: failed to delete keys", zap.Error(err))
+		return deleted, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,45 @@
+package cache
+
+import "sync/atomic"
+
+// Stats aggregates cache usage of the user banner handler.
+type Stats struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+type StatsSnapshot struct {
+	Hits     int64
+	Misses   int64
+	Errors   int64
+	HitRatio float64
+}
+
+func NewStats() *Stats {
+	return &Stats{}
+}
+
+func (s *Stats) Hit() {
+	s.hits.Add(1)
+}
+
+func (s *Stats) Miss() {
+	s.misses.Add(1)
+}
+
+func (s *Stats) Error() {
+	s.errors.Add(1)
+}
+
+func (s *Stats) Snapshot() StatsSnapshot {
+	snapshot := StatsSnapshot{
+		Hits:   s.hits.Load(),
+		Misses: s.misses.Load(),
+		Errors: s.errors.Load(),
+	}
+	if lookups := snapshot.Hits + snapshot.Misses; lookups > 0 {
+		snapshot.HitRatio = float64(snapshot.Hits) / float64(lookups)
+	}
+	return snapshot
+}
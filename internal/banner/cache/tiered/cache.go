@@ -0,0 +1,64 @@
+package tiered
+
+import (
+	"context"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	"go.uber.org/zap"
+)
+
+// tieredCache serves entries from an in-process cache in front of a shared
+// remote one. Local entries are evicted on every instance through the invalidator.
+type tieredCache struct {
+	local       cache.Cache
+	remote      cache.Cache
+	invalidator cache.Invalidator
+	log         *zap.Logger
+}
+
+func New(local, remote cache.Cache, invalidator cache.Invalidator, log *zap.Logger) cache.Cache {
+	return &tieredCache{
+		local:       local,
+		remote:      remote,
+		invalidator: invalidator,
+		log:         log,
+	}
+}
+
+func (c *tieredCache) Set(ctx context.Context, key string, value *cache.Value) error {
+	if err := c.remote.Set(ctx, key, value); err != nil {
+		return err
+	}
+	return c.local.Set(ctx, key, value)
+}
+
+func (c *tieredCache) Get(ctx context.Context, key string) (*cache.Value, error) {
+	value, err := c.local.Get(ctx, key)
+	if err == nil {
+		return value, nil
+	}
+
+	value, err = c.remote.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err = c.local.Set(ctx, key, value); err != nil {
+		c.log.Warn("Cache: failed to set local value", zap.Error(err))
+	}
+	return value, nil
+}
+
+func (c *tieredCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return c.remote.TTL(ctx, key)
+}
+
+// Delete invalidates local entries even if the remote cache fails, otherwise
+// they would be served until they expire. The remote error is returned first.
+func (c *tieredCache) Delete(ctx context.Context, pattern string) (int64, error) {
+	deleted, err := c.remote.Delete(ctx, pattern)
+	if invalidateErr := c.invalidator.Invalidate(ctx, pattern); err == nil {
+		err = invalidateErr
+	}
+	return deleted, err
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
 	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
 	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/background"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
 	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
 	"github.com/gorilla/mux"
@@ -14,10 +16,12 @@ import (
 	"go.uber.org/zap"
 	"net/http"
 	"strconv"
+	"time"
 
 	pBanner "github.com/SlavaShagalov/avito-intern-task/internal/banner"
 
 	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
 )
 
 const (
@@ -28,17 +32,26 @@ const (
 	UseLastRevisionKey = "use_last_revision"
 )
 
+// cacheWriteTimeout bounds writes to the cache done after the response is sent.
+const cacheWriteTimeout = 5 * time.Second
+
 type delivery struct {
 	uc    pBanner.Usecase
 	cache cache.Cache
-	log   *zap.Logger
+	stats *cache.Stats
+	// cacheWrites tracks writes to the cache done after the response is sent.
+	cacheWrites *background.Group
+	log         *zap.Logger
 }
 
-func RegisterHandlers(mux *mux.Router, uc pBanner.Usecase, cache cache.Cache, log *zap.Logger, checkAuth mw.Middleware, adminAccess mw.Middleware) {
+func RegisterHandlers(mux *mux.Router, uc pBanner.Usecase, cache cache.Cache, stats *cache.Stats, cacheWrites *background.Group,
+	log *zap.Logger, checkAuth mw.Middleware, checkPermission mw.PermissionMiddleware) {
 	dlv := delivery{
-		uc:    uc,
-		cache: cache,
-		log:   log,
+		uc:          uc,
+		cache:       cache,
+		stats:       stats,
+		cacheWrites: cacheWrites,
+		log:         log,
 	}
 
 	const (
@@ -47,11 +60,11 @@ func RegisterHandlers(mux *mux.Router, uc pBanner.Usecase, cache cache.Cache, lo
 		userBannerPath = constants.ApiPrefix + "/user_banner"
 	)
 
-	mux.HandleFunc(bannersPath, checkAuth(adminAccess(dlv.create))).Methods(http.MethodPost)
-	mux.HandleFunc(bannersPath, checkAuth(adminAccess(dlv.list))).Methods(http.MethodGet)
-	mux.HandleFunc(userBannerPath, checkAuth(dlv.get)).Methods(http.MethodGet)
-	mux.HandleFunc(bannerPath, checkAuth(adminAccess(dlv.partialUpdate))).Methods(http.MethodPatch)
-	mux.HandleFunc(bannerPath, checkAuth(adminAccess(dlv.delete))).Methods(http.MethodDelete)
+	mux.HandleFunc(bannersPath, checkAuth(checkPermission(models.PermissionBannerCreate)(dlv.create))).Methods(http.MethodPost)
+	mux.HandleFunc(bannersPath, checkAuth(checkPermission(models.PermissionBannerRead)(dlv.list))).Methods(http.MethodGet)
+	mux.HandleFunc(userBannerPath, mw.FailOpen(checkAuth)(dlv.get)).Methods(http.MethodGet)
+	mux.HandleFunc(bannerPath, checkAuth(checkPermission(models.PermissionBannerUpdate)(dlv.partialUpdate))).Methods(http.MethodPatch)
+	mux.HandleFunc(bannerPath, checkAuth(checkPermission(models.PermissionBannerDelete)(dlv.delete))).Methods(http.MethodDelete)
 }
 
 func (d *delivery) create(w http.ResponseWriter, r *http.Request) {
@@ -68,6 +81,11 @@ func (d *delivery) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if request.IsActive && !canPublish(r) {
+		pHTTP.HandleError(w, r, pErrors.ErrPermissionDenied)
+		return
+	}
+
 	params := pBannerRepo.CreateParams{
 		TagIDs:    request.TagIDs,
 		FeatureID: request.FeatureID,
@@ -75,7 +93,7 @@ func (d *delivery) create(w http.ResponseWriter, r *http.Request) {
 		IsActive:  request.IsActive,
 	}
 
-	bannerID, err := d.uc.Create(r.Context(), &params)
+	bannerID, err := d.uc.Create(r.Context(), mw.GetPrincipal(r.Context()), &params)
 	if err != nil {
 		pHTTP.HandleError(w, r, err)
 		return
@@ -115,7 +133,7 @@ func (d *delivery) list(w http.ResponseWriter, r *http.Request) {
 		Offset:    offset,
 	}
 
-	banners, err := d.uc.List(r.Context(), &params)
+	banners, err := d.uc.List(r.Context(), mw.GetPrincipal(r.Context()), &params)
 	if err != nil {
 		pHTTP.HandleError(w, r, err)
 		return
@@ -126,12 +144,8 @@ func (d *delivery) list(w http.ResponseWriter, r *http.Request) {
 }
 
 func (d *delivery) get(w http.ResponseWriter, r *http.Request) {
-	isAdmin, ok := r.Context().Value(mw.ContextIsAdmin).(bool)
-	if !ok {
-		d.log.Error("is_admin field not found")
-		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
-		return
-	}
+	// Callers allowed to read all banners see inactive ones too.
+	isAdmin := mw.GetPrincipal(r.Context()).Can(models.PermissionBannerRead)
 
 	queryParams := r.URL.Query()
 	tagID, err := strconv.ParseInt(queryParams.Get(TagIDKey), 10, 64)
@@ -145,18 +159,24 @@ func (d *delivery) get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	key := fmt.Sprintf("%d:%d:%t", featureID, tagID, isAdmin)
+	key := cache.Key(tenant.ID(r.Context()), featureID, tagID, isAdmin)
 	if !queryParams.Has(UseLastRevisionKey) {
 		value, err := d.cache.Get(r.Context(), key)
 		if err == nil {
+			d.stats.Hit()
 			d.log.Debug("Cache hit", zap.String("key", key))
 			if value.Body != nil {
-				pHTTP.SendJSON(w, r, value.Code, value.Body)
+				pHTTP.SendRawJSON(w, r, value.Code, value.Body)
 			} else {
 				w.WriteHeader(value.Code)
 			}
 			return
 		}
+		if errors.Is(err, pErrors.ErrCacheMiss) {
+			d.stats.Miss()
+		} else {
+			d.stats.Error()
+		}
 		d.log.Debug("Cache miss", zap.Error(err), zap.String("key", key))
 	}
 
@@ -169,26 +189,35 @@ func (d *delivery) get(w http.ResponseWriter, r *http.Request) {
 	content, err := d.uc.Get(r.Context(), &params)
 	if err != nil {
 		if errors.Is(err, pErrors.ErrBannerDisabled) {
-			go d.cache.Set(context.Background(), key, &cache.Value{Code: http.StatusForbidden}) // nolint
+			d.setCache(key, &cache.Value{Code: http.StatusForbidden})
 			w.WriteHeader(http.StatusForbidden)
 			return
 		}
-		go func() {
-			code, _ := pErrors.ErrorToHTTPCode(err)
-			_ = d.cache.Set(context.Background(), key, &cache.Value{
-				Code: code,
-				Body: pHTTP.JSONError{Error: err.Error()},
-			})
-		}()
+		code, _ := pErrors.ErrorToHTTPCode(err)
+		if value, err := cache.NewJSONValue(code, pHTTP.JSONError{Error: err.Error()}); err == nil {
+			d.setCache(key, value)
+		}
 		pHTTP.HandleError(w, r, err)
 		return
 	}
 
-	go d.cache.Set(context.Background(), key, &cache.Value{ // nolint
-		Code: http.StatusOK,
-		Body: content,
+	value, err := cache.NewJSONValue(http.StatusOK, content)
+	if err != nil {
+		pHTTP.HandleError(w, r, fmt.Errorf("failed to marshal : %w", err))
+		return
+	}
+	d.setCache(key, value)
+	pHTTP.SendRawJSON(w, r, value.Code, value.Body)
+}
+
+func (d *delivery) setCache(key string, value *cache.Value) {
+	d.cacheWrites.Go(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
+		defer cancel()
+		if err := d.cache.Set(ctx, key, value); err != nil {
+			d.stats.Error()
+		}
 	})
-	pHTTP.SendJSON(w, r, http.StatusOK, content)
 }
 
 func (d *delivery) partialUpdate(w http.ResponseWriter, r *http.Request) {
@@ -213,6 +242,11 @@ func (d *delivery) partialUpdate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if request.IsActive != nil && !canPublish(r) {
+		pHTTP.HandleError(w, r, pErrors.ErrPermissionDenied)
+		return
+	}
+
 	params := pBannerRepo.PartialUpdateParams{
 		ID:        bannerID,
 		TagIDs:    request.TagIDs,
@@ -221,7 +255,7 @@ func (d *delivery) partialUpdate(w http.ResponseWriter, r *http.Request) {
 		IsActive:  request.IsActive,
 	}
 
-	err = d.uc.PartialUpdate(r.Context(), &params)
+	err = d.uc.PartialUpdate(r.Context(), mw.GetPrincipal(r.Context()), &params)
 	if err != nil {
 		pHTTP.HandleError(w, r, err)
 		return
@@ -237,7 +271,7 @@ func (d *delivery) delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = d.uc.Delete(r.Context(), bannerID)
+	err = d.uc.Delete(r.Context(), mw.GetPrincipal(r.Context()), bannerID)
 	if err != nil {
 		pHTTP.HandleError(w, r, err)
 		return
@@ -245,3 +279,8 @@ func (d *delivery) delete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// canPublish reports whether the caller may change banner visibility.
+func canPublish(r *http.Request) bool {
+	return mw.GetPrincipal(r.Context()).Can(models.PermissionBannerPublish)
+}
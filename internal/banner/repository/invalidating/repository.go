@@ -0,0 +1,80 @@
+package invalidating
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// repository deletes cached banners of every feature affected by a write. The
+// cache is the tiered one: entries are deleted from the shared cache before
+// the local caches of all instances are invalidated, so that they aren't
+// filled again with the stale shared entries.
+type repository struct {
+	pBannerRepo.Repository
+	cache cache.Cache
+	log   *zap.Logger
+}
+
+func New(repo pBannerRepo.Repository, cache cache.Cache, log *zap.Logger) pBannerRepo.Repository {
+	return &repository{
+		Repository: repo,
+		cache:      cache,
+		log:        log,
+	}
+}
+
+func (r *repository) Create(ctx context.Context, params *pBannerRepo.CreateParams) (int64, error) {
+	id, err := r.Repository.Create(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+	r.invalidate(ctx, params.FeatureID)
+	return id, nil
+}
+
+func (r *repository) PartialUpdate(ctx context.Context, params *pBannerRepo.PartialUpdateParams) error {
+	banner, err := r.Repository.GetByID(ctx, params.ID)
+	if errors.Is(err, pErrors.ErrBannerNotFound) {
+		// Nothing is cached for a missing banner, whether it's an error is up
+		// to the repository: an empty update succeeds.
+		return r.Repository.PartialUpdate(ctx, params)
+	}
+	if err != nil {
+		return err
+	}
+	if err = r.Repository.PartialUpdate(ctx, params); err != nil {
+		return err
+	}
+	r.invalidate(ctx, banner.FeatureID)
+	if params.FeatureID != nil && *params.FeatureID != banner.FeatureID {
+		r.invalidate(ctx, *params.FeatureID)
+	}
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int64) error {
+	banner, err := r.Repository.GetByID(ctx, id)
+	if errors.Is(err, pErrors.ErrBannerNotFound) {
+		return r.Repository.Delete(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	if err = r.Repository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, banner.FeatureID)
+	return nil
+}
+
+func (r *repository) invalidate(ctx context.Context, featureID int64) {
+	if _, err := r.cache.Delete(ctx, cache.FeaturePattern(tenant.ID(ctx), featureID)); err != nil {
+		r.log.Warn("Failed to invalidate cached banners", zap.Int64("feature_id", featureID), zap.Error(err))
+	}
+}
@@ -0,0 +1,302 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"go.uber.org/zap"
+)
+
+type reference struct {
+	tenantID  int64
+	featureID int64
+	tagID     int64
+}
+
+type banner struct {
+	tenantID  int64
+	content   []byte
+	isActive  bool
+	createdAt time.Time
+	updatedAt time.Time
+}
+
+// repository mirrors the semantics of the Postgres schema: a (feature_id, tag_id)
+// pair references at most one banner of the tenant and references are removed
+// with their banner. Features and tags aren't stored, so any ids are accepted.
+type repository struct {
+	mu         sync.RWMutex
+	lastID     int64
+	banners    map[int64]*banner
+	references map[reference]int64
+	log        *zap.Logger
+}
+
+func New(log *zap.Logger) pBannerRepo.Repository {
+	return &repository{
+		banners:    make(map[int64]*banner),
+		references: make(map[reference]int64),
+		log:        log,
+	}
+}
+
+func (r *repository) Create(ctx context.Context, params *pBannerRepo.CreateParams) (int64, error) {
+	content, err := json.Marshal(params.Content)
+	if err != nil {
+		r.log.Error(constants.DBError, zap.Error(err))
+		return 0, pErrors.ErrDb
+	}
+	// Postgres rejects an INSERT without values.
+	if len(params.TagIDs) == 0 {
+		return 0, pErrors.ErrDb
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tenantID := tenant.ID(ctx)
+	refs := make([]reference, 0, len(params.TagIDs))
+	for _, tagID := range params.TagIDs {
+		ref := reference{tenantID: tenantID, featureID: params.FeatureID, tagID: tagID}
+		if _, exists := r.references[ref]; exists || containsRef(refs, ref) {
+			return 0, pErrors.ErrBannerAlreadyExists
+		}
+		refs = append(refs, ref)
+	}
+
+	r.lastID++
+	bannerID := r.lastID
+	now := time.Now()
+	r.banners[bannerID] = &banner{
+		tenantID:  tenantID,
+		content:   content,
+		isActive:  params.IsActive,
+		createdAt: now,
+		updatedAt: now,
+	}
+	for _, ref := range refs {
+		r.references[ref] = bannerID
+	}
+
+	r.log.Debug("Banner created", zap.Int64("banner_id", bannerID))
+	return bannerID, nil
+}
+
+func (r *repository) List(ctx context.Context, params *pBannerRepo.FilterParams) ([]models.Banner, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int64, 0, len(r.banners))
+	for id, b := range r.banners {
+		if tenant.Allows(ctx, b.tenantID) && r.matches(id, params) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if params.Offset > 0 {
+		if params.Offset >= len(ids) {
+			ids = nil
+		} else {
+			ids = ids[params.Offset:]
+		}
+	}
+	if params.Limit > 0 && params.Limit < len(ids) {
+		ids = ids[:params.Limit]
+	}
+
+	banners := make([]models.Banner, 0, len(ids))
+	for _, id := range ids {
+		b, err := r.model(id)
+		if err != nil {
+			return nil, err
+		}
+		banners = append(banners, *b)
+	}
+	return banners, nil
+}
+
+func (r *repository) Get(ctx context.Context, params *pBannerRepo.GetParams) (*models.Banner, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.references[reference{tenantID: tenant.ID(ctx), featureID: params.FeatureID, tagID: params.TagID}]
+	if !ok {
+		return nil, pErrors.ErrBannerNotFound
+	}
+	return r.model(id)
+}
+
+func (r *repository) GetByID(ctx context.Context, id int64) (*models.Banner, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if b, exists := r.banners[id]; !exists || !tenant.Allows(ctx, b.tenantID) {
+		return nil, pErrors.ErrBannerNotFound
+	}
+	return r.model(id)
+}
+
+func (r *repository) PartialUpdate(ctx context.Context, params *pBannerRepo.PartialUpdateParams) error {
+	var content []byte
+	if params.Content != nil {
+		var err error
+		content, err = json.Marshal(params.Content)
+		if err != nil {
+			r.log.Error(constants.DBError, zap.Error(err))
+			return pErrors.ErrDb
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, exists := r.banners[params.ID]
+	exists = exists && b.tenantID == tenant.ID(ctx)
+	if !exists && (params.Content != nil || params.IsActive != nil || params.TagIDs != nil || params.FeatureID != nil) {
+		return pErrors.ErrBannerNotFound
+	}
+
+	if params.TagIDs != nil || params.FeatureID != nil {
+		oldRefs := r.refs(params.ID)
+		if len(oldRefs) == 0 {
+			return pErrors.ErrBannerNotFound
+		}
+
+		featureID := oldRefs[0].featureID
+		if params.FeatureID != nil {
+			featureID = *params.FeatureID
+		}
+		tagIDs := params.TagIDs
+		if tagIDs == nil {
+			for _, ref := range oldRefs {
+				tagIDs = append(tagIDs, ref.tagID)
+			}
+		} else if len(tagIDs) == 0 {
+			// Postgres rejects an INSERT without values.
+			return pErrors.ErrDb
+		}
+
+		newRefs := make([]reference, 0, len(tagIDs))
+		for _, tagID := range tagIDs {
+			ref := reference{tenantID: b.tenantID, featureID: featureID, tagID: tagID}
+			if id, taken := r.references[ref]; (taken && id != params.ID) || containsRef(newRefs, ref) {
+				return pErrors.ErrBannerAlreadyExists
+			}
+			newRefs = append(newRefs, ref)
+		}
+
+		for _, ref := range oldRefs {
+			delete(r.references, ref)
+		}
+		for _, ref := range newRefs {
+			r.references[ref] = params.ID
+		}
+	}
+
+	if params.Content != nil || params.IsActive != nil {
+		if params.Content != nil {
+			b.content = content
+		}
+		if params.IsActive != nil {
+			b.isActive = *params.IsActive
+		}
+		b.updatedAt = time.Now()
+	}
+
+	r.log.Debug("Banner updated", zap.Int64("banner_id", params.ID))
+	return nil
+}
+
+func (r *repository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b, exists := r.banners[id]; !exists || b.tenantID != tenant.ID(ctx) {
+		return pErrors.ErrBannerNotFound
+	}
+	for _, ref := range r.refs(id) {
+		delete(r.references, ref)
+	}
+	delete(r.banners, id)
+
+	r.log.Debug("Banner deleted", zap.Int64("banner_id", id))
+	return nil
+}
+
+func (r *repository) matches(id int64, params *pBannerRepo.FilterParams) bool {
+	if params.FeatureID <= 0 && params.TagID <= 0 && params.FeatureIDs == nil {
+		return true
+	}
+	for ref, bannerID := range r.references {
+		if bannerID != id {
+			continue
+		}
+		if (params.FeatureID <= 0 || ref.featureID == params.FeatureID) &&
+			(params.TagID <= 0 || ref.tagID == params.TagID) &&
+			(params.FeatureIDs == nil || containsID(params.FeatureIDs, ref.featureID)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *repository) refs(id int64) []reference {
+	refs := make([]reference, 0, 4)
+	for ref, bannerID := range r.references {
+		if bannerID == id {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].tagID < refs[j].tagID })
+	return refs
+}
+
+func (r *repository) model(id int64) (*models.Banner, error) {
+	b := r.banners[id]
+	refs := r.refs(id)
+
+	banner := &models.Banner{
+		ID:        id,
+		TenantID:  b.tenantID,
+		TagIDs:    make([]int64, 0, len(refs)),
+		IsActive:  b.isActive,
+		CreatedAt: b.createdAt,
+		UpdatedAt: b.updatedAt,
+	}
+	for _, ref := range refs {
+		banner.FeatureID = ref.featureID
+		banner.TagIDs = append(banner.TagIDs, ref.tagID)
+	}
+	if err := json.Unmarshal(b.content, &banner.Content); err != nil {
+		r.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return banner, nil
+}
+
+func containsRef(refs []reference, ref reference) bool {
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
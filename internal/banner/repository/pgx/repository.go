@@ -6,6 +6,7 @@ import (
 	"github.com/SlavaShagalov/avito-intern-task/internal/models"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
 	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -17,6 +18,8 @@ import (
 	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
 )
 
+// repository writes banners of the tenant of ctx. Reads made with
+// tenant.WithAll return banners of all tenants.
 type repository struct {
 	pool *pgxpool.Pool
 	log  *zap.Logger
@@ -30,12 +33,12 @@ func New(pool *pgxpool.Pool, log *zap.Logger) pBannerRepo.Repository {
 }
 
 const createBannerCmd = `
-INSERT INTO banners (content, is_active)
-VALUES ($1, $2)
+INSERT INTO banners (tenant_id, content, is_active)
+VALUES ($1, $2, $3)
 RETURNING id;`
 
 const createBannerReferencesCmd = `
-INSERT INTO banner_references(banner_id, feature_id, tag_id)
+INSERT INTO banner_references(tenant_id, banner_id, feature_id, tag_id)
 VALUES %s;`
 
 func (r *repository) Create(ctx context.Context, params *pBannerRepo.CreateParams) (int64, error) {
@@ -46,7 +49,9 @@ func (r *repository) Create(ctx context.Context, params *pBannerRepo.CreateParam
 	}
 	defer tx.Rollback(ctx) // nolint
 
+	tenantID := tenant.ID(ctx)
 	row := tx.QueryRow(ctx, createBannerCmd,
+		tenantID,
 		params.Content,
 		params.IsActive,
 	)
@@ -60,8 +65,8 @@ func (r *repository) Create(ctx context.Context, params *pBannerRepo.CreateParam
 	var valueStrings []string
 	var args []any
 	for _, tagID := range params.TagIDs {
-		valueString := fmt.Sprintf("($%d, $%d, $%d)", len(args)+1, len(args)+2, len(args)+3)
-		args = append(args, bannerID, params.FeatureID, tagID)
+		valueString := fmt.Sprintf("($%d, $%d, $%d, $%d)", len(args)+1, len(args)+2, len(args)+3, len(args)+4)
+		args = append(args, tenantID, bannerID, params.FeatureID, tagID)
 		valueStrings = append(valueStrings, valueString)
 	}
 
@@ -88,6 +93,7 @@ func (r *repository) Create(ctx context.Context, params *pBannerRepo.CreateParam
 
 const listCmd = `
 SELECT b.id,
+       b.tenant_id,
        ARRAY_AGG(br.tag_id) AS tag_ids,
        br.feature_id,
        b.content,
@@ -97,14 +103,14 @@ SELECT b.id,
 FROM banners b
          JOIN
      banner_references br ON b.id = br.banner_id
-%s
+WHERE ($1::bigint = 0 OR b.tenant_id = $1)%s
 GROUP BY b.id, br.feature_id
 ORDER BY b.id
 %s;`
 
 func (r *repository) List(ctx context.Context, params *pBannerRepo.FilterParams) ([]models.Banner, error) {
-	conditions := make([]string, 0, 2)
-	args := make([]any, 0, 4)
+	conditions := make([]string, 0, 3)
+	args := []any{tenant.ID(ctx)}
 	if params.FeatureID > 0 {
 		conditions = append(conditions, fmt.Sprintf("feature_id = $%d", len(args)+1))
 		args = append(args, params.FeatureID)
@@ -113,12 +119,16 @@ func (r *repository) List(ctx context.Context, params *pBannerRepo.FilterParams)
 		conditions = append(conditions, fmt.Sprintf("tag_id = $%d", len(args)+1))
 		args = append(args, params.TagID)
 	}
+	if params.FeatureIDs != nil {
+		conditions = append(conditions, fmt.Sprintf("feature_id = ANY($%d)", len(args)+1))
+		args = append(args, params.FeatureIDs)
+	}
 
 	var conditionPart string
 	if len(conditions) > 0 {
 		condition := strings.Join(conditions, " AND ")
 		conditionPart = fmt.Sprintf(`
-WHERE b.id IN (SELECT banner_id
+  AND b.id IN (SELECT banner_id
                FROM banner_references
                WHERE %s)`, condition)
 	}
@@ -147,6 +157,7 @@ WHERE b.id IN (SELECT banner_id
 	for rows.Next() {
 		err = rows.Scan(
 			&banner.ID,
+			&banner.TenantID,
 			&banner.TagIDs,
 			&banner.FeatureID,
 			&banner.Content,
@@ -166,6 +177,7 @@ WHERE b.id IN (SELECT banner_id
 
 const getCmd = `
 SELECT b.id,
+       b.tenant_id,
        ARRAY_AGG(br.tag_id) AS tag_ids,
        br.feature_id,
        b.content,
@@ -177,15 +189,57 @@ FROM banners b
 WHERE b.id = (SELECT banner_id
               FROM banner_references
               WHERE tag_id = $1
-                AND feature_id = $2)
+                AND feature_id = $2
+                AND tenant_id = $3)
 GROUP BY b.id, br.feature_id;`
 
 func (r *repository) Get(ctx context.Context, params *pBannerRepo.GetParams) (*models.Banner, error) {
-	row := r.pool.QueryRow(ctx, getCmd, params.TagID, params.FeatureID)
+	row := r.pool.QueryRow(ctx, getCmd, params.TagID, params.FeatureID, tenant.ID(ctx))
 
 	banner := new(models.Banner)
 	err := row.Scan(
 		&banner.ID,
+		&banner.TenantID,
+		&banner.TagIDs,
+		&banner.FeatureID,
+		&banner.Content,
+		&banner.IsActive,
+		&banner.CreatedAt,
+		&banner.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pErrors.ErrBannerNotFound
+		}
+		r.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	return banner, nil
+}
+
+const getByIDCmd = `
+SELECT b.id,
+       b.tenant_id,
+       ARRAY_AGG(br.tag_id) AS tag_ids,
+       br.feature_id,
+       b.content,
+       b.is_active,
+       b.created_at,
+       b.updated_at
+FROM banners b
+         JOIN banner_references br ON b.id = br.banner_id
+WHERE b.id = $1
+  AND ($2::bigint = 0 OR b.tenant_id = $2)
+GROUP BY b.id, br.feature_id;`
+
+func (r *repository) GetByID(ctx context.Context, id int64) (*models.Banner, error) {
+	row := r.pool.QueryRow(ctx, getByIDCmd, id, tenant.ID(ctx))
+
+	banner := new(models.Banner)
+	err := row.Scan(
+		&banner.ID,
+		&banner.TenantID,
 		&banner.TagIDs,
 		&banner.FeatureID,
 		&banner.Content,
@@ -208,18 +262,21 @@ const updateBannerCmd = `
 UPDATE banners
 SET %s,
     updated_at = now()
-WHERE id = $%d;`
+WHERE id = $%d
+  AND tenant_id = $%d;`
 
 const delBannerReferencesCmd = `
 DELETE
 FROM banner_references
 WHERE banner_id = $1
+  AND tenant_id = $2
 RETURNING feature_id;`
 
 const updateBannerFeatureCmd = `
 UPDATE banner_references
 SET feature_id = $2
-WHERE banner_id = $1;`
+WHERE banner_id = $1
+  AND tenant_id = $3;`
 
 func (r *repository) PartialUpdate(ctx context.Context, params *pBannerRepo.PartialUpdateParams) error {
 	tx, err := r.pool.Begin(ctx)
@@ -229,6 +286,7 @@ func (r *repository) PartialUpdate(ctx context.Context, params *pBannerRepo.Part
 	}
 	defer tx.Rollback(ctx) // nolint
 
+	tenantID := tenant.ID(ctx)
 	setValues := make([]string, 0, 2)
 	args := make([]any, 0, 3)
 	if params.Content != nil {
@@ -243,8 +301,8 @@ func (r *repository) PartialUpdate(ctx context.Context, params *pBannerRepo.Part
 	}
 	if len(setValues) > 0 {
 		setValuesPart := strings.Join(setValues, ", ")
-		cmd := fmt.Sprintf(updateBannerCmd, setValuesPart, len(args)+1)
-		args = append(args, params.ID)
+		cmd := fmt.Sprintf(updateBannerCmd, setValuesPart, len(args)+1, len(args)+2)
+		args = append(args, params.ID, tenantID)
 
 		res, err := tx.Exec(ctx, cmd, args...)
 		if err != nil {
@@ -257,7 +315,7 @@ func (r *repository) PartialUpdate(ctx context.Context, params *pBannerRepo.Part
 	}
 
 	if params.TagIDs != nil {
-		row := tx.QueryRow(ctx, delBannerReferencesCmd, params.ID)
+		row := tx.QueryRow(ctx, delBannerReferencesCmd, params.ID, tenantID)
 		var featureID int64
 		if err = row.Scan(&featureID); err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
@@ -273,9 +331,10 @@ func (r *repository) PartialUpdate(ctx context.Context, params *pBannerRepo.Part
 		valueStrings := make([]string, 0, 3)
 		insArgs := make([]any, 0, 3)
 		for _, tagID := range params.TagIDs {
-			valueString := fmt.Sprintf("($%d, $%d, $%d)", len(insArgs)+1, len(insArgs)+2, len(insArgs)+3)
+			valueString := fmt.Sprintf("($%d, $%d, $%d, $%d)",
+				len(insArgs)+1, len(insArgs)+2, len(insArgs)+3, len(insArgs)+4)
 			valueStrings = append(valueStrings, valueString)
-			insArgs = append(insArgs, params.ID, featureID, tagID)
+			insArgs = append(insArgs, tenantID, params.ID, featureID, tagID)
 		}
 
 		cmd := fmt.Sprintf(createBannerReferencesCmd, strings.Join(valueStrings, ", "))
@@ -293,7 +352,7 @@ func (r *repository) PartialUpdate(ctx context.Context, params *pBannerRepo.Part
 			return pErrors.ErrBannerNotFound
 		}
 	} else if params.FeatureID != nil {
-		res, err := tx.Exec(ctx, updateBannerFeatureCmd, params.ID, *params.FeatureID)
+		res, err := tx.Exec(ctx, updateBannerFeatureCmd, params.ID, *params.FeatureID, tenantID)
 		if err != nil {
 			pgErr := err.(*pgconn.PgError)
 			if pgErr.Code == pgerrcode.UniqueViolation {
@@ -319,10 +378,11 @@ func (r *repository) PartialUpdate(ctx context.Context, params *pBannerRepo.Part
 
 const deleteCmd = `
 	DELETE FROM banners
-	WHERE id = $1;`
+	WHERE id = $1
+	  AND tenant_id = $2;`
 
 func (r *repository) Delete(ctx context.Context, id int64) error {
-	res, err := r.pool.Exec(ctx, deleteCmd, id)
+	res, err := r.pool.Exec(ctx, deleteCmd, id, tenant.ID(ctx))
 	if err != nil {
 		r.log.Error(constants.DBError, zap.Error(err))
 		return pErrors.ErrDb
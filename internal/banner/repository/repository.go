@@ -34,8 +34,10 @@ func (p *CreateParams) Validate() error {
 type FilterParams struct {
 	FeatureID int64
 	TagID     int64
-	Limit     int
-	Offset    int
+	// FeatureIDs restricts banners to these features unless nil.
+	FeatureIDs []int64
+	Limit      int
+	Offset     int
 }
 
 type GetParams struct {
@@ -73,6 +75,7 @@ type Repository interface {
 	Create(ctx context.Context, params *CreateParams) (int64, error)
 	List(ctx context.Context, params *FilterParams) ([]models.Banner, error)
 	Get(ctx context.Context, params *GetParams) (*models.Banner, error)
+	GetByID(ctx context.Context, id int64) (*models.Banner, error)
 	PartialUpdate(ctx context.Context, params *PartialUpdateParams) error
 	Delete(ctx context.Context, id int64) error
 }
@@ -0,0 +1,265 @@
+package snapshot
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// Channel notified by the triggers in schema.sql with the changed banner id as payload.
+	channel = "banners_changed"
+
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 10 * time.Second
+)
+
+type reference struct {
+	tenantID  int64
+	featureID int64
+	tagID     int64
+}
+
+// index is immutable once published, changes are applied to a copy.
+type index struct {
+	banners    map[int64]*models.Banner
+	references map[reference]*models.Banner
+}
+
+func newIndex(banners []models.Banner) *index {
+	idx := &index{
+		banners:    make(map[int64]*models.Banner, len(banners)),
+		references: make(map[reference]*models.Banner, len(banners)),
+	}
+	for i := range banners {
+		idx.put(&banners[i])
+	}
+	return idx
+}
+
+func (idx *index) clone() *index {
+	c := &index{
+		banners:    make(map[int64]*models.Banner, len(idx.banners)),
+		references: make(map[reference]*models.Banner, len(idx.references)),
+	}
+	for id, banner := range idx.banners {
+		c.banners[id] = banner
+	}
+	for ref, banner := range idx.references {
+		c.references[ref] = banner
+	}
+	return c
+}
+
+func (idx *index) put(banner *models.Banner) {
+	idx.banners[banner.ID] = banner
+	for _, tagID := range banner.TagIDs {
+		idx.references[reference{tenantID: banner.TenantID, featureID: banner.FeatureID, tagID: tagID}] = banner
+	}
+}
+
+func (idx *index) remove(id int64) {
+	banner, ok := idx.banners[id]
+	if !ok {
+		return
+	}
+	for _, tagID := range banner.TagIDs {
+		ref := reference{tenantID: banner.TenantID, featureID: banner.FeatureID, tagID: tagID}
+		if idx.references[ref] == banner {
+			delete(idx.references, ref)
+		}
+	}
+	delete(idx.banners, id)
+}
+
+// Snapshot keeps all banners in memory and serves Get without touching
+// the database. Other methods are delegated to the wrapped repository.
+type Snapshot struct {
+	pBannerRepo.Repository
+	pool           *pgxpool.Pool
+	reloadInterval time.Duration
+	current        atomic.Pointer[index]
+	mu             sync.Mutex
+	loaded         chan struct{}
+	loadedOnce     sync.Once
+	log            *zap.Logger
+}
+
+// New creates a snapshot over repo. If pool is nil, the snapshot is refreshed
+// by periodic reloads only.
+func New(repo pBannerRepo.Repository, pool *pgxpool.Pool, reloadInterval time.Duration, log *zap.Logger) *Snapshot {
+	return &Snapshot{
+		Repository:     repo,
+		pool:           pool,
+		reloadInterval: reloadInterval,
+		loaded:         make(chan struct{}),
+		log:            log,
+	}
+}
+
+// Loaded is closed after the first successful full load.
+func (s *Snapshot) Loaded() <-chan struct{} {
+	return s.loaded
+}
+
+func (s *Snapshot) Get(ctx context.Context, params *pBannerRepo.GetParams) (*models.Banner, error) {
+	idx := s.current.Load()
+	if idx == nil {
+		return s.Repository.Get(ctx, params)
+	}
+	banner, ok := idx.references[reference{tenantID: tenant.ID(ctx), featureID: params.FeatureID, tagID: params.TagID}]
+	if !ok {
+		return nil, pErrors.ErrBannerNotFound
+	}
+	return banner, nil
+}
+
+// Reload replaces the snapshot with banners of all tenants from the repository.
+func (s *Snapshot) Reload(ctx context.Context) error {
+	start := time.Now()
+	banners, err := s.Repository.List(tenant.WithAll(ctx), &pBannerRepo.FilterParams{})
+	if err != nil {
+		s.log.Error("Snapshot: failed to load banners", zap.Error(err))
+		return err
+	}
+
+	s.mu.Lock()
+	s.current.Store(newIndex(banners))
+	s.mu.Unlock()
+	s.loadedOnce.Do(func() { close(s.loaded) })
+
+	s.log.Info("Snapshot: reloaded",
+		zap.Int("banners", len(banners)),
+		zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// refresh re-reads a single banner and swaps in an updated copy of the index.
+func (s *Snapshot) refresh(ctx context.Context, id int64) error {
+	banner, err := s.Repository.GetByID(tenant.WithAll(ctx), id)
+	if err != nil && !errors.Is(err, pErrors.ErrBannerNotFound) {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	idx := s.current.Load()
+	if idx == nil {
+		return nil
+	}
+	next := idx.clone()
+	next.remove(id)
+	if banner != nil {
+		next.put(banner)
+	}
+	s.current.Store(next)
+
+	s.log.Debug("Snapshot: banner refreshed", zap.Int64("banner_id", id))
+	return nil
+}
+
+// Run keeps the snapshot fresh until ctx is done. The backoff between retries
+// starts over once a reload succeeds again.
+func (s *Snapshot) Run(ctx context.Context) {
+	backoff := minBackoff
+	reloaded := func() { backoff = minBackoff }
+	for {
+		var err error
+		if s.pool != nil {
+			err = s.listen(ctx, reloaded)
+		} else {
+			err = s.poll(ctx, reloaded)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.log.Warn("Snapshot: refresh failed", zap.Error(err), zap.Duration("retry_in", backoff))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (s *Snapshot) poll(ctx context.Context, reloaded func()) error {
+	if err := s.Reload(ctx); err != nil {
+		return err
+	}
+	reloaded()
+	ticker := time.NewTicker(s.reloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// listen subscribes to change notifications and only then performs a full
+// reload, so that no change committed in between is lost.
+func (s *Snapshot) listen(ctx context.Context, reloaded func()) error {
+	poolConn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	conn := poolConn.Hijack()
+	defer conn.Close(context.Background()) // nolint
+
+	if _, err = conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+	if err = s.Reload(ctx); err != nil {
+		return err
+	}
+	reloaded()
+
+	nextReload := time.Now().Add(s.reloadInterval)
+	for {
+		waitCtx, cancel := context.WithDeadline(ctx, nextReload)
+		notification, err := conn.WaitForNotification(waitCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				return err
+			}
+			if err = s.Reload(ctx); err != nil {
+				return err
+			}
+			nextReload = time.Now().Add(s.reloadInterval)
+			continue
+		}
+
+		id, err := strconv.ParseInt(notification.Payload, 10, 64)
+		if err != nil {
+			s.log.Warn("Snapshot: bad notification payload", zap.String("payload", notification.Payload))
+			continue
+		}
+		if err = s.refresh(ctx, id); err != nil {
+			return err
+		}
+	}
+}
@@ -7,9 +7,9 @@ import (
 )
 
 type Usecase interface {
-	Create(ctx context.Context, params *pBannerRepo.CreateParams) (int64, error)
-	List(ctx context.Context, params *pBannerRepo.FilterParams) ([]models.Banner, error)
+	Create(ctx context.Context, principal *models.Principal, params *pBannerRepo.CreateParams) (int64, error)
+	List(ctx context.Context, principal *models.Principal, params *pBannerRepo.FilterParams) ([]models.Banner, error)
 	Get(ctx context.Context, params *pBannerRepo.GetParams) (map[string]any, error)
-	PartialUpdate(ctx context.Context, params *pBannerRepo.PartialUpdateParams) error
-	Delete(ctx context.Context, id int64) error
+	PartialUpdate(ctx context.Context, principal *models.Principal, params *pBannerRepo.PartialUpdateParams) error
+	Delete(ctx context.Context, principal *models.Principal, id int64) error
 }
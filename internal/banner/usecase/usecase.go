@@ -7,29 +7,45 @@ import (
 	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
 	"github.com/SlavaShagalov/avito-intern-task/internal/models"
 	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pTeam "github.com/SlavaShagalov/avito-intern-task/internal/team"
 	"go.uber.org/zap"
 )
 
 type usecase struct {
-	repo repository.Repository
-	log  *zap.Logger
+	repo      repository.Repository
+	teamsRepo pTeam.Repository
+	log       *zap.Logger
 }
 
-func New(repo repository.Repository, log *zap.Logger) pBanner.Usecase {
+func New(repo repository.Repository, teamsRepo pTeam.Repository, log *zap.Logger) pBanner.Usecase {
 	return &usecase{
-		repo: repo,
-		log:  log,
+		repo:      repo,
+		teamsRepo: teamsRepo,
+		log:       log,
 	}
 }
 
-func (uc *usecase) Create(ctx context.Context, params *pBannerRepo.CreateParams) (int64, error) {
+func (uc *usecase) Create(ctx context.Context, principal *models.Principal, params *pBannerRepo.CreateParams) (int64, error) {
 	if err := params.Validate(); err != nil {
 		return 0, err
 	}
+	if err := uc.checkOwnership(ctx, principal, params.FeatureID); err != nil {
+		return 0, err
+	}
 	return uc.repo.Create(ctx, params)
 }
 
-func (uc *usecase) List(ctx context.Context, params *pBannerRepo.FilterParams) ([]models.Banner, error) {
+func (uc *usecase) List(ctx context.Context, principal *models.Principal, params *pBannerRepo.FilterParams) ([]models.Banner, error) {
+	if !principal.Can(models.PermissionFeatureAny) {
+		featureIDs, err := uc.teamsRepo.FeaturesByUser(ctx, principal.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if len(featureIDs) == 0 {
+			return []models.Banner{}, nil
+		}
+		params.FeatureIDs = featureIDs
+	}
 	return uc.repo.List(ctx, params)
 }
 
@@ -44,13 +60,62 @@ func (uc *usecase) Get(ctx context.Context, params *pBannerRepo.GetParams) (map[
 	return banner.Content, nil
 }
 
-func (uc *usecase) PartialUpdate(ctx context.Context, params *pBannerRepo.PartialUpdateParams) error {
+func (uc *usecase) PartialUpdate(ctx context.Context, principal *models.Principal, params *pBannerRepo.PartialUpdateParams) error {
 	if err := params.Validate(); err != nil {
 		return err
 	}
+	if !principal.Can(models.PermissionFeatureAny) {
+		banner, err := uc.repo.GetByID(ctx, params.ID)
+		if err != nil {
+			return err
+		}
+		featureIDs := []int64{banner.FeatureID}
+		if params.FeatureID != nil {
+			featureIDs = append(featureIDs, *params.FeatureID)
+		}
+		if err = uc.checkOwnership(ctx, principal, featureIDs...); err != nil {
+			return err
+		}
+	}
 	return uc.repo.PartialUpdate(ctx, params)
 }
 
-func (uc *usecase) Delete(ctx context.Context, id int64) error {
+func (uc *usecase) Delete(ctx context.Context, principal *models.Principal, id int64) error {
+	if !principal.Can(models.PermissionFeatureAny) {
+		banner, err := uc.repo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = uc.checkOwnership(ctx, principal, banner.FeatureID); err != nil {
+			return err
+		}
+	}
 	return uc.repo.Delete(ctx, id)
 }
+
+// checkOwnership returns ErrFeatureNotOwned unless all features are owned by
+// the principal's teams.
+func (uc *usecase) checkOwnership(ctx context.Context, principal *models.Principal, featureIDs ...int64) error {
+	if principal.Can(models.PermissionFeatureAny) {
+		return nil
+	}
+
+	owned, err := uc.teamsRepo.FeaturesByUser(ctx, principal.UserID)
+	if err != nil {
+		return err
+	}
+	for _, featureID := range featureIDs {
+		found := false
+		for _, ownedID := range owned {
+			if ownedID == featureID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			uc.log.Debug("Feature not owned", zap.Int64("user_id", principal.UserID), zap.Int64("feature_id", featureID))
+			return pErrors.ErrFeatureNotOwned
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package warmup
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	"github.com/SlavaShagalov/avito-intern-task/internal/health"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+const defaultConcurrency = 16
+
+type Warmer struct {
+	repo        pBannerRepo.Repository
+	cache       cache.Cache
+	concurrency int
+	log         *zap.Logger
+}
+
+func New(repo pBannerRepo.Repository, cache cache.Cache, concurrency int, log *zap.Logger) *Warmer {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	return &Warmer{
+		repo:        repo,
+		cache:       cache,
+		concurrency: concurrency,
+		log:         log,
+	}
+}
+
+// Run loads all active banners and pre-populates the cache for every
+// (feature_id, tag_id) pair of every tenant, for both regular users and admins.
+func (w *Warmer) Run(ctx context.Context) error {
+	start := time.Now()
+	w.log.Info("Cache warm-up started", zap.Int("concurrency", w.concurrency))
+
+	banners, err := w.repo.List(tenant.WithAll(ctx), &pBannerRepo.FilterParams{})
+	if err != nil {
+		w.log.Error("Cache warm-up: failed to load banners", zap.Error(err))
+		return err
+	}
+
+	var keys, failed atomic.Int64
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(w.concurrency)
+	for _, banner := range banners {
+		if !banner.IsActive {
+			continue
+		}
+		value, err := cache.NewJSONValue(http.StatusOK, banner.Content)
+		if err != nil {
+			w.log.Error("Cache warm-up: failed to marshal banner", zap.Int64("banner_id", banner.ID), zap.Error(err))
+			failed.Add(int64(2 * len(banner.TagIDs)))
+			continue
+		}
+		for _, tagID := range banner.TagIDs {
+			for _, isAdmin := range []bool{false, true} {
+				key := cache.Key(banner.TenantID, banner.FeatureID, tagID, isAdmin)
+				g.Go(func() error {
+					if err := gCtx.Err(); err != nil {
+						return err
+					}
+					if err := w.cache.Set(gCtx, key, value); err != nil {
+						failed.Add(1)
+						return nil
+					}
+					keys.Add(1)
+					return nil
+				})
+			}
+		}
+	}
+	if err = g.Wait(); err != nil {
+		w.log.Error("Cache warm-up interrupted", zap.Error(err))
+		return err
+	}
+
+	w.log.Info("Cache warm-up finished",
+		zap.Int64("keys", keys.Load()),
+		zap.Int64("failed", failed.Load()),
+		zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// WarmUp runs the warm-up within timeout and marks the instance ready once it's
+// over. A failed warm-up leaves the cache cold, but doesn't keep the instance
+// out of service.
+func (w *Warmer) WarmUp(ctx context.Context, timeout time.Duration, readiness *health.Readiness) {
+	warmupCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := w.Run(warmupCtx); err != nil {
+		w.log.Warn("Cache warm-up failed, serving with cold cache", zap.Error(err))
+	}
+	readiness.SetReady(true)
+}
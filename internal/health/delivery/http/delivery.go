@@ -0,0 +1,73 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/health"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	livePath  = "/healthz"
+	readyPath = "/readyz"
+
+	statusAlive    = "alive"
+	statusReady    = "ready"
+	statusDegraded = "degraded"
+	statusNotReady = "not ready"
+
+	statusUp   = "up"
+	statusDown = "down"
+)
+
+type delivery struct {
+	readiness *health.Readiness
+	log       *zap.Logger
+}
+
+func RegisterHandlers(mux *mux.Router, readiness *health.Readiness, log *zap.Logger) {
+	dlv := delivery{
+		readiness: readiness,
+		log:       log,
+	}
+
+	mux.HandleFunc(livePath, dlv.live).Methods(http.MethodGet)
+	mux.HandleFunc(readyPath, dlv.ready).Methods(http.MethodGet)
+}
+
+func (d *delivery) live(w http.ResponseWriter, r *http.Request) {
+	pHTTP.SendJSON(w, r, http.StatusOK, statusResponse{Status: statusAlive})
+}
+
+// ready doesn't ping dependencies while the instance warms up or shuts down.
+func (d *delivery) ready(w http.ResponseWriter, r *http.Request) {
+	if !d.readiness.IsReady() {
+		pHTTP.SendJSON(w, r, http.StatusServiceUnavailable, statusResponse{Status: statusNotReady})
+		return
+	}
+
+	results := d.readiness.Check(r.Context())
+	response := statusResponse{
+		Status:       statusReady,
+		Dependencies: make([]dependency, 0, len(results)),
+	}
+	code := http.StatusOK
+	for i := range results {
+		result := &results[i]
+		response.Dependencies = append(response.Dependencies, newDependencyResponse(result))
+		if result.Err == nil {
+			continue
+		}
+
+		d.log.Warn("Dependency is unavailable", zap.String("dependency", result.Name), zap.Error(result.Err))
+		if result.Hard {
+			response.Status = statusNotReady
+			code = http.StatusServiceUnavailable
+		} else if code == http.StatusOK {
+			response.Status = statusDegraded
+		}
+	}
+	pHTTP.SendJSON(w, r, code, response)
+}
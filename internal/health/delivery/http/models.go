@@ -0,0 +1,29 @@
+package http
+
+import "github.com/SlavaShagalov/avito-intern-task/internal/health"
+
+// API responses
+type statusResponse struct {
+	Status       string       `json:"status"`
+	Dependencies []dependency `json:"dependencies,omitempty"`
+}
+
+type dependency struct {
+	Name      string  `json:"name"`
+	Hard      bool    `json:"hard"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+func newDependencyResponse(result *health.Result) dependency {
+	status := statusUp
+	if result.Err != nil {
+		status = statusDown
+	}
+	return dependency{
+		Name:      result.Name,
+		Hard:      result.Hard,
+		Status:    status,
+		LatencyMs: float64(result.Latency.Microseconds()) / 1000,
+	}
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Dependency is an external service checked for readiness. A failing hard
+// dependency makes the instance not ready, a failing soft one only degrades it.
+type Dependency struct {
+	Name string
+	Hard bool
+	Ping func(ctx context.Context) error
+}
+
+type Result struct {
+	Name    string
+	Hard    bool
+	Err     error
+	Latency time.Duration
+}
+
+// Readiness reports whether the instance may receive traffic.
+type Readiness struct {
+	ready        atomic.Bool
+	draining     atomic.Bool
+	timeout      time.Duration
+	dependencies []Dependency
+}
+
+func NewReadiness(timeout time.Duration, dependencies ...Dependency) *Readiness {
+	return &Readiness{
+		timeout:      timeout,
+		dependencies: dependencies,
+	}
+}
+
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+// Drain makes the instance not ready for good: SetReady(true) made by
+// start-up work finishing during shutdown doesn't bring it back.
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+func (r *Readiness) IsReady() bool {
+	return r.ready.Load() && !r.draining.Load()
+}
+
+// Check pings all dependencies concurrently, each within the timeout.
+func (r *Readiness) Check(ctx context.Context) []Result {
+	results := make([]Result, len(r.dependencies))
+
+	var wg sync.WaitGroup
+	for i, dependency := range r.dependencies {
+		wg.Add(1)
+		go func(i int, dependency Dependency) {
+			defer wg.Done()
+			pingCtx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := dependency.Ping(pingCtx)
+			results[i] = Result{
+				Name:    dependency.Name,
+				Hard:    dependency.Hard,
+				Err:     err,
+				Latency: time.Since(start),
+			}
+		}(i, dependency)
+	}
+	wg.Wait()
+
+	return results
+}
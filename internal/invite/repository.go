@@ -0,0 +1,19 @@
+package invite
+
+import (
+	"context"
+	"time"
+)
+
+type CreateParams struct {
+	CodeHash  string
+	CreatedBy int64
+	ExpiresAt time.Time
+}
+
+type Repository interface {
+	Create(ctx context.Context, params *CreateParams) error
+	// Use deletes the invite code. Unknown and expired codes, and codes of other
+	// tenants are rejected with ErrInvalidInviteCode.
+	Use(ctx context.Context, codeHash string) error
+}
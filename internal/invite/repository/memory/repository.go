@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pInvite "github.com/SlavaShagalov/avito-intern-task/internal/invite"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"go.uber.org/zap"
+)
+
+type code struct {
+	tenantID  int64
+	expiresAt time.Time
+}
+
+type repository struct {
+	mu sync.Mutex
+	// codes are keyed by hashes.
+	codes map[string]code
+	log   *zap.Logger
+}
+
+func New(log *zap.Logger) pInvite.Repository {
+	return &repository{
+		codes: make(map[string]code),
+		log:   log,
+	}
+}
+
+func (repo *repository) Create(ctx context.Context, params *pInvite.CreateParams) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.codes[params.CodeHash] = code{tenantID: tenant.ID(ctx), expiresAt: params.ExpiresAt}
+
+	repo.log.Debug("Invite code created", zap.Int64("created_by", params.CreatedBy))
+	return nil
+}
+
+func (repo *repository) Use(ctx context.Context, codeHash string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	c, exists := repo.codes[codeHash]
+	if !exists || c.tenantID != tenant.ID(ctx) || !time.Now().Before(c.expiresAt) {
+		return pErrors.ErrInvalidInviteCode
+	}
+	delete(repo.codes, codeHash)
+
+	repo.log.Debug("Invite code used")
+	return nil
+}
@@ -0,0 +1,57 @@
+package pgx
+
+import (
+	"context"
+
+	pInvite "github.com/SlavaShagalov/avito-intern-task/internal/invite"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	pool *pgxpool.Pool
+	log  *zap.Logger
+}
+
+func New(pool *pgxpool.Pool, log *zap.Logger) pInvite.Repository {
+	return &repository{
+		pool: pool,
+		log:  log,
+	}
+}
+
+const createCmd = `
+	INSERT INTO invite_codes (code_hash, tenant_id, created_by, expires_at)
+	VALUES ($1, $2, NULLIF($3, 0), $4);`
+
+func (repo *repository) Create(ctx context.Context, params *pInvite.CreateParams) error {
+	_, err := repo.pool.Exec(ctx, createCmd, params.CodeHash, tenant.ID(ctx), params.CreatedBy, params.ExpiresAt)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+
+	repo.log.Debug("Invite code created", zap.Int64("created_by", params.CreatedBy))
+	return nil
+}
+
+const useCmd = `
+	DELETE FROM invite_codes
+	WHERE code_hash = $1 AND tenant_id = $2 AND expires_at > now();`
+
+func (repo *repository) Use(ctx context.Context, codeHash string) error {
+	res, err := repo.pool.Exec(ctx, useCmd, codeHash, tenant.ID(ctx))
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if res.RowsAffected() == 0 {
+		return pErrors.ErrInvalidInviteCode
+	}
+
+	repo.log.Debug("Invite code used")
+	return nil
+}
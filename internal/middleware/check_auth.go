@@ -2,43 +2,161 @@ package middleware
 
 import (
 	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/apikey"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/mtls"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
 	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
 	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"net/http"
+	"strings"
 )
 
-func NewCheckAuth(log *zap.Logger) Middleware {
+const (
+	apiKeyScheme = "ApiKey "
+	bearerScheme = "Bearer "
+)
+
+// NewCheckAuth accepts either an API key in the X-API-Key or the
+// "Authorization: ApiKey" header, or a JWT in the "Authorization: Bearer" or
+// the legacy token header. JWTs are verified with the key named by their kid
+// header. Requests without them are authenticated by a client certificate
+// verified by the TLS handshake if services are given. The request is bound to
+// the tenant of the credentials.
+func NewCheckAuth(denyList denylist.DenyList, apiKeys apikey.Usecase, keys *keyring.Keyring, services *mtls.Services,
+	log *zap.Logger) Middleware {
+	issuer := viper.GetString(config.AuthTokenIssuer)
+	if issuer == "" {
+		issuer = auth.DefaultTokenIssuer
+	}
+	audience := viper.GetString(config.AuthTokenAudience)
+	if audience == "" {
+		audience = auth.DefaultTokenAudience
+	}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods([]string{keyring.AlgorithmRS256, keyring.AlgorithmEdDSA}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+		jwt.WithExpirationRequired(),
+	)
+
 	return func(h http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			tokenString := r.Header.Get("token")
+			if key := apiKeyFromRequest(r); key != "" {
+				apiKey, err := apiKeys.Authenticate(r.Context(), key)
+				if err != nil {
+					pHTTP.HandleError(w, r, err)
+					return
+				}
+
+				ctx := withIdentity(tenant.WithID(r.Context(), credentialsTenant(apiKey.TenantID)), &identity{
+					apiKeyID:    apiKey.ID,
+					permissions: apiKey.Scopes,
+				})
+				h(w, r.WithContext(ctx))
+				return
+			}
 
-			parsedToken, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					log.Warn("Unexpected signing method", zap.Any("alg", token.Header["alg"]))
-					return nil, pErrors.ErrInvalidAuthToken
+			token := tokenFromRequest(r)
+			if token == "" && services != nil {
+				if service, ok := serviceFromRequest(r, services); ok {
+					ctx := withIdentity(tenant.WithID(r.Context(), credentialsTenant(service.TenantID)), &identity{
+						service:     service.Name,
+						permissions: service.Permissions,
+					})
+					h(w, r.WithContext(ctx))
+					return
 				}
+			}
 
-				return []byte(viper.GetString(config.AuthKey)), nil
-			})
+			var claims auth.Claims
+			_, err := parser.ParseWithClaims(token, &claims, keys.Keyfunc)
 			if err != nil {
 				pHTTP.HandleError(w, r, pErrors.ErrInvalidAuthToken)
 				return
 			}
 
-			claims, ok := parsedToken.Claims.(jwt.MapClaims)
-			if !ok {
-				pHTTP.HandleError(w, r, pErrors.ErrInvalidAuthToken)
+			revoked, err := denyList.Contains(r.Context(), denylist.TokenKey(claims.ID), denylist.SessionKey(claims.SessionID))
+			if err != nil {
+				// Sessions of disabled and deleted users are revoked through
+				// the deny list only, so their tokens are accepted unchecked
+				// only by read-only routes marked with FailOpen.
+				if !failOpen(r.Context()) {
+					log.Error("Check auth: failed to check deny list", zap.Error(err))
+					pHTTP.HandleError(w, r, pErrors.ErrDenyListUnavailable)
+					return
+				}
+				log.Warn("Check auth: deny list unavailable, token accepted unchecked", zap.Error(err))
+			}
+			if revoked {
+				pHTTP.HandleError(w, r, pErrors.ErrAuthTokenRevoked)
 				return
 			}
 
-			ctx := context.WithValue(r.Context(), ContextUserID, claims["user_id"])
-			ctx = context.WithValue(ctx, ContextIsAdmin, claims["is_admin"])
-
+			permissions := claims.Permissions
+			if permissions == nil {
+				permissions = []string{}
+			}
+			ctx := withIdentity(tenant.WithID(r.Context(), credentialsTenant(claims.TenantID)), &identity{
+				userID:      claims.UserID,
+				role:        claims.Role,
+				permissions: permissions,
+				token: &Token{
+					ID:        claims.ID,
+					SessionID: claims.SessionID,
+					ExpiresAt: claims.ExpiresAt.Time,
+				},
+			})
 			h(w, r.WithContext(ctx))
 		}
 	}
 }
+
+// FailOpen lets checkAuth accept tokens it can't check against the deny list.
+// It's meant for read-only routes that keep working while Redis is down.
+func FailOpen(checkAuth Middleware) Middleware {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		next := checkAuth(h)
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r.WithContext(context.WithValue(r.Context(), failOpenKey, true)))
+		}
+	}
+}
+
+func failOpen(ctx context.Context) bool {
+	open, _ := ctx.Value(failOpenKey).(bool)
+	return open
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if authorization := r.Header.Get("Authorization"); strings.HasPrefix(authorization, apiKeyScheme) {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, apiKeyScheme))
+	}
+	return ""
+}
+
+func tokenFromRequest(r *http.Request) string {
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > len(bearerScheme) && strings.EqualFold(authorization[:len(bearerScheme)], bearerScheme) {
+		return strings.TrimSpace(authorization[len(bearerScheme):])
+	}
+	return r.Header.Get("token")
+}
+
+// serviceFromRequest identifies the leaf of the chain the client certificate
+// is verified with.
+func serviceFromRequest(r *http.Request, services *mtls.Services) (*mtls.Service, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+	return services.Identify(r.TLS.VerifiedChains[0][0])
+}
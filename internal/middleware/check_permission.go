@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// PermissionMiddleware builds a middleware requiring the permission.
+type PermissionMiddleware func(permission string) Middleware
+
+func NewCheckPermission(log *zap.Logger) PermissionMiddleware {
+	return func(permission string) Middleware {
+		return func(h http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				if !GetPrincipal(r.Context()).Can(permission) {
+					log.Debug("Permission denied", zap.String("permission", permission))
+					pHTTP.HandleError(w, r, pErrors.ErrPermissionDenied)
+					return
+				}
+				h(w, r)
+			}
+		}
+	}
+}
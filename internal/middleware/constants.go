@@ -1,6 +0,0 @@
-package middleware
-
-const (
-	ContextUserID  = "user_id"
-	ContextIsAdmin = "is_admin"
-)
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"time"
+)
+
+type contextKey int
+
+const (
+	identityKey contextKey = iota
+	failOpenKey
+)
+
+// Token is the access token the request is authenticated with.
+type Token struct {
+	ID        string
+	SessionID int64
+	ExpiresAt time.Time
+}
+
+// identity is the caller authenticated by NewCheckAuth: either a user with a
+// token, an API key or a service with a client certificate.
+type identity struct {
+	userID      int64
+	apiKeyID    int64
+	service     string
+	role        string
+	permissions []string
+	token       *Token
+}
+
+func withIdentity(ctx context.Context, id *identity) context.Context {
+	return context.WithValue(ctx, identityKey, id)
+}
+
+func getIdentity(ctx context.Context) *identity {
+	id, _ := ctx.Value(identityKey).(*identity)
+	if id == nil {
+		return &identity{}
+	}
+	return id
+}
+
+// GetUserID returns false for API keys and unauthenticated requests.
+func GetUserID(ctx context.Context) (int64, bool) {
+	id := getIdentity(ctx)
+	return id.userID, id.userID != 0
+}
+
+// GetAPIKeyID returns false unless the request is authenticated with an API key.
+func GetAPIKeyID(ctx context.Context) (int64, bool) {
+	id := getIdentity(ctx)
+	return id.apiKeyID, id.apiKeyID != 0
+}
+
+// GetService returns false unless the request is authenticated with a client
+// certificate.
+func GetService(ctx context.Context) (string, bool) {
+	id := getIdentity(ctx)
+	return id.service, id.service != ""
+}
+
+// GetRole is empty for API keys and services.
+func GetRole(ctx context.Context) string {
+	return getIdentity(ctx).role
+}
+
+func GetPermissions(ctx context.Context) []string {
+	return getIdentity(ctx).permissions
+}
+
+// GetToken returns false for API keys and services: they have no session.
+func GetToken(ctx context.Context) (*Token, bool) {
+	token := getIdentity(ctx).token
+	return token, token != nil
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+)
+
+// GetPrincipal returns the caller authenticated by NewCheckAuth.
+func GetPrincipal(ctx context.Context) *models.Principal {
+	id := getIdentity(ctx)
+	return &models.Principal{
+		UserID:      id.userID,
+		Permissions: id.permissions,
+	}
+}
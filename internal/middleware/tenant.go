@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+)
+
+const TenantHeader = "X-Tenant-ID"
+
+// NewTenant binds requests to the tenant from the X-Tenant-ID header or to the
+// default one. Only public endpoints, like sign in, depend on it: NewCheckAuth
+// replaces it with the tenant of the credentials.
+func NewTenant() func(handler http.Handler) http.Handler {
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(TenantHeader)
+			if header == "" {
+				handler.ServeHTTP(w, r)
+				return
+			}
+			tenantID, err := strconv.ParseInt(header, 10, 64)
+			if err != nil || tenantID <= 0 {
+				pHTTP.HandleError(w, r, pErrors.ErrBadTenantIDParam)
+				return
+			}
+			handler.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), tenantID)))
+		})
+	}
+}
+
+// credentialsTenant treats credentials issued before tenants as ones of the
+// default tenant.
+func credentialsTenant(tenantID int64) int64 {
+	if tenantID == 0 {
+		return tenant.DefaultID
+	}
+	return tenantID
+}
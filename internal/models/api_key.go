@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// APIKey authenticates a service instead of a user. Only a hash of the key
+// is stored, Prefix helps to tell keys apart.
+type APIKey struct {
+	ID         int64
+	TenantID   int64
+	Name       string
+	Prefix     string
+	Scopes     []string
+	CreatedBy  int64
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
@@ -6,6 +6,7 @@ import (
 
 type Banner struct {
 	ID        int64
+	TenantID  int64
 	TagIDs    []int64
 	FeatureID int64
 	Content   map[string]any
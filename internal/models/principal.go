@@ -0,0 +1,11 @@
+package models
+
+// Principal is the authenticated caller of a usecase.
+type Principal struct {
+	UserID      int64
+	Permissions []string
+}
+
+func (p *Principal) Can(permission string) bool {
+	return HasPermission(p.Permissions, permission)
+}
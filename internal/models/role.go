@@ -0,0 +1,66 @@
+package models
+
+const (
+	RoleUser      = "user"
+	RoleViewer    = "viewer"
+	RoleEditor    = "editor"
+	RolePublisher = "publisher"
+	RoleAdmin     = "admin"
+	// RoleSuperAdmin manages teams and isn't limited to the features of its teams.
+	// Super-admins of the default tenant provision other tenants.
+	RoleSuperAdmin = "superadmin"
+)
+
+const (
+	// PermissionBannerRead allows listing banners and getting inactive ones.
+	PermissionBannerRead   = "banner:read"
+	PermissionBannerCreate = "banner:create"
+	PermissionBannerUpdate = "banner:update"
+	// PermissionBannerPublish allows activating and deactivating banners.
+	PermissionBannerPublish = "banner:publish"
+	PermissionBannerDelete  = "banner:delete"
+	PermissionCacheManage   = "cache:manage"
+	PermissionTeamManage    = "team:manage"
+	PermissionAPIKeyManage  = "apikey:manage"
+	PermissionUserManage    = "user:manage"
+	// PermissionFeatureAny allows managing banners of features not owned by
+	// the caller's teams.
+	PermissionFeatureAny = "feature:any"
+	// PermissionTenantManage is only effective in the default tenant.
+	PermissionTenantManage = "tenant:manage"
+)
+
+// DefaultRolePermissions is the permission matrix seeded by schema.sql.
+// It is used as is by the in-memory storage.
+var DefaultRolePermissions = map[string][]string{
+	RoleUser:   {},
+	RoleViewer: {PermissionBannerRead},
+	RoleEditor: {PermissionBannerRead, PermissionBannerCreate, PermissionBannerUpdate},
+	RolePublisher: {PermissionBannerRead, PermissionBannerCreate, PermissionBannerUpdate,
+		PermissionBannerPublish},
+	RoleAdmin: {PermissionBannerRead, PermissionBannerCreate, PermissionBannerUpdate,
+		PermissionBannerPublish, PermissionBannerDelete, PermissionCacheManage, PermissionAPIKeyManage,
+		PermissionUserManage},
+	RoleSuperAdmin: {PermissionBannerRead, PermissionBannerCreate, PermissionBannerUpdate,
+		PermissionBannerPublish, PermissionBannerDelete, PermissionCacheManage, PermissionAPIKeyManage,
+		PermissionUserManage, PermissionTeamManage, PermissionFeatureAny, PermissionTenantManage},
+}
+
+// HasPermissions reports whether permissions include all of required.
+func HasPermissions(permissions, required []string) bool {
+	for _, p := range required {
+		if !HasPermission(permissions, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func HasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
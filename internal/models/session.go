@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Session is a chain of rotated refresh tokens started by a single sign-in.
+type Session struct {
+	ID        int64
+	TenantID  int64
+	UserID    int64
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
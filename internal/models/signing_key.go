@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// SigningKey signs access tokens. Tokens name the key in the kid header, so
+// a key verifies tokens until it expires, after it's no longer used for
+// signing.
+type SigningKey struct {
+	ID        string
+	TenantID  int64
+	Algorithm string
+	// PrivateKey is PKCS #8 DER.
+	PrivateKey []byte
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+type Team struct {
+	ID         int64
+	Name       string
+	MemberIDs  []int64
+	FeatureIDs []int64
+	CreatedAt  time.Time
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// Tenant is an independent product hosted by the deployment.
+type Tenant struct {
+	ID        int64
+	Name      string
+	CreatedAt time.Time
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// TwoFactor is a TOTP second factor of a user. It's pending until the user
+// confirms it with the first code.
+type TwoFactor struct {
+	UserID int64
+	// Secret is base32 encoded.
+	Secret      string
+	ConfirmedAt *time.Time
+	// LastUsedStep is the time step of the last accepted code.
+	LastUsedStep int64
+	CreatedAt    time.Time
+}
+
+func (f *TwoFactor) IsConfirmed() bool {
+	return f.ConfirmedAt != nil
+}
@@ -3,9 +3,16 @@ package models
 import "time"
 
 type User struct {
-	ID        int64
-	Username  string
-	Password  string
-	IsAdmin   bool
-	CreatedAt time.Time
+	ID          int64
+	TenantID    int64
+	Username    string
+	Password    string
+	Role        string
+	Permissions []string
+	IsDisabled  bool
+	CreatedAt   time.Time
+}
+
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin || u.Role == RoleSuperAdmin
 }
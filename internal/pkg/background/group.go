@@ -0,0 +1,37 @@
+package background
+
+import (
+	"context"
+	"sync"
+)
+
+// Group tracks fire-and-forget goroutines, so that shutdown can wait for them.
+// The zero value is ready to use.
+type Group struct {
+	wg sync.WaitGroup
+}
+
+func (g *Group) Go(f func()) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		f()
+	}()
+}
+
+// Wait blocks until all goroutines return or ctx is done. It must be called
+// once nothing can start new goroutines.
+func (g *Group) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,9 +1,76 @@
 package config
 
 import (
+	"time"
+
 	"github.com/spf13/viper"
 )
 
+// API
+
+func SetAPIDefaults() {
+	viper.SetDefault(ServerShutdownDelay, 0)
+	viper.SetDefault(ServerShutdownTimeout, 30*time.Second)
+	viper.SetDefault(ServerHealthCheckTimeout, 500*time.Millisecond)
+	viper.SetDefault(AuthAccessTokenTTL, 15*time.Minute)
+	viper.SetDefault(AuthRefreshTokenTTL, 30*24*time.Hour)
+	viper.SetDefault(AuthResetTokenTTL, 24*time.Hour)
+	viper.SetDefault(AuthTokenIssuer, "avito-banner-service")
+	viper.SetDefault(AuthTokenAudience, "avito-banner-service")
+	viper.SetDefault(AuthSigningAlgorithm, "RS256")
+	viper.SetDefault(AuthSigningKeyRotation, 24*time.Hour)
+	viper.SetDefault(AuthSigningKeysReload, time.Minute)
+	viper.SetDefault(AuthDenyListCacheTTL, 5*time.Second)
+	viper.SetDefault(AuthDenyListMaxStaleness, 5*time.Minute)
+	viper.SetDefault(AuthAPIKeyCacheTTL, 30*time.Second)
+	viper.SetDefault(AuthSignUpMode, SignUpOpen)
+	viper.SetDefault(AuthInviteCodeTTL, 7*24*time.Hour)
+	viper.SetDefault(AuthUsernameMinLength, 3)
+	viper.SetDefault(AuthUsernameMaxLength, 32)
+	viper.SetDefault(AuthUsernamePattern, `^[a-zA-Z0-9_.-]+$`)
+	viper.SetDefault(AuthReservedUsernames, []string{"admin", "administrator", "root", "superadmin", "system", "support", "api"})
+	viper.SetDefault(AuthPasswordMinLength, 8)
+	viper.SetDefault(AuthPasswordMinClasses, 2)
+	viper.SetDefault(AuthRejectCommonPasswords, true)
+	viper.SetDefault(AuthPasswordHasher, HasherArgon2id)
+	viper.SetDefault(AuthBcryptCost, 10)
+	viper.SetDefault(AuthArgon2idMemory, 64*1024)
+	viper.SetDefault(AuthArgon2idIterations, 3)
+	viper.SetDefault(AuthArgon2idParallelism, 2)
+	viper.SetDefault(AuthSignInWindow, 15*time.Minute)
+	viper.SetDefault(AuthSignInFreeAttempts, 3)
+	viper.SetDefault(AuthSignInBaseDelay, time.Second)
+	viper.SetDefault(AuthSignInMaxDelay, 30*time.Second)
+	viper.SetDefault(AuthSignInLockoutAttempts, 10)
+	viper.SetDefault(AuthSignInIPLockoutAttempts, 100)
+	viper.SetDefault(AuthSignInLockoutDuration, 15*time.Minute)
+	viper.SetDefault(AuthTwoFactorIssuer, "Avito Banners")
+	viper.SetDefault(AuthTwoFactorSkew, 1)
+	viper.SetDefault(AuthTwoFactorEnforceAdmins, false)
+	viper.SetDefault(AuthTwoFactorChallengeTTL, 5*time.Minute)
+	viper.SetDefault(StorageBackend, BackendPostgres)
+	viper.SetDefault(CacheBackend, BackendRedis)
+	viper.SetDefault(MemoryAdminUsername, "admin")
+	viper.SetDefault(CacheWarmupEnabled, true)
+	viper.SetDefault(CacheWarmupConcurrency, 16)
+	viper.SetDefault(CacheWarmupTimeout, time.Minute)
+	viper.SetDefault(CacheLocalEnabled, false)
+	viper.SetDefault(CacheLocalTTL, 30*time.Second)
+	viper.SetDefault(CacheCompression, "none")
+	viper.SetDefault(CacheCompressionThreshold, 1024)
+	viper.SetDefault(CacheBreakerThreshold, 5)
+	viper.SetDefault(CacheBreakerProbeInterval, time.Second)
+	viper.SetDefault(SnapshotEnabled, false)
+	viper.SetDefault(SnapshotReloadInterval, 5*time.Minute)
+	viper.SetDefault(RedisMode, RedisModeStandalone)
+	viper.SetDefault(RedisDialTimeout, 500*time.Millisecond)
+	viper.SetDefault(RedisReadTimeout, 200*time.Millisecond)
+	viper.SetDefault(RedisWriteTimeout, 200*time.Millisecond)
+	viper.SetDefault(RedisMaxRetries, 1)
+	viper.SetDefault(MemcachedTimeout, 200*time.Millisecond)
+	viper.SetDefault(MemcachedMaxIdleConns, 16)
+}
+
 // Postgres
 
 func SetTestPostgresConfig() {
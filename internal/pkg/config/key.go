@@ -2,7 +2,105 @@ package config
 
 const (
 	ServerPort = "PORT"
-	AuthKey    = "AUTH_KEY"
+
+	ServerShutdownDelay   = "SHUTDOWN_DELAY"
+	ServerShutdownTimeout = "SHUTDOWN_TIMEOUT"
+
+	ServerHealthCheckTimeout = "HEALTH_CHECK_TIMEOUT"
+
+	ServerTrustedProxies = "TRUSTED_PROXIES"
+
+	ServerTLSCertFile          = "TLS_CERT_FILE"
+	ServerTLSKeyFile           = "TLS_KEY_FILE"
+	ServerTLSClientCAFile      = "TLS_CLIENT_CA_FILE"
+	ServerTLSRequireClientCert = "TLS_REQUIRE_CLIENT_CERT"
+	ServerTLSClientServices    = "TLS_CLIENT_SERVICES"
+)
+
+// Auth
+const (
+	AuthAccessTokenTTL  = "AUTH_ACCESS_TOKEN_TTL"
+	AuthRefreshTokenTTL = "AUTH_REFRESH_TOKEN_TTL"
+	AuthResetTokenTTL   = "AUTH_RESET_TOKEN_TTL"
+
+	AuthTokenIssuer   = "AUTH_TOKEN_ISSUER"
+	AuthTokenAudience = "AUTH_TOKEN_AUDIENCE"
+
+	AuthSigningAlgorithm   = "AUTH_SIGNING_ALGORITHM"
+	AuthSigningKeyRotation = "AUTH_SIGNING_KEY_ROTATION"
+	AuthSigningKeysReload  = "AUTH_SIGNING_KEYS_RELOAD"
+	// AuthSigningKeysEncryptionKey is also read from the environment.
+	AuthSigningKeysEncryptionKey = "AUTH_SIGNING_KEYS_ENCRYPTION_KEY"
+
+	AuthDenyListCacheTTL     = "AUTH_DENY_LIST_CACHE_TTL"
+	AuthDenyListMaxStaleness = "AUTH_DENY_LIST_MAX_STALENESS"
+	AuthAPIKeyCacheTTL       = "AUTH_API_KEY_CACHE_TTL"
+
+	AuthSignUpMode    = "AUTH_SIGNUP_MODE"
+	AuthInviteCodeTTL = "AUTH_INVITE_CODE_TTL"
+
+	AuthUsernameMinLength     = "AUTH_USERNAME_MIN_LENGTH"
+	AuthUsernameMaxLength     = "AUTH_USERNAME_MAX_LENGTH"
+	AuthUsernamePattern       = "AUTH_USERNAME_PATTERN"
+	AuthReservedUsernames     = "AUTH_RESERVED_USERNAMES"
+	AuthPasswordMinLength     = "AUTH_PASSWORD_MIN_LENGTH"
+	AuthPasswordMinClasses    = "AUTH_PASSWORD_MIN_CLASSES"
+	AuthRejectCommonPasswords = "AUTH_REJECT_COMMON_PASSWORDS"
+
+	AuthPasswordHasher      = "AUTH_PASSWORD_HASHER"
+	AuthBcryptCost          = "AUTH_BCRYPT_COST"
+	AuthArgon2idMemory      = "AUTH_ARGON2ID_MEMORY"
+	AuthArgon2idIterations  = "AUTH_ARGON2ID_ITERATIONS"
+	AuthArgon2idParallelism = "AUTH_ARGON2ID_PARALLELISM"
+
+	AuthSignInWindow            = "AUTH_SIGNIN_WINDOW"
+	AuthSignInFreeAttempts      = "AUTH_SIGNIN_FREE_ATTEMPTS"
+	AuthSignInBaseDelay         = "AUTH_SIGNIN_BASE_DELAY"
+	AuthSignInMaxDelay          = "AUTH_SIGNIN_MAX_DELAY"
+	AuthSignInLockoutAttempts   = "AUTH_SIGNIN_LOCKOUT_ATTEMPTS"
+	AuthSignInIPLockoutAttempts = "AUTH_SIGNIN_IP_LOCKOUT_ATTEMPTS"
+	AuthSignInLockoutDuration   = "AUTH_SIGNIN_LOCKOUT_DURATION"
+
+	AuthTwoFactorIssuer        = "AUTH_TWO_FACTOR_ISSUER"
+	AuthTwoFactorSkew          = "AUTH_TWO_FACTOR_SKEW"
+	AuthTwoFactorEnforceAdmins = "AUTH_TWO_FACTOR_ENFORCE_ADMINS"
+	AuthTwoFactorChallengeTTL  = "AUTH_TWO_FACTOR_CHALLENGE_TTL"
+)
+
+// Sign up modes
+const (
+	SignUpOpen     = "open"
+	SignUpInvite   = "invite"
+	SignUpDisabled = "disabled"
+)
+
+// Password hashers
+const (
+	HasherArgon2id = "argon2id"
+	HasherBcrypt   = "bcrypt"
+)
+
+// Storage
+const (
+	StorageBackend      = "STORAGE_BACKEND"
+	CacheBackend        = "CACHE_BACKEND"
+	MemoryAdminUsername = "MEMORY_ADMIN_USERNAME"
+	MemoryAdminPassword = "MEMORY_ADMIN_PASSWORD"
+)
+
+// Storage backends
+const (
+	BackendPostgres = "postgres"
+	BackendRedis    = "redis"
+	BackendMemory   = "memory"
+
+	BackendMemcached = "memcached"
+)
+
+// Snapshot
+const (
+	SnapshotEnabled        = "SNAPSHOT_ENABLED"
+	SnapshotReloadInterval = "SNAPSHOT_RELOAD_INTERVAL"
 )
 
 // Postgres
@@ -17,8 +115,49 @@ const (
 
 // Redis
 const (
+	RedisMode     = "REDIS_MODE"
 	RedisHost     = "REDIS_HOST"
 	RedisPort     = "REDIS_PORT"
 	RedisDB       = "REDIS_DB"
+	RedisUser     = "REDIS_USER"
 	RedisPassword = "REDIS_PASSWORD"
+
+	RedisDialTimeout  = "REDIS_DIAL_TIMEOUT"
+	RedisReadTimeout  = "REDIS_READ_TIMEOUT"
+	RedisWriteTimeout = "REDIS_WRITE_TIMEOUT"
+	RedisMaxRetries   = "REDIS_MAX_RETRIES"
+
+	RedisMasterName       = "REDIS_MASTER_NAME"
+	RedisSentinelAddrs    = "REDIS_SENTINEL_ADDRS"
+	RedisSentinelPassword = "REDIS_SENTINEL_PASSWORD"
+	RedisClusterAddrs     = "REDIS_CLUSTER_ADDRS"
+)
+
+// Redis modes
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
+
+// Memcached
+const (
+	MemcachedAddrs        = "MEMCACHED_ADDRS"
+	MemcachedTimeout      = "MEMCACHED_TIMEOUT"
+	MemcachedMaxIdleConns = "MEMCACHED_MAX_IDLE_CONNS"
+)
+
+// Cache
+const (
+	CacheWarmupEnabled     = "CACHE_WARMUP_ENABLED"
+	CacheWarmupConcurrency = "CACHE_WARMUP_CONCURRENCY"
+	CacheWarmupTimeout     = "CACHE_WARMUP_TIMEOUT"
+	CacheLocalEnabled      = "CACHE_LOCAL_ENABLED"
+	CacheLocalTTL          = "CACHE_LOCAL_TTL"
+
+	CacheCompression          = "CACHE_COMPRESSION"
+	CacheCompressionThreshold = "CACHE_COMPRESSION_THRESHOLD"
+
+	CacheBreakerThreshold     = "CACHE_BREAKER_THRESHOLD"
+	CacheBreakerProbeInterval = "CACHE_BREAKER_PROBE_INTERVAL"
 )
@@ -15,16 +15,61 @@ var (
 	// User
 	ErrUserNotFound      = errors.New("user not found")
 	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrUserDisabled      = errors.New("user disabled")
+
+	// Credentials
+	ErrBadUsernameLength  = errors.New("bad username length")
+	ErrBadUsernameCharset = errors.New("username contains forbidden characters")
+	ErrUsernameReserved   = errors.New("username reserved")
+	ErrPasswordTooShort   = errors.New("password too short")
+	ErrPasswordTooLong    = errors.New("password too long")
+	ErrPasswordTooSimple  = errors.New("password contains too few character classes")
+	ErrPasswordTooCommon  = errors.New("password too common")
+
+	// Team
+	ErrTeamNotFound        = errors.New("team not found")
+	ErrTeamAlreadyExists   = errors.New("team already exists")
+	ErrTeamMemberNotFound  = errors.New("team member not found")
+	ErrTeamFeatureNotFound = errors.New("feature not owned by team")
+	ErrFeatureNotFound     = errors.New("feature not found")
+	ErrFeatureNotOwned     = errors.New("feature not owned by your teams")
+
+	// Tenant
+	ErrTenantNotFound      = errors.New("tenant not found")
+	ErrTenantAlreadyExists = errors.New("tenant already exists")
+	ErrDefaultTenant       = errors.New("default tenant can't be deleted")
 
 	// Auth
 	ErrWrongLoginOrPassword = errors.New("wrong login or password")
+	ErrWrongPassword        = errors.New("wrong password")
 	ErrGetHashedPassword    = errors.New("get hashed password error")
 	ErrInvalidAuthToken     = errors.New("invalid auth token")
 	ErrAuthTokenNotFound    = errors.New("auth token not found")
+	ErrAuthTokenRevoked     = errors.New("auth token revoked")
+	ErrInvalidRefreshToken  = errors.New("invalid refresh token")
+	ErrRefreshTokenReused   = errors.New("refresh token reused, session revoked")
+	ErrInvalidAPIKey        = errors.New("invalid api key")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrInvalidResetToken    = errors.New("invalid or expired reset token")
+	ErrSignUpDisabled       = errors.New("sign up disabled")
+	ErrInvalidInviteCode    = errors.New("invalid or expired invite code")
+	ErrTooManyAttempts      = errors.New("too many sign in attempts, try again later")
+	ErrDenyListUnavailable  = errors.New("revoked tokens can't be checked, try again later")
+
+	// Two-factor
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor authentication not enrolled")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication required")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrInvalidChallengeToken   = errors.New("invalid or expired challenge token")
 
 	// Access
-	ErrAdminRequired  = errors.New("admin privileges required")
-	ErrBannerDisabled = errors.New("banner disabled")
+	ErrPermissionDenied = errors.New("permission denied")
+	ErrBannerDisabled   = errors.New("banner disabled")
+
+	// Cache
+	ErrCacheMiss        = errors.New("cache miss")
+	ErrCacheUnavailable = errors.New("cache unavailable")
 
 	// HTTP
 	ErrReadBody = errors.New("read request body error")
@@ -33,11 +78,20 @@ var (
 	ErrBadContentField   = errors.New("bad content field")
 	ErrBadFeatureIDField = errors.New("bad feature_id field")
 	ErrBadTagIDsField    = errors.New("bad tag_ids field")
+	ErrBadNameField      = errors.New("bad name field")
+	ErrBadRoleField      = errors.New("bad role field")
+	ErrBadScopesField    = errors.New("bad scopes field")
+	ErrBadExpiresAtField = errors.New("bad expires_at field")
 
 	// Get params
 	ErrBadBannerIDParam  = errors.New("bad banner id parameter")
+	ErrBadTeamIDParam    = errors.New("bad team id parameter")
+	ErrBadUserIDParam    = errors.New("bad user id parameter")
+	ErrBadTenantIDParam  = errors.New("bad tenant id parameter")
+	ErrBadAPIKeyIDParam  = errors.New("bad api key id parameter")
 	ErrBadFeatureIDParam = errors.New("bad feature id parameter")
 	ErrBadTagIDParam     = errors.New("bad tag id parameter")
 	ErrBadLimitParam     = errors.New("bad limit parameter")
 	ErrBadOffsetParam    = errors.New("bad offset parameter")
+	ErrBadIsAdminParam   = errors.New("bad is_admin parameter")
 )
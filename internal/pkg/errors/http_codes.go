@@ -13,26 +13,80 @@ var httpCodes = map[error]int{
 	ErrBadFeatureIDField: http.StatusBadRequest,
 	ErrBadTagIDsField:    http.StatusBadRequest,
 	ErrBadContentField:   http.StatusBadRequest,
+	ErrBadNameField:      http.StatusBadRequest,
+	ErrBadRoleField:      http.StatusBadRequest,
+	ErrBadScopesField:    http.StatusBadRequest,
+	ErrBadExpiresAtField: http.StatusBadRequest,
 
 	// User
 	ErrUserNotFound:      http.StatusNotFound,
 	ErrUserAlreadyExists: http.StatusConflict,
+	ErrUserDisabled:      http.StatusForbidden,
+
+	// Credentials
+	ErrBadUsernameLength:  http.StatusBadRequest,
+	ErrBadUsernameCharset: http.StatusBadRequest,
+	ErrUsernameReserved:   http.StatusBadRequest,
+	ErrPasswordTooShort:   http.StatusBadRequest,
+	ErrPasswordTooLong:    http.StatusBadRequest,
+	ErrPasswordTooSimple:  http.StatusBadRequest,
+	ErrPasswordTooCommon:  http.StatusBadRequest,
+
+	// Team
+	ErrTeamNotFound:        http.StatusNotFound,
+	ErrTeamAlreadyExists:   http.StatusConflict,
+	ErrTeamMemberNotFound:  http.StatusNotFound,
+	ErrTeamFeatureNotFound: http.StatusNotFound,
+	ErrFeatureNotFound:     http.StatusNotFound,
+	ErrFeatureNotOwned:     http.StatusForbidden,
+
+	// Tenant
+	ErrTenantNotFound:      http.StatusNotFound,
+	ErrTenantAlreadyExists: http.StatusConflict,
+	ErrDefaultTenant:       http.StatusConflict,
 
 	// Auth
 	ErrWrongLoginOrPassword: http.StatusBadRequest,
+	ErrWrongPassword:        http.StatusForbidden,
 	ErrAuthTokenNotFound:    http.StatusUnauthorized,
 	ErrInvalidAuthToken:     http.StatusUnauthorized,
-	ErrAdminRequired:        http.StatusForbidden,
+	ErrAuthTokenRevoked:     http.StatusUnauthorized,
+	ErrInvalidRefreshToken:  http.StatusUnauthorized,
+	ErrRefreshTokenReused:   http.StatusUnauthorized,
+	ErrInvalidAPIKey:        http.StatusUnauthorized,
+	ErrAPIKeyNotFound:       http.StatusNotFound,
+	ErrInvalidResetToken:    http.StatusBadRequest,
+	ErrSignUpDisabled:       http.StatusForbidden,
+	ErrInvalidInviteCode:    http.StatusForbidden,
+	ErrTooManyAttempts:      http.StatusTooManyRequests,
+	ErrDenyListUnavailable:  http.StatusServiceUnavailable,
+	ErrPermissionDenied:     http.StatusForbidden,
+
+	// Two-factor
+	ErrTwoFactorAlreadyEnabled: http.StatusConflict,
+	ErrTwoFactorNotEnrolled:    http.StatusBadRequest,
+	ErrTwoFactorRequired:       http.StatusForbidden,
+	ErrInvalidTwoFactorCode:    http.StatusBadRequest,
+	ErrInvalidChallengeToken:   http.StatusUnauthorized,
+
+	// Cache
+	ErrCacheMiss:        http.StatusNotFound,
+	ErrCacheUnavailable: http.StatusServiceUnavailable,
 
 	// HTTP
 	ErrReadBody: http.StatusBadRequest,
 
 	// Get params
 	ErrBadBannerIDParam:  http.StatusBadRequest,
+	ErrBadTeamIDParam:    http.StatusBadRequest,
+	ErrBadUserIDParam:    http.StatusBadRequest,
+	ErrBadTenantIDParam:  http.StatusBadRequest,
+	ErrBadAPIKeyIDParam:  http.StatusBadRequest,
 	ErrBadFeatureIDParam: http.StatusBadRequest,
 	ErrBadTagIDParam:     http.StatusBadRequest,
 	ErrBadLimitParam:     http.StatusBadRequest,
 	ErrBadOffsetParam:    http.StatusBadRequest,
+	ErrBadIsAdminParam:   http.StatusBadRequest,
 }
 
 func ErrorToHTTPCode(err error) (int, bool) {
@@ -11,12 +11,54 @@ var jsonErrors = map[error]struct{}{
 	ErrBadFeatureIDField: {},
 	ErrBadTagIDsField:    {},
 	ErrBadContentField:   {},
+	ErrBadNameField:      {},
+	ErrBadRoleField:      {},
+	ErrBadScopesField:    {},
+	ErrBadExpiresAtField: {},
+
+	// Auth
+	ErrDenyListUnavailable: {},
+
+	// Cache
+	ErrCacheUnavailable: {},
 
 	// User
 	ErrUserAlreadyExists: {},
+	ErrUserDisabled:      {},
+
+	// Credentials
+	ErrBadUsernameLength:  {},
+	ErrBadUsernameCharset: {},
+	ErrUsernameReserved:   {},
+	ErrPasswordTooShort:   {},
+	ErrPasswordTooLong:    {},
+	ErrPasswordTooSimple:  {},
+	ErrPasswordTooCommon:  {},
+
+	// Team
+	ErrTeamAlreadyExists: {},
+	ErrFeatureNotOwned:   {},
+
+	// Tenant
+	ErrTenantAlreadyExists: {},
+	ErrDefaultTenant:       {},
 
 	// Auth
 	ErrWrongLoginOrPassword: {},
+	ErrWrongPassword:        {},
+	ErrInvalidResetToken:    {},
+	ErrSignUpDisabled:       {},
+	ErrInvalidInviteCode:    {},
+	ErrTooManyAttempts:      {},
+	ErrInvalidRefreshToken:  {},
+	ErrRefreshTokenReused:   {},
+
+	// Two-factor
+	ErrTwoFactorAlreadyEnabled: {},
+	ErrTwoFactorNotEnrolled:    {},
+	ErrTwoFactorRequired:       {},
+	ErrInvalidTwoFactorCode:    {},
+	ErrInvalidChallengeToken:   {},
 
 	// HTTP
 	ErrReadBody: {},
@@ -24,9 +66,11 @@ var jsonErrors = map[error]struct{}{
 	// Get params
 	ErrBadBannerIDParam:  {},
 	ErrBadFeatureIDParam: {},
+	ErrBadTenantIDParam:  {},
 	ErrBadTagIDParam:     {},
 	ErrBadLimitParam:     {},
 	ErrBadOffsetParam:    {},
+	ErrBadIsAdminParam:   {},
 }
 
 func IsJSONError(err error) bool {
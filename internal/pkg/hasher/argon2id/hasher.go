@@ -0,0 +1,132 @@
+package argon2id
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+
+	pHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher"
+)
+
+// Prefix of hashes in the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+const Prefix = "$argon2id$"
+
+const (
+	DefaultMemory      = 64 * 1024
+	DefaultIterations  = 3
+	DefaultParallelism = 2
+	DefaultSaltLength  = 16
+	DefaultKeyLength   = 32
+)
+
+var (
+	ErrInvalidHash         = errors.New("invalid argon2id hash")
+	ErrIncompatibleVersion = errors.New("incompatible argon2 version")
+	ErrMismatchedPassword  = errors.New("hashed password is not the hash of the given password")
+)
+
+type Params struct {
+	// Memory is in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type hasher struct {
+	params Params
+}
+
+// New replaces unset parameters with defaults.
+func New(params Params) pHasher.Hasher {
+	if params.Memory == 0 {
+		params.Memory = DefaultMemory
+	}
+	if params.Iterations == 0 {
+		params.Iterations = DefaultIterations
+	}
+	if params.Parallelism == 0 {
+		params.Parallelism = DefaultParallelism
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = DefaultSaltLength
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = DefaultKeyLength
+	}
+	return &hasher{params: params}
+}
+
+func (h *hasher) GetHashedPassword(_ context.Context, password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism,
+		h.params.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", Prefix, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *hasher) CompareHashAndPassword(_ context.Context, hashedPassword, password string) error {
+	params, salt, key, err := decode(hashedPassword)
+	if err != nil {
+		return err
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism,
+		params.KeyLength)
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func (h *hasher) NeedsRehash(hashedPassword string) bool {
+	params, _, _, err := decode(hashedPassword)
+	return err != nil || params != h.params
+}
+
+func decode(hashedPassword string) (Params, []byte, []byte, error) {
+	var params Params
+	parts := strings.Split(strings.TrimPrefix(hashedPassword, Prefix), "$")
+	if !strings.HasPrefix(hashedPassword, Prefix) || len(parts) != 4 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[0], "v=%d", &version); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	if version != argon2.Version {
+		return params, nil, nil, ErrIncompatibleVersion
+	}
+
+	_, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil || params.Memory == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
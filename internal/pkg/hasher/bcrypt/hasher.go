@@ -2,23 +2,45 @@ package bcrypt
 
 import (
 	"context"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 
 	pHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher"
 )
 
-type hasher struct{}
+// Prefix is shared by the $2a$, $2b$ and $2y$ bcrypt versions.
+const Prefix = "$2"
+
+type hasher struct {
+	cost int
+}
 
 func New() pHasher.Hasher {
-	return &hasher{}
+	return NewWithCost(bcrypt.DefaultCost)
+}
+
+// NewWithCost replaces a cost out of the bcrypt bounds with the default one.
+func NewWithCost(cost int) pHasher.Hasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &hasher{cost: cost}
 }
 
 func (h *hasher) GetHashedPassword(_ context.Context, password string) (string, error) {
-	pswd, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	pswd, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
 	return string(pswd), err
 }
 
 func (h *hasher) CompareHashAndPassword(_ context.Context, hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+func (h *hasher) NeedsRehash(hashedPassword string) bool {
+	if !strings.HasPrefix(hashedPassword, Prefix) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	return err != nil || cost != h.cost
+}
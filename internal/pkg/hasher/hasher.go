@@ -5,4 +5,7 @@ import "context"
 type Hasher interface {
 	GetHashedPassword(ctx context.Context, password string) (string, error)
 	CompareHashAndPassword(ctx context.Context, hashedPassword, password string) error
+	// NeedsRehash reports whether the hash was made by another algorithm or
+	// with other parameters than new hashes are.
+	NeedsRehash(hashedPassword string) bool
 }
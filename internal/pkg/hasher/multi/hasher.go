@@ -0,0 +1,61 @@
+package multi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	pHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher"
+)
+
+var ErrUnknownFormat = errors.New("unknown password hash format")
+
+// Format is a hasher whose hashes start with Prefix.
+type Format struct {
+	Prefix string
+	Hasher pHasher.Hasher
+}
+
+// hasher lets hashes of several algorithms coexist: new hashes are made by
+// the current format, the others only verify passwords until rehashed.
+type hasher struct {
+	current Format
+	formats []Format
+}
+
+func New(current Format, legacy ...Format) pHasher.Hasher {
+	return &hasher{
+		current: current,
+		formats: append([]Format{current}, legacy...),
+	}
+}
+
+func (h *hasher) GetHashedPassword(ctx context.Context, password string) (string, error) {
+	return h.current.Hasher.GetHashedPassword(ctx, password)
+}
+
+func (h *hasher) CompareHashAndPassword(ctx context.Context, hashedPassword, password string) error {
+	format, ok := h.format(hashedPassword)
+	if !ok {
+		return ErrUnknownFormat
+	}
+	return format.Hasher.CompareHashAndPassword(ctx, hashedPassword, password)
+}
+
+func (h *hasher) NeedsRehash(hashedPassword string) bool {
+	format, ok := h.format(hashedPassword)
+	if !ok || format.Prefix != h.current.Prefix {
+		return true
+	}
+	return format.Hasher.NeedsRehash(hashedPassword)
+}
+
+func (h *hasher) format(hashedPassword string) (Format, bool) {
+	for _, format := range h.formats {
+		if strings.HasPrefix(hashedPassword, format.Prefix) {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
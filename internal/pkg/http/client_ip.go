@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// TrustedProxies are the load balancers allowed to report the client address
+// in X-Forwarded-For and X-Real-IP. These headers are ignored on connections
+// from other peers: clients can set them.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies accepts IP addresses and CIDR ranges.
+func ParseTrustedProxies(proxies []string) (TrustedProxies, error) {
+	trusted := make(TrustedProxies, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, errors.Errorf("bad trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad trusted proxy %q", proxy)
+		}
+		trusted = append(trusted, network)
+	}
+	return trusted, nil
+}
+
+func (p TrustedProxies) trusts(addr string) bool {
+	ip := net.ParseIP(strings.TrimSpace(addr))
+	if ip == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the peer address unless the peer is a trusted proxy. Then
+// X-Forwarded-For is read from the right, skipping other trusted proxies, and
+// X-Real-IP is used if it has no other address.
+func (p TrustedProxies) ClientIP(r *http.Request) string {
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+	if !p.trusts(peer) {
+		return peer
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr == "" || p.trusts(addr) {
+			continue
+		}
+		if net.ParseIP(addr) == nil {
+			break
+		}
+		return addr
+	}
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return peer
+}
@@ -54,10 +54,15 @@ func SendJSON(w http.ResponseWriter, r *http.Request, status int, dataStruct any
 		return
 	}
 
+	SendRawJSON(w, r, status, dataJSON)
+}
+
+// SendRawJSON writes already serialized JSON.
+func SendRawJSON(w http.ResponseWriter, r *http.Request, status int, dataJSON []byte) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
-	_, err = w.Write(dataJSON)
+	_, err := w.Write(dataJSON)
 	if err != nil {
 		HandleError(w, r, fmt.Errorf("failed to send : %w", err))
 		return
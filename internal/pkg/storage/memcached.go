@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// NewMemcachedClient creates a client without checking the connection.
+// Keys are distributed between MEMCACHED_ADDRS by the client.
+func NewMemcachedClient(log *zap.Logger) *memcache.Client {
+	addrs := viper.GetStringSlice(config.MemcachedAddrs)
+	log.Info("Memcached connecting...", zap.Strings("addrs", addrs))
+
+	client := memcache.New(addrs...)
+	client.Timeout = viper.GetDuration(config.MemcachedTimeout)
+	client.MaxIdleConns = viper.GetInt(config.MemcachedMaxIdleConns)
+	return client
+}
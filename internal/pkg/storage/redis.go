@@ -1,7 +1,6 @@
 package storage
 
 import (
-	"context"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
 
 	"github.com/redis/go-redis/v9"
@@ -9,24 +8,47 @@ import (
 	"go.uber.org/zap"
 )
 
-func NewRedis(log *zap.Logger, ctx context.Context) (*redis.Client, error) {
-	log.Info("Redis connecting...",
-		zap.String("host", viper.GetString(config.RedisHost)),
-		zap.String("port", viper.GetString(config.RedisPort)),
-		zap.Int("db", viper.GetInt(config.RedisDB)),
-	)
-
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     viper.GetString(config.RedisHost) + ":" + viper.GetString(config.RedisPort),
-		Password: viper.GetString(config.RedisPassword),
-		DB:       viper.GetInt(config.RedisDB),
-	})
-
-	if err := rdb.Ping(ctx).Err(); err != nil {
-		log.Error("Failed to create Redis connection, ", zap.Error(err))
-		return nil, err
+// NewRedisClient creates a standalone, sentinel or cluster client depending on
+// REDIS_MODE without checking the connection. The client connects lazily and
+// reconnects on its own, so it may be created while Redis is down.
+func NewRedisClient(log *zap.Logger) redis.UniversalClient {
+	opts := &redis.UniversalOptions{
+		Username:     viper.GetString(config.RedisUser),
+		Password:     viper.GetString(config.RedisPassword),
+		DB:           viper.GetInt(config.RedisDB),
+		DialTimeout:  viper.GetDuration(config.RedisDialTimeout),
+		ReadTimeout:  viper.GetDuration(config.RedisReadTimeout),
+		WriteTimeout: viper.GetDuration(config.RedisWriteTimeout),
+		MaxRetries:   viper.GetInt(config.RedisMaxRetries),
 	}
 
-	log.Info("Redis connected")
-	return rdb, nil
+	switch mode := viper.GetString(config.RedisMode); mode {
+	case config.RedisModeSentinel:
+		opts.MasterName = viper.GetString(config.RedisMasterName)
+		opts.Addrs = viper.GetStringSlice(config.RedisSentinelAddrs)
+		opts.SentinelPassword = viper.GetString(config.RedisSentinelPassword)
+		log.Info("Redis connecting...",
+			zap.String("mode", mode),
+			zap.String("master", opts.MasterName),
+			zap.Strings("sentinels", opts.Addrs),
+			zap.Int("db", opts.DB),
+		)
+		return redis.NewFailoverClient(opts.Failover())
+	case config.RedisModeCluster:
+		opts.Addrs = viper.GetStringSlice(config.RedisClusterAddrs)
+		log.Info("Redis connecting...",
+			zap.String("mode", mode),
+			zap.Strings("addrs", opts.Addrs),
+		)
+		return redis.NewClusterClient(opts.Cluster())
+	default:
+		opts.Addrs = []string{viper.GetString(config.RedisHost) + ":" + viper.GetString(config.RedisPort)}
+		log.Info("Redis connecting...",
+			zap.String("mode", config.RedisModeStandalone),
+			zap.String("host", viper.GetString(config.RedisHost)),
+			zap.String("port", viper.GetString(config.RedisPort)),
+			zap.Int("db", opts.DB),
+		)
+		return redis.NewClient(opts.Simple())
+	}
 }
@@ -0,0 +1,21 @@
+package resettoken
+
+import (
+	"context"
+	"time"
+)
+
+type CreateParams struct {
+	UserID    int64
+	TokenHash string
+	ExpiresAt time.Time
+}
+
+type Repository interface {
+	// Create stores a password reset token and invalidates the previous
+	// tokens of the user.
+	Create(ctx context.Context, params *CreateParams) error
+	// Use deletes the token and returns the ids of its user and tenant. Unknown
+	// and expired tokens are rejected with ErrInvalidResetToken.
+	Use(ctx context.Context, tokenHash string) (userID, tenantID int64, err error)
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pResetToken "github.com/SlavaShagalov/avito-intern-task/internal/resettoken"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"go.uber.org/zap"
+)
+
+type resetToken struct {
+	tenantID  int64
+	userID    int64
+	expiresAt time.Time
+}
+
+type repository struct {
+	mu     sync.Mutex
+	tokens map[string]resetToken
+	log    *zap.Logger
+}
+
+func New(log *zap.Logger) pResetToken.Repository {
+	return &repository{
+		tokens: make(map[string]resetToken),
+		log:    log,
+	}
+}
+
+func (repo *repository) Create(ctx context.Context, params *pResetToken.CreateParams) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for hash, token := range repo.tokens {
+		if token.userID == params.UserID {
+			delete(repo.tokens, hash)
+		}
+	}
+	repo.tokens[params.TokenHash] = resetToken{
+		tenantID:  tenant.ID(ctx),
+		userID:    params.UserID,
+		expiresAt: params.ExpiresAt,
+	}
+
+	repo.log.Debug("Reset token created", zap.Int64("user_id", params.UserID))
+	return nil
+}
+
+func (repo *repository) Use(_ context.Context, tokenHash string) (int64, int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	token, exists := repo.tokens[tokenHash]
+	if !exists || !time.Now().Before(token.expiresAt) {
+		return 0, 0, pErrors.ErrInvalidResetToken
+	}
+	delete(repo.tokens, tokenHash)
+
+	repo.log.Debug("Reset token used", zap.Int64("user_id", token.userID))
+	return token.userID, token.tenantID, nil
+}
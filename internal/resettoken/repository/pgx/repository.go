@@ -0,0 +1,64 @@
+package pgx
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pResetToken "github.com/SlavaShagalov/avito-intern-task/internal/resettoken"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	pool *pgxpool.Pool
+	log  *zap.Logger
+}
+
+func New(pool *pgxpool.Pool, log *zap.Logger) pResetToken.Repository {
+	return &repository{
+		pool: pool,
+		log:  log,
+	}
+}
+
+const createCmd = `
+	WITH deleted AS (
+	    DELETE FROM password_reset_tokens
+	    WHERE user_id = $1)
+	INSERT INTO password_reset_tokens (token_hash, tenant_id, user_id, expires_at)
+	VALUES ($2, $3, $1, $4);`
+
+func (repo *repository) Create(ctx context.Context, params *pResetToken.CreateParams) error {
+	_, err := repo.pool.Exec(ctx, createCmd, params.UserID, params.TokenHash, tenant.ID(ctx), params.ExpiresAt)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+
+	repo.log.Debug("Reset token created", zap.Int64("user_id", params.UserID))
+	return nil
+}
+
+const useCmd = `
+	DELETE FROM password_reset_tokens
+	WHERE token_hash = $1 AND expires_at > now()
+	RETURNING user_id, tenant_id;`
+
+func (repo *repository) Use(ctx context.Context, tokenHash string) (int64, int64, error) {
+	var userID, tenantID int64
+	err := repo.pool.QueryRow(ctx, useCmd, tokenHash).Scan(&userID, &tenantID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, pErrors.ErrInvalidResetToken
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return 0, 0, pErrors.ErrDb
+	}
+
+	repo.log.Debug("Reset token used", zap.Int64("user_id", userID))
+	return userID, tenantID, nil
+}
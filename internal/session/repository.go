@@ -0,0 +1,32 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+)
+
+type CreateParams struct {
+	UserID           int64
+	RefreshTokenHash string
+	ExpiresAt        time.Time
+}
+
+type RotateParams struct {
+	RefreshTokenHash    string
+	NewRefreshTokenHash string
+	ExpiresAt           time.Time
+}
+
+type Repository interface {
+	// Create starts a session with its first refresh token.
+	Create(ctx context.Context, params *CreateParams) (*models.Session, error)
+	// Rotate marks the refresh token as used and stores its replacement. If the
+	// token has already been used, the session is returned with ErrRefreshTokenReused.
+	Rotate(ctx context.Context, params *RotateParams) (*models.Session, error)
+	Revoke(ctx context.Context, id int64) error
+	// RevokeByUser revokes active sessions of the user except the given one
+	// and returns their ids.
+	RevokeByUser(ctx context.Context, userID, exceptID int64) ([]int64, error)
+}
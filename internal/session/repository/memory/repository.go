@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pSession "github.com/SlavaShagalov/avito-intern-task/internal/session"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// DefaultSweepInterval bounds how often expired refresh tokens are dropped.
+const DefaultSweepInterval = time.Minute
+
+type refreshToken struct {
+	sessionID int64
+	expiresAt time.Time
+	used      bool
+}
+
+// repository drops expired refresh tokens, used ones included, and the
+// sessions left without tokens, so that its size is bounded by the sessions
+// refreshed within the refresh token lifetime. A reused expired token is
+// then reported as invalid, not as reused.
+type repository struct {
+	mu            sync.Mutex
+	lastID        int64
+	sessions      map[int64]models.Session
+	tokens        map[string]*refreshToken
+	sweepInterval time.Duration
+	lastSweep     time.Time
+	log           *zap.Logger
+}
+
+func New(log *zap.Logger) pSession.Repository {
+	return NewWithSweepInterval(DefaultSweepInterval, log)
+}
+
+func NewWithSweepInterval(sweepInterval time.Duration, log *zap.Logger) pSession.Repository {
+	return &repository{
+		sessions:      make(map[int64]models.Session),
+		tokens:        make(map[string]*refreshToken),
+		sweepInterval: sweepInterval,
+		lastSweep:     time.Now(),
+		log:           log,
+	}
+}
+
+func (repo *repository) Create(ctx context.Context, params *pSession.CreateParams) (*models.Session, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.sweep(time.Now())
+
+	repo.lastID++
+	session := models.Session{
+		ID:        repo.lastID,
+		TenantID:  tenant.ID(ctx),
+		UserID:    params.UserID,
+		CreatedAt: time.Now(),
+	}
+	repo.sessions[session.ID] = session
+	repo.tokens[params.RefreshTokenHash] = &refreshToken{
+		sessionID: session.ID,
+		expiresAt: params.ExpiresAt,
+	}
+
+	repo.log.Debug("Session created", zap.Int64("session_id", session.ID), zap.Int64("user_id", session.UserID))
+	return &session, nil
+}
+
+func (repo *repository) Rotate(_ context.Context, params *pSession.RotateParams) (*models.Session, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	repo.sweep(time.Now())
+
+	token, exists := repo.tokens[params.RefreshTokenHash]
+	if !exists {
+		return nil, pErrors.ErrInvalidRefreshToken
+	}
+	session := repo.sessions[token.sessionID]
+	switch {
+	case token.used:
+		return &session, pErrors.ErrRefreshTokenReused
+	case time.Now().After(token.expiresAt), session.RevokedAt != nil:
+		return nil, pErrors.ErrInvalidRefreshToken
+	}
+
+	token.used = true
+	repo.tokens[params.NewRefreshTokenHash] = &refreshToken{
+		sessionID: session.ID,
+		expiresAt: params.ExpiresAt,
+	}
+
+	repo.log.Debug("Refresh token rotated", zap.Int64("session_id", session.ID))
+	return &session, nil
+}
+
+func (repo *repository) Revoke(_ context.Context, id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	session, exists := repo.sessions[id]
+	if exists && session.RevokedAt == nil {
+		now := time.Now()
+		session.RevokedAt = &now
+		repo.sessions[id] = session
+	}
+
+	repo.log.Debug("Session revoked", zap.Int64("session_id", id))
+	return nil
+}
+
+func (repo *repository) RevokeByUser(ctx context.Context, userID, exceptID int64) ([]int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	ids := make([]int64, 0, 4)
+	now := time.Now()
+	for id, session := range repo.sessions {
+		if session.TenantID != tenant.ID(ctx) || session.UserID != userID || id == exceptID || session.RevokedAt != nil {
+			continue
+		}
+		session.RevokedAt = &now
+		repo.sessions[id] = session
+		ids = append(ids, id)
+	}
+
+	repo.log.Debug("User sessions revoked", zap.Int64("user_id", userID), zap.Int("count", len(ids)))
+	return ids, nil
+}
+
+// sweep runs at most once per sweepInterval, so that the cost is spread over
+// many calls.
+func (repo *repository) sweep(now time.Time) {
+	if now.Sub(repo.lastSweep) < repo.sweepInterval {
+		return
+	}
+	repo.lastSweep = now
+
+	live := make(map[int64]bool, len(repo.sessions))
+	for hash, token := range repo.tokens {
+		if now.After(token.expiresAt) {
+			delete(repo.tokens, hash)
+			continue
+		}
+		live[token.sessionID] = true
+	}
+	for id := range repo.sessions {
+		if !live[id] {
+			delete(repo.sessions, id)
+		}
+	}
+}
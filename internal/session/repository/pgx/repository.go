@@ -0,0 +1,176 @@
+package pgx
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pSession "github.com/SlavaShagalov/avito-intern-task/internal/session"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	pool *pgxpool.Pool
+	log  *zap.Logger
+}
+
+func New(pool *pgxpool.Pool, log *zap.Logger) pSession.Repository {
+	return &repository{
+		pool: pool,
+		log:  log,
+	}
+}
+
+const createSessionCmd = `
+	INSERT INTO sessions (tenant_id, user_id)
+	VALUES ($1, $2)
+	RETURNING id, tenant_id, user_id, created_at, revoked_at;`
+
+const createRefreshTokenCmd = `
+	INSERT INTO refresh_tokens (token_hash, tenant_id, session_id, expires_at)
+	VALUES ($1, $2, $3, $4);`
+
+func (repo *repository) Create(ctx context.Context, params *pSession.CreateParams) (*models.Session, error) {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	defer tx.Rollback(ctx) // nolint
+
+	session := new(models.Session)
+	err = tx.QueryRow(ctx, createSessionCmd, tenant.ID(ctx), params.UserID).Scan(
+		&session.ID,
+		&session.TenantID,
+		&session.UserID,
+		&session.CreatedAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	_, err = tx.Exec(ctx, createRefreshTokenCmd, params.RefreshTokenHash, session.TenantID, session.ID,
+		params.ExpiresAt)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	repo.log.Debug("Session created", zap.Int64("session_id", session.ID), zap.Int64("user_id", session.UserID))
+	return session, nil
+}
+
+const getRefreshTokenCmd = `
+	SELECT s.id, s.tenant_id, s.user_id, s.created_at, s.revoked_at, rt.expires_at < now(), rt.used_at IS NOT NULL
+	FROM refresh_tokens rt
+	         JOIN sessions s ON s.id = rt.session_id
+	WHERE rt.token_hash = $1
+	FOR UPDATE OF rt;`
+
+const useRefreshTokenCmd = `
+	UPDATE refresh_tokens
+	SET used_at = now()
+	WHERE token_hash = $1;`
+
+func (repo *repository) Rotate(ctx context.Context, params *pSession.RotateParams) (*models.Session, error) {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	defer tx.Rollback(ctx) // nolint
+
+	session := new(models.Session)
+	var expired, used bool
+	err = tx.QueryRow(ctx, getRefreshTokenCmd, params.RefreshTokenHash).Scan(
+		&session.ID,
+		&session.TenantID,
+		&session.UserID,
+		&session.CreatedAt,
+		&session.RevokedAt,
+		&expired,
+		&used,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pErrors.ErrInvalidRefreshToken
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	switch {
+	case used:
+		return session, pErrors.ErrRefreshTokenReused
+	case expired, session.RevokedAt != nil:
+		return nil, pErrors.ErrInvalidRefreshToken
+	}
+
+	if _, err = tx.Exec(ctx, useRefreshTokenCmd, params.RefreshTokenHash); err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	_, err = tx.Exec(ctx, createRefreshTokenCmd, params.NewRefreshTokenHash, session.TenantID, session.ID,
+		params.ExpiresAt)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	repo.log.Debug("Refresh token rotated", zap.Int64("session_id", session.ID))
+	return session, nil
+}
+
+const revokeCmd = `
+	UPDATE sessions
+	SET revoked_at = now()
+	WHERE id = $1 AND revoked_at IS NULL;`
+
+func (repo *repository) Revoke(ctx context.Context, id int64) error {
+	_, err := repo.pool.Exec(ctx, revokeCmd, id)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+
+	repo.log.Debug("Session revoked", zap.Int64("session_id", id))
+	return nil
+}
+
+const revokeByUserCmd = `
+	UPDATE sessions
+	SET revoked_at = now()
+	WHERE tenant_id = $1 AND user_id = $2 AND id <> $3 AND revoked_at IS NULL
+	RETURNING id;`
+
+func (repo *repository) RevokeByUser(ctx context.Context, userID, exceptID int64) ([]int64, error) {
+	rows, err := repo.pool.Query(ctx, revokeByUserCmd, tenant.ID(ctx), userID, exceptID)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	repo.log.Debug("User sessions revoked", zap.Int64("user_id", userID), zap.Int("count", len(ids)))
+	return ids, nil
+}
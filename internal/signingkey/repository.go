@@ -0,0 +1,14 @@
+package signingkey
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+)
+
+type Repository interface {
+	Create(ctx context.Context, key *models.SigningKey) error
+	// List returns unexpired keys, the newest first.
+	List(ctx context.Context) ([]models.SigningKey, error)
+	DeleteExpired(ctx context.Context) error
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pSigningKey "github.com/SlavaShagalov/avito-intern-task/internal/signingkey"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	mu   sync.Mutex
+	keys map[string]models.SigningKey
+	log  *zap.Logger
+}
+
+func New(log *zap.Logger) pSigningKey.Repository {
+	return &repository{
+		keys: make(map[string]models.SigningKey),
+		log:  log,
+	}
+}
+
+func (repo *repository) Create(_ context.Context, key *models.SigningKey) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.keys[key.ID] = *key
+
+	repo.log.Debug("Signing key created", zap.String("kid", key.ID))
+	return nil
+}
+
+func (repo *repository) List(_ context.Context) ([]models.SigningKey, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+	keys := make([]models.SigningKey, 0, len(repo.keys))
+	for _, key := range repo.keys {
+		if now.Before(key.ExpiresAt) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+func (repo *repository) DeleteExpired(_ context.Context) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	now := time.Now()
+	for id, key := range repo.keys {
+		if !now.Before(key.ExpiresAt) {
+			delete(repo.keys, id)
+			repo.log.Debug("Signing key deleted", zap.String("kid", id))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package pgx
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pSigningKey "github.com/SlavaShagalov/avito-intern-task/internal/signingkey"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	pool *pgxpool.Pool
+	log  *zap.Logger
+}
+
+func New(pool *pgxpool.Pool, log *zap.Logger) pSigningKey.Repository {
+	return &repository{
+		pool: pool,
+		log:  log,
+	}
+}
+
+const createCmd = `
+	INSERT INTO signing_keys (id, tenant_id, algorithm, private_key, created_at, expires_at)
+	VALUES ($1, $2, $3, $4, $5, $6);`
+
+func (repo *repository) Create(ctx context.Context, key *models.SigningKey) error {
+	_, err := repo.pool.Exec(ctx, createCmd, key.ID, key.TenantID, key.Algorithm, key.PrivateKey, key.CreatedAt, key.ExpiresAt)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+
+	repo.log.Debug("Signing key created", zap.String("kid", key.ID))
+	return nil
+}
+
+const listCmd = `
+	SELECT id, tenant_id, algorithm, private_key, created_at, expires_at
+	FROM signing_keys
+	WHERE expires_at > now()
+	ORDER BY created_at DESC;`
+
+func (repo *repository) List(ctx context.Context) ([]models.SigningKey, error) {
+	rows, err := repo.pool.Query(ctx, listCmd)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	keys, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.SigningKey, error) {
+		var key models.SigningKey
+		err := row.Scan(&key.ID, &key.TenantID, &key.Algorithm, &key.PrivateKey, &key.CreatedAt, &key.ExpiresAt)
+		return key, err
+	})
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return keys, nil
+}
+
+const deleteExpiredCmd = `
+	DELETE FROM signing_keys
+	WHERE expires_at <= now();`
+
+func (repo *repository) DeleteExpired(ctx context.Context) error {
+	res, err := repo.pool.Exec(ctx, deleteExpiredCmd)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+
+	if res.RowsAffected() > 0 {
+		repo.log.Debug("Signing keys deleted", zap.Int64("count", res.RowsAffected()))
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	pTeam "github.com/SlavaShagalov/avito-intern-task/internal/team"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type delivery struct {
+	uc  pTeam.Usecase
+	log *zap.Logger
+}
+
+func RegisterHandlers(mux *mux.Router, uc pTeam.Usecase, log *zap.Logger, checkAuth mw.Middleware, checkPermission mw.PermissionMiddleware) {
+	dlv := delivery{
+		uc:  uc,
+		log: log,
+	}
+
+	const (
+		teamsPath   = constants.ApiPrefix + "/teams"
+		teamPath    = teamsPath + "/{id}"
+		memberPath  = teamPath + "/members/{user_id}"
+		featurePath = teamPath + "/features/{feature_id}"
+	)
+
+	manage := checkPermission(models.PermissionTeamManage)
+	mux.HandleFunc(teamsPath, checkAuth(manage(dlv.create))).Methods(http.MethodPost)
+	mux.HandleFunc(teamsPath, checkAuth(manage(dlv.list))).Methods(http.MethodGet)
+	mux.HandleFunc(teamPath, checkAuth(manage(dlv.get))).Methods(http.MethodGet)
+	mux.HandleFunc(teamPath, checkAuth(manage(dlv.delete))).Methods(http.MethodDelete)
+	mux.HandleFunc(memberPath, checkAuth(manage(dlv.addMember))).Methods(http.MethodPut)
+	mux.HandleFunc(memberPath, checkAuth(manage(dlv.removeMember))).Methods(http.MethodDelete)
+	mux.HandleFunc(featurePath, checkAuth(manage(dlv.addFeature))).Methods(http.MethodPut)
+	mux.HandleFunc(featurePath, checkAuth(manage(dlv.removeFeature))).Methods(http.MethodDelete)
+}
+
+func (d *delivery) create(w http.ResponseWriter, r *http.Request) {
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request createRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	team, err := d.uc.Create(r.Context(), request.Name)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusCreated, newTeamResponse(team))
+}
+
+func (d *delivery) list(w http.ResponseWriter, r *http.Request) {
+	teams, err := d.uc.List(r.Context())
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusOK, newListResponse(teams))
+}
+
+func (d *delivery) get(w http.ResponseWriter, r *http.Request) {
+	teamID, err := pathID(r, "id", pErrors.ErrBadTeamIDParam)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	team, err := d.uc.Get(r.Context(), teamID)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusOK, newTeamResponse(team))
+}
+
+func (d *delivery) delete(w http.ResponseWriter, r *http.Request) {
+	teamID, err := pathID(r, "id", pErrors.ErrBadTeamIDParam)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	if err = d.uc.Delete(r.Context(), teamID); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (d *delivery) addMember(w http.ResponseWriter, r *http.Request) {
+	d.handleReference(w, r, "user_id", pErrors.ErrBadUserIDParam, d.uc.AddMember)
+}
+
+func (d *delivery) removeMember(w http.ResponseWriter, r *http.Request) {
+	d.handleReference(w, r, "user_id", pErrors.ErrBadUserIDParam, d.uc.RemoveMember)
+}
+
+func (d *delivery) addFeature(w http.ResponseWriter, r *http.Request) {
+	d.handleReference(w, r, "feature_id", pErrors.ErrBadFeatureIDParam, d.uc.AddFeature)
+}
+
+func (d *delivery) removeFeature(w http.ResponseWriter, r *http.Request) {
+	d.handleReference(w, r, "feature_id", pErrors.ErrBadFeatureIDParam, d.uc.RemoveFeature)
+}
+
+// handleReference parses the team id and the id of a team member or a
+// feature and applies action to them.
+func (d *delivery) handleReference(w http.ResponseWriter, r *http.Request, name string, badParam error,
+	action func(ctx context.Context, teamID, id int64) error) {
+	teamID, err := pathID(r, "id", pErrors.ErrBadTeamIDParam)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	id, err := pathID(r, name, badParam)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	if err = action(r.Context(), teamID, id); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathID(r *http.Request, name string, badParam error) (int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, badParam
+	}
+	return id, nil
+}
@@ -0,0 +1,38 @@
+package http
+
+import (
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"time"
+)
+
+// API requests
+type createRequest struct {
+	Name string `json:"name"`
+}
+
+// API responses
+type team struct {
+	ID         int64     `json:"team_id"`
+	Name       string    `json:"name"`
+	MemberIDs  []int64   `json:"member_ids"`
+	FeatureIDs []int64   `json:"feature_ids"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func newTeamResponse(t *models.Team) *team {
+	return &team{
+		ID:         t.ID,
+		Name:       t.Name,
+		MemberIDs:  t.MemberIDs,
+		FeatureIDs: t.FeatureIDs,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+func newListResponse(teams []models.Team) []team {
+	response := make([]team, 0, len(teams))
+	for i := range teams {
+		response = append(response, *newTeamResponse(&teams[i]))
+	}
+	return response
+}
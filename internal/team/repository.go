@@ -0,0 +1,23 @@
+package team
+
+import (
+	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+)
+
+type Repository interface {
+	Create(ctx context.Context, name string) (*models.Team, error)
+	List(ctx context.Context) ([]models.Team, error)
+	GetByID(ctx context.Context, id int64) (*models.Team, error)
+	Delete(ctx context.Context, id int64) error
+
+	AddMember(ctx context.Context, teamID, userID int64) error
+	RemoveMember(ctx context.Context, teamID, userID int64) error
+
+	// AddFeature makes the team the only owner of the feature.
+	AddFeature(ctx context.Context, teamID, featureID int64) error
+	RemoveFeature(ctx context.Context, teamID, featureID int64) error
+
+	// FeaturesByUser returns features owned by the teams of the user.
+	FeaturesByUser(ctx context.Context, userID int64) ([]int64, error)
+}
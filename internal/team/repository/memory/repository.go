@@ -0,0 +1,222 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pTeam "github.com/SlavaShagalov/avito-intern-task/internal/team"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"go.uber.org/zap"
+)
+
+type team struct {
+	tenantID  int64
+	name      string
+	members   map[int64]struct{}
+	createdAt time.Time
+}
+
+// Features are numbered per tenant.
+type featureKey struct {
+	tenantID  int64
+	featureID int64
+}
+
+// repository doesn't know users and features, so any ids are accepted.
+type repository struct {
+	mu     sync.RWMutex
+	lastID int64
+	teams  map[int64]*team
+	// owners maps a feature to the team owning it.
+	owners map[featureKey]int64
+	log    *zap.Logger
+}
+
+func New(log *zap.Logger) pTeam.Repository {
+	return &repository{
+		teams:  make(map[int64]*team),
+		owners: make(map[featureKey]int64),
+		log:    log,
+	}
+}
+
+func (repo *repository) Create(ctx context.Context, name string) (*models.Team, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	tenantID := tenant.ID(ctx)
+	for _, t := range repo.teams {
+		if t.tenantID == tenantID && t.name == name {
+			return nil, pErrors.ErrTeamAlreadyExists
+		}
+	}
+
+	repo.lastID++
+	repo.teams[repo.lastID] = &team{
+		tenantID:  tenantID,
+		name:      name,
+		members:   make(map[int64]struct{}),
+		createdAt: time.Now(),
+	}
+
+	repo.log.Debug("Team created", zap.Int64("team_id", repo.lastID))
+	return repo.model(repo.lastID), nil
+}
+
+func (repo *repository) List(ctx context.Context) ([]models.Team, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tenantID := tenant.ID(ctx)
+	ids := make([]int64, 0, len(repo.teams))
+	for id, t := range repo.teams {
+		if t.tenantID == tenantID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	teams := make([]models.Team, 0, len(ids))
+	for _, id := range ids {
+		teams = append(teams, *repo.model(id))
+	}
+	return teams, nil
+}
+
+func (repo *repository) GetByID(ctx context.Context, id int64) (*models.Team, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	if _, exists := repo.team(ctx, id); !exists {
+		return nil, pErrors.ErrTeamNotFound
+	}
+	return repo.model(id), nil
+}
+
+func (repo *repository) Delete(ctx context.Context, id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.team(ctx, id); !exists {
+		return pErrors.ErrTeamNotFound
+	}
+	for key, teamID := range repo.owners {
+		if teamID == id {
+			delete(repo.owners, key)
+		}
+	}
+	delete(repo.teams, id)
+
+	repo.log.Debug("Team deleted", zap.Int64("team_id", id))
+	return nil
+}
+
+func (repo *repository) AddMember(ctx context.Context, teamID, userID int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	t, exists := repo.team(ctx, teamID)
+	if !exists {
+		return pErrors.ErrTeamNotFound
+	}
+	t.members[userID] = struct{}{}
+
+	repo.log.Debug("Team member added", zap.Int64("team_id", teamID), zap.Int64("user_id", userID))
+	return nil
+}
+
+func (repo *repository) RemoveMember(ctx context.Context, teamID, userID int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	t, exists := repo.team(ctx, teamID)
+	if !exists {
+		return pErrors.ErrTeamMemberNotFound
+	}
+	if _, exists = t.members[userID]; !exists {
+		return pErrors.ErrTeamMemberNotFound
+	}
+	delete(t.members, userID)
+
+	repo.log.Debug("Team member removed", zap.Int64("team_id", teamID), zap.Int64("user_id", userID))
+	return nil
+}
+
+func (repo *repository) AddFeature(ctx context.Context, teamID, featureID int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.team(ctx, teamID); !exists {
+		return pErrors.ErrTeamNotFound
+	}
+	repo.owners[featureKey{tenantID: tenant.ID(ctx), featureID: featureID}] = teamID
+
+	repo.log.Debug("Team feature added", zap.Int64("team_id", teamID), zap.Int64("feature_id", featureID))
+	return nil
+}
+
+func (repo *repository) RemoveFeature(ctx context.Context, teamID, featureID int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.team(ctx, teamID); !exists {
+		return pErrors.ErrTeamFeatureNotFound
+	}
+	key := featureKey{tenantID: tenant.ID(ctx), featureID: featureID}
+	if owner, exists := repo.owners[key]; !exists || owner != teamID {
+		return pErrors.ErrTeamFeatureNotFound
+	}
+	delete(repo.owners, key)
+
+	repo.log.Debug("Team feature removed", zap.Int64("team_id", teamID), zap.Int64("feature_id", featureID))
+	return nil
+}
+
+func (repo *repository) FeaturesByUser(_ context.Context, userID int64) ([]int64, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	featureIDs := make([]int64, 0, 4)
+	for key, teamID := range repo.owners {
+		if _, member := repo.teams[teamID].members[userID]; member {
+			featureIDs = append(featureIDs, key.featureID)
+		}
+	}
+	sort.Slice(featureIDs, func(i, j int) bool { return featureIDs[i] < featureIDs[j] })
+	return featureIDs, nil
+}
+
+// team returns the team only if it belongs to the tenant of ctx.
+func (repo *repository) team(ctx context.Context, id int64) (*team, bool) {
+	t, exists := repo.teams[id]
+	if !exists || t.tenantID != tenant.ID(ctx) {
+		return nil, false
+	}
+	return t, true
+}
+
+func (repo *repository) model(id int64) *models.Team {
+	t := repo.teams[id]
+	team := &models.Team{
+		ID:         id,
+		Name:       t.name,
+		MemberIDs:  make([]int64, 0, len(t.members)),
+		FeatureIDs: make([]int64, 0, 4),
+		CreatedAt:  t.createdAt,
+	}
+	for userID := range t.members {
+		team.MemberIDs = append(team.MemberIDs, userID)
+	}
+	for key, teamID := range repo.owners {
+		if teamID == id {
+			team.FeatureIDs = append(team.FeatureIDs, key.featureID)
+		}
+	}
+	sort.Slice(team.MemberIDs, func(i, j int) bool { return team.MemberIDs[i] < team.MemberIDs[j] })
+	sort.Slice(team.FeatureIDs, func(i, j int) bool { return team.FeatureIDs[i] < team.FeatureIDs[j] })
+	return team
+}
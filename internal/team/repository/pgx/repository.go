@@ -0,0 +1,233 @@
+package pgx
+
+import (
+	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pTeam "github.com/SlavaShagalov/avito-intern-task/internal/team"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// repository works with teams of the tenant of ctx. Composite foreign keys
+// keep members and features in the tenant of their team.
+type repository struct {
+	pool *pgxpool.Pool
+	log  *zap.Logger
+}
+
+func New(pool *pgxpool.Pool, log *zap.Logger) pTeam.Repository {
+	return &repository{
+		pool: pool,
+		log:  log,
+	}
+}
+
+const createCmd = `
+	INSERT INTO teams (tenant_id, name)
+	VALUES ($1, $2)
+	RETURNING id, name, created_at;`
+
+func (repo *repository) Create(ctx context.Context, name string) (*models.Team, error) {
+	team := &models.Team{MemberIDs: []int64{}, FeatureIDs: []int64{}}
+	err := repo.pool.QueryRow(ctx, createCmd, tenant.ID(ctx), name).Scan(&team.ID, &team.Name, &team.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, pErrors.ErrTeamAlreadyExists
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	repo.log.Debug("Team created", zap.Int64("team_id", team.ID))
+	return team, nil
+}
+
+const selectTeamsPart = `
+	SELECT t.id,
+	       t.name,
+	       ARRAY(SELECT user_id FROM team_members WHERE team_id = t.id ORDER BY user_id),
+	       ARRAY(SELECT feature_id FROM team_features WHERE team_id = t.id ORDER BY feature_id),
+	       t.created_at
+	FROM teams t`
+
+const listCmd = selectTeamsPart + `
+	WHERE t.tenant_id = $1
+	ORDER BY t.id;`
+
+func (repo *repository) List(ctx context.Context) ([]models.Team, error) {
+	rows, err := repo.pool.Query(ctx, listCmd, tenant.ID(ctx))
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	defer rows.Close()
+
+	teams := make([]models.Team, 0, 4)
+	for rows.Next() {
+		team, err := scanTeam(rows)
+		if err != nil {
+			repo.log.Error(constants.DBError, zap.Error(err))
+			return nil, pErrors.ErrDb
+		}
+		teams = append(teams, *team)
+	}
+	if err = rows.Err(); err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return teams, nil
+}
+
+const getByIDCmd = selectTeamsPart + `
+	WHERE t.id = $1
+	  AND t.tenant_id = $2;`
+
+func (repo *repository) GetByID(ctx context.Context, id int64) (*models.Team, error) {
+	team, err := scanTeam(repo.pool.QueryRow(ctx, getByIDCmd, id, tenant.ID(ctx)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pErrors.ErrTeamNotFound
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return team, nil
+}
+
+const deleteCmd = `
+	DELETE FROM teams
+	WHERE id = $1
+	  AND tenant_id = $2;`
+
+func (repo *repository) Delete(ctx context.Context, id int64) error {
+	res, err := repo.pool.Exec(ctx, deleteCmd, id, tenant.ID(ctx))
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if res.RowsAffected() == 0 {
+		return pErrors.ErrTeamNotFound
+	}
+	repo.log.Debug("Team deleted", zap.Int64("team_id", id))
+	return nil
+}
+
+const addMemberCmd = `
+	INSERT INTO team_members (team_id, user_id, tenant_id)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING;`
+
+func (repo *repository) AddMember(ctx context.Context, teamID, userID int64) error {
+	_, err := repo.pool.Exec(ctx, addMemberCmd, teamID, userID, tenant.ID(ctx))
+	if err != nil {
+		return repo.referenceError(err, pErrors.ErrUserNotFound)
+	}
+	repo.log.Debug("Team member added", zap.Int64("team_id", teamID), zap.Int64("user_id", userID))
+	return nil
+}
+
+const removeMemberCmd = `
+	DELETE FROM team_members
+	WHERE team_id = $1
+	  AND user_id = $2
+	  AND tenant_id = $3;`
+
+func (repo *repository) RemoveMember(ctx context.Context, teamID, userID int64) error {
+	res, err := repo.pool.Exec(ctx, removeMemberCmd, teamID, userID, tenant.ID(ctx))
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if res.RowsAffected() == 0 {
+		return pErrors.ErrTeamMemberNotFound
+	}
+	repo.log.Debug("Team member removed", zap.Int64("team_id", teamID), zap.Int64("user_id", userID))
+	return nil
+}
+
+const addFeatureCmd = `
+	INSERT INTO team_features (feature_id, team_id, tenant_id)
+	VALUES ($2, $1, $3)
+	ON CONFLICT (tenant_id, feature_id) DO UPDATE SET team_id = excluded.team_id;`
+
+func (repo *repository) AddFeature(ctx context.Context, teamID, featureID int64) error {
+	_, err := repo.pool.Exec(ctx, addFeatureCmd, teamID, featureID, tenant.ID(ctx))
+	if err != nil {
+		return repo.referenceError(err, pErrors.ErrFeatureNotFound)
+	}
+	repo.log.Debug("Team feature added", zap.Int64("team_id", teamID), zap.Int64("feature_id", featureID))
+	return nil
+}
+
+const removeFeatureCmd = `
+	DELETE FROM team_features
+	WHERE team_id = $1
+	  AND feature_id = $2
+	  AND tenant_id = $3;`
+
+func (repo *repository) RemoveFeature(ctx context.Context, teamID, featureID int64) error {
+	res, err := repo.pool.Exec(ctx, removeFeatureCmd, teamID, featureID, tenant.ID(ctx))
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if res.RowsAffected() == 0 {
+		return pErrors.ErrTeamFeatureNotFound
+	}
+	repo.log.Debug("Team feature removed", zap.Int64("team_id", teamID), zap.Int64("feature_id", featureID))
+	return nil
+}
+
+const featuresByUserCmd = `
+	SELECT tf.feature_id
+	FROM team_features tf
+	         JOIN team_members tm ON tm.team_id = tf.team_id
+	WHERE tm.user_id = $1
+	ORDER BY tf.feature_id;`
+
+func (repo *repository) FeaturesByUser(ctx context.Context, userID int64) ([]int64, error) {
+	rows, err := repo.pool.Query(ctx, featuresByUserCmd, userID)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	featureIDs, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return featureIDs, nil
+}
+
+// referenceError tells a missing team from a missing referenced row.
+func (repo *repository) referenceError(err error, referenceErr error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+		if pgErr.ConstraintName == "team_members_team_id_fkey" || pgErr.ConstraintName == "team_features_team_id_fkey" {
+			return pErrors.ErrTeamNotFound
+		}
+		return referenceErr
+	}
+	repo.log.Error(constants.DBError, zap.Error(err))
+	return pErrors.ErrDb
+}
+
+func scanTeam(row pgx.Row) (*models.Team, error) {
+	team := new(models.Team)
+	err := row.Scan(
+		&team.ID,
+		&team.Name,
+		&team.MemberIDs,
+		&team.FeatureIDs,
+		&team.CreatedAt,
+	)
+	return team, err
+}
@@ -0,0 +1,17 @@
+package team
+
+import (
+	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+)
+
+type Usecase interface {
+	Create(ctx context.Context, name string) (*models.Team, error)
+	List(ctx context.Context) ([]models.Team, error)
+	Get(ctx context.Context, id int64) (*models.Team, error)
+	Delete(ctx context.Context, id int64) error
+	AddMember(ctx context.Context, teamID, userID int64) error
+	RemoveMember(ctx context.Context, teamID, userID int64) error
+	AddFeature(ctx context.Context, teamID, featureID int64) error
+	RemoveFeature(ctx context.Context, teamID, featureID int64) error
+}
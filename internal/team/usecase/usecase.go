@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pTeam "github.com/SlavaShagalov/avito-intern-task/internal/team"
+	"go.uber.org/zap"
+)
+
+type usecase struct {
+	repo pTeam.Repository
+	log  *zap.Logger
+}
+
+func New(repo pTeam.Repository, log *zap.Logger) pTeam.Usecase {
+	return &usecase{
+		repo: repo,
+		log:  log,
+	}
+}
+
+func (uc *usecase) Create(ctx context.Context, name string) (*models.Team, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, pErrors.ErrBadNameField
+	}
+	return uc.repo.Create(ctx, name)
+}
+
+func (uc *usecase) List(ctx context.Context) ([]models.Team, error) {
+	return uc.repo.List(ctx)
+}
+
+func (uc *usecase) Get(ctx context.Context, id int64) (*models.Team, error) {
+	return uc.repo.GetByID(ctx, id)
+}
+
+func (uc *usecase) Delete(ctx context.Context, id int64) error {
+	return uc.repo.Delete(ctx, id)
+}
+
+func (uc *usecase) AddMember(ctx context.Context, teamID, userID int64) error {
+	return uc.repo.AddMember(ctx, teamID, userID)
+}
+
+func (uc *usecase) RemoveMember(ctx context.Context, teamID, userID int64) error {
+	return uc.repo.RemoveMember(ctx, teamID, userID)
+}
+
+func (uc *usecase) AddFeature(ctx context.Context, teamID, featureID int64) error {
+	return uc.repo.AddFeature(ctx, teamID, featureID)
+}
+
+func (uc *usecase) RemoveFeature(ctx context.Context, teamID, featureID int64) error {
+	return uc.repo.RemoveFeature(ctx, teamID, featureID)
+}
@@ -0,0 +1,37 @@
+package tenant
+
+import "context"
+
+// DefaultID is the tenant created by schema.sql. Requests which don't name a
+// tenant belong to it, and its super-admins provision other tenants.
+const DefaultID int64 = 1
+
+// allID is never a tenant id: tenants are numbered from 1.
+const allID int64 = 0
+
+type contextKey struct{}
+
+func WithID(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// WithAll lets background jobs, like the snapshot or the cache warm-up, read
+// the banners of all tenants at once.
+func WithAll(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, allID)
+}
+
+// ID returns the tenant of ctx, DefaultID if it isn't set and 0 for WithAll.
+func ID(ctx context.Context) int64 {
+	id, ok := ctx.Value(contextKey{}).(int64)
+	if !ok {
+		return DefaultID
+	}
+	return id
+}
+
+// Allows reports whether data of the tenant is visible in ctx.
+func Allows(ctx context.Context, tenantID int64) bool {
+	id := ID(ctx)
+	return id == allID || id == tenantID
+}
@@ -0,0 +1,124 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	pTenant "github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type delivery struct {
+	uc  pTenant.Usecase
+	log *zap.Logger
+}
+
+func RegisterHandlers(mux *mux.Router, uc pTenant.Usecase, log *zap.Logger, checkAuth mw.Middleware, checkPermission mw.PermissionMiddleware) {
+	dlv := delivery{
+		uc:  uc,
+		log: log,
+	}
+
+	const (
+		tenantsPath = constants.ApiPrefix + "/tenants"
+		tenantPath  = tenantsPath + "/{id}"
+	)
+
+	manage := func(h http.HandlerFunc) http.HandlerFunc {
+		return checkPermission(models.PermissionTenantManage)(defaultTenantOnly(h))
+	}
+	mux.HandleFunc(tenantsPath, checkAuth(manage(dlv.create))).Methods(http.MethodPost)
+	mux.HandleFunc(tenantsPath, checkAuth(manage(dlv.list))).Methods(http.MethodGet)
+	mux.HandleFunc(tenantPath, checkAuth(manage(dlv.get))).Methods(http.MethodGet)
+	mux.HandleFunc(tenantPath, checkAuth(manage(dlv.delete))).Methods(http.MethodDelete)
+}
+
+// defaultTenantOnly keeps super-admins of other tenants away from tenants
+// they don't belong to.
+func defaultTenantOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if pTenant.ID(r.Context()) != pTenant.DefaultID {
+			pHTTP.HandleError(w, r, pErrors.ErrPermissionDenied)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func (d *delivery) create(w http.ResponseWriter, r *http.Request) {
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request createRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	tenant, admin, err := d.uc.Create(r.Context(), &pTenant.CreateParams{
+		Name:          request.Name,
+		AdminUsername: request.AdminUsername,
+		AdminPassword: request.AdminPassword,
+	})
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusCreated, newCreateResponse(tenant, admin))
+}
+
+func (d *delivery) list(w http.ResponseWriter, r *http.Request) {
+	tenants, err := d.uc.List(r.Context())
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusOK, newListResponse(tenants))
+}
+
+func (d *delivery) get(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := pathID(r)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	tenant, err := d.uc.Get(r.Context(), tenantID)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusOK, newTenantResponse(tenant))
+}
+
+func (d *delivery) delete(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := pathID(r)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	if err = d.uc.Delete(r.Context(), tenantID); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, pErrors.ErrBadTenantIDParam
+	}
+	return id, nil
+}
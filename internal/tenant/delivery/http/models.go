@@ -0,0 +1,58 @@
+package http
+
+import (
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"time"
+)
+
+// API requests
+type createRequest struct {
+	Name          string `json:"name"`
+	AdminUsername string `json:"admin_username"`
+	AdminPassword string `json:"admin_password"`
+}
+
+// API responses
+type tenant struct {
+	ID        int64     `json:"tenant_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type admin struct {
+	ID       int64  `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+type createResponse struct {
+	tenant
+	Admin admin `json:"admin"`
+}
+
+func newTenantResponse(t *models.Tenant) *tenant {
+	return &tenant{
+		ID:        t.ID,
+		Name:      t.Name,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+func newCreateResponse(t *models.Tenant, u *models.User) *createResponse {
+	return &createResponse{
+		tenant: *newTenantResponse(t),
+		Admin: admin{
+			ID:       u.ID,
+			Username: u.Username,
+			Role:     u.Role,
+		},
+	}
+}
+
+func newListResponse(tenants []models.Tenant) []tenant {
+	response := make([]tenant, 0, len(tenants))
+	for i := range tenants {
+		response = append(response, *newTenantResponse(&tenants[i]))
+	}
+	return response
+}
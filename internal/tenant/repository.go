@@ -0,0 +1,14 @@
+package tenant
+
+import (
+	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+)
+
+type Repository interface {
+	Create(ctx context.Context, name string) (*models.Tenant, error)
+	List(ctx context.Context) ([]models.Tenant, error)
+	GetByID(ctx context.Context, id int64) (*models.Tenant, error)
+	// Delete removes the tenant with all its data.
+	Delete(ctx context.Context, id int64) error
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pTenant "github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// repository starts with the default tenant, like the Postgres schema. Unlike
+// Postgres, it doesn't delete the data of a deleted tenant kept by other
+// in-memory repositories.
+type repository struct {
+	mu      sync.RWMutex
+	lastID  int64
+	tenants map[int64]*models.Tenant
+	log     *zap.Logger
+}
+
+func New(log *zap.Logger) pTenant.Repository {
+	return &repository{
+		lastID: pTenant.DefaultID,
+		tenants: map[int64]*models.Tenant{
+			pTenant.DefaultID: {ID: pTenant.DefaultID, Name: "default", CreatedAt: time.Now()},
+		},
+		log: log,
+	}
+}
+
+func (repo *repository) Create(_ context.Context, name string) (*models.Tenant, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for _, t := range repo.tenants {
+		if t.Name == name {
+			return nil, pErrors.ErrTenantAlreadyExists
+		}
+	}
+
+	repo.lastID++
+	tenant := &models.Tenant{ID: repo.lastID, Name: name, CreatedAt: time.Now()}
+	repo.tenants[tenant.ID] = tenant
+
+	repo.log.Debug("Tenant created", zap.Int64("tenant_id", tenant.ID))
+	copied := *tenant
+	return &copied, nil
+}
+
+func (repo *repository) List(_ context.Context) ([]models.Tenant, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	tenants := make([]models.Tenant, 0, len(repo.tenants))
+	for _, t := range repo.tenants {
+		tenants = append(tenants, *t)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants, nil
+}
+
+func (repo *repository) GetByID(_ context.Context, id int64) (*models.Tenant, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	t, exists := repo.tenants[id]
+	if !exists {
+		return nil, pErrors.ErrTenantNotFound
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (repo *repository) Delete(_ context.Context, id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.tenants[id]; !exists {
+		return pErrors.ErrTenantNotFound
+	}
+	delete(repo.tenants, id)
+
+	repo.log.Debug("Tenant deleted", zap.Int64("tenant_id", id))
+	return nil
+}
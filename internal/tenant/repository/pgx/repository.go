@@ -0,0 +1,162 @@
+package pgx
+
+import (
+	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pTenant "github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	pool *pgxpool.Pool
+	log  *zap.Logger
+}
+
+func New(pool *pgxpool.Pool, log *zap.Logger) pTenant.Repository {
+	return &repository{
+		pool: pool,
+		log:  log,
+	}
+}
+
+const createCmd = `
+	INSERT INTO tenants (name)
+	VALUES ($1)
+	RETURNING id, name, created_at;`
+
+// Roles, features and tags of a new tenant start as copies of the ones of the
+// default tenant. Features and tags keep their ids, which are per tenant.
+const copyRolesCmd = `
+	INSERT INTO roles (tenant_id, name)
+	SELECT $1, name
+	FROM roles
+	WHERE tenant_id = $2;`
+
+const copyRolePermissionsCmd = `
+	INSERT INTO role_permissions (tenant_id, role, permission)
+	SELECT $1, role, permission
+	FROM role_permissions
+	WHERE tenant_id = $2;`
+
+const copyFeaturesCmd = `
+	INSERT INTO features (tenant_id, id, name)
+	SELECT $1, id, name
+	FROM features
+	WHERE tenant_id = $2;`
+
+const copyTagsCmd = `
+	INSERT INTO tags (tenant_id, id, name)
+	SELECT $1, id, name
+	FROM tags
+	WHERE tenant_id = $2;`
+
+func (repo *repository) Create(ctx context.Context, name string) (*models.Tenant, error) {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	defer tx.Rollback(ctx) // nolint
+
+	tenant, err := scanTenant(tx.QueryRow(ctx, createCmd, name))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return nil, pErrors.ErrTenantAlreadyExists
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	for _, cmd := range []string{copyRolesCmd, copyRolePermissionsCmd, copyFeaturesCmd, copyTagsCmd} {
+		if _, err = tx.Exec(ctx, cmd, tenant.ID, pTenant.DefaultID); err != nil {
+			repo.log.Error(constants.DBError, zap.Error(err))
+			return nil, pErrors.ErrDb
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	repo.log.Debug("Tenant created", zap.Int64("tenant_id", tenant.ID))
+	return tenant, nil
+}
+
+const listCmd = `
+	SELECT id, name, created_at
+	FROM tenants
+	ORDER BY id;`
+
+func (repo *repository) List(ctx context.Context) ([]models.Tenant, error) {
+	rows, err := repo.pool.Query(ctx, listCmd)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	defer rows.Close()
+
+	tenants := make([]models.Tenant, 0, 4)
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			repo.log.Error(constants.DBError, zap.Error(err))
+			return nil, pErrors.ErrDb
+		}
+		tenants = append(tenants, *tenant)
+	}
+	if err = rows.Err(); err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return tenants, nil
+}
+
+const getByIDCmd = `
+	SELECT id, name, created_at
+	FROM tenants
+	WHERE id = $1;`
+
+func (repo *repository) GetByID(ctx context.Context, id int64) (*models.Tenant, error) {
+	tenant, err := scanTenant(repo.pool.QueryRow(ctx, getByIDCmd, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pErrors.ErrTenantNotFound
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return tenant, nil
+}
+
+// The data of the tenant is removed by cascades.
+const deleteCmd = `
+	DELETE FROM tenants
+	WHERE id = $1;`
+
+func (repo *repository) Delete(ctx context.Context, id int64) error {
+	res, err := repo.pool.Exec(ctx, deleteCmd, id)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if res.RowsAffected() == 0 {
+		return pErrors.ErrTenantNotFound
+	}
+	repo.log.Debug("Tenant deleted", zap.Int64("tenant_id", id))
+	return nil
+}
+
+func scanTenant(row pgx.Row) (*models.Tenant, error) {
+	tenant := new(models.Tenant)
+	err := row.Scan(&tenant.ID, &tenant.Name, &tenant.CreatedAt)
+	return tenant, err
+}
@@ -0,0 +1,21 @@
+package tenant
+
+import (
+	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+)
+
+type CreateParams struct {
+	Name string
+	// The first super-admin of the tenant, who signs up other users.
+	AdminUsername string
+	AdminPassword string
+}
+
+type Usecase interface {
+	Create(ctx context.Context, params *CreateParams) (*models.Tenant, *models.User, error)
+	List(ctx context.Context) ([]models.Tenant, error)
+	Get(ctx context.Context, id int64) (*models.Tenant, error)
+	// Delete refuses to remove the default tenant.
+	Delete(ctx context.Context, id int64) error
+}
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/policy"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher"
+	pTenant "github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	pUser "github.com/SlavaShagalov/avito-intern-task/internal/user"
+	"go.uber.org/zap"
+)
+
+type usecase struct {
+	repo        pTenant.Repository
+	usersRepo   pUser.Repository
+	credentials *policy.Policy
+	hasher      pHasher.Hasher
+	keys        *keyring.Keyring
+	log         *zap.Logger
+}
+
+func New(repo pTenant.Repository, usersRepo pUser.Repository, credentials *policy.Policy, hasher pHasher.Hasher,
+	keys *keyring.Keyring, log *zap.Logger) pTenant.Usecase {
+	return &usecase{
+		repo:        repo,
+		usersRepo:   usersRepo,
+		credentials: credentials,
+		hasher:      hasher,
+		keys:        keys,
+		log:         log,
+	}
+}
+
+func (uc *usecase) Create(ctx context.Context, params *pTenant.CreateParams) (*models.Tenant, *models.User, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return nil, nil, pErrors.ErrBadNameField
+	}
+	if err := uc.credentials.ValidateUsername(params.AdminUsername); err != nil {
+		return nil, nil, err
+	}
+	if err := uc.credentials.ValidatePassword(params.AdminPassword); err != nil {
+		return nil, nil, err
+	}
+	hashedPassword, err := uc.hasher.GetHashedPassword(ctx, params.AdminPassword)
+	if err != nil {
+		return nil, nil, pErrors.ErrGetHashedPassword
+	}
+
+	tenant, err := uc.repo.Create(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	admin, err := uc.usersRepo.Create(pTenant.WithID(ctx, tenant.ID), &pUser.CreateParams{
+		Username: params.AdminUsername,
+		Password: hashedPassword,
+		Role:     models.RoleSuperAdmin,
+	})
+	if err != nil {
+		// A tenant nobody can sign in to is useless.
+		if delErr := uc.repo.Delete(ctx, tenant.ID); delErr != nil {
+			uc.log.Error("Failed to delete tenant without admin", zap.Int64("tenant_id", tenant.ID),
+				zap.Error(delErr))
+		}
+		return nil, nil, err
+	}
+	// Tokens of the tenant are signed with its own keys. Until they are
+	// created, on the next rotation otherwise, its users can't sign in.
+	if err = uc.keys.Rotate(ctx); err != nil {
+		uc.log.Warn("Failed to create signing keys of tenant", zap.Int64("tenant_id", tenant.ID), zap.Error(err))
+	}
+
+	uc.log.Info("Tenant created", zap.Int64("tenant_id", tenant.ID), zap.String("name", tenant.Name),
+		zap.Int64("admin_id", admin.ID))
+	return tenant, admin, nil
+}
+
+func (uc *usecase) List(ctx context.Context) ([]models.Tenant, error) {
+	return uc.repo.List(ctx)
+}
+
+func (uc *usecase) Get(ctx context.Context, id int64) (*models.Tenant, error) {
+	return uc.repo.GetByID(ctx, id)
+}
+
+// Delete leaves cached banners of the tenant to expire: they can't be read
+// again because tenant ids aren't reused.
+func (uc *usecase) Delete(ctx context.Context, id int64) error {
+	if id == pTenant.DefaultID {
+		return pErrors.ErrDefaultTenant
+	}
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	uc.log.Info("Tenant deleted", zap.Int64("tenant_id", id))
+	return nil
+}
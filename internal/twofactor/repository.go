@@ -0,0 +1,32 @@
+package twofactor
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+)
+
+type CreateParams struct {
+	UserID             int64
+	Secret             string
+	RecoveryCodeHashes []string
+}
+
+type Repository interface {
+	// Create stores a pending factor with its recovery codes. A pending factor
+	// of the user is replaced, a confirmed one is kept and
+	// ErrTwoFactorAlreadyEnabled is returned.
+	Create(ctx context.Context, params *CreateParams) error
+	// Get returns ErrTwoFactorNotEnrolled if the user has no factor.
+	Get(ctx context.Context, userID int64) (*models.TwoFactor, error)
+	Confirm(ctx context.Context, userID int64) error
+	// UseStep stores the time step of an accepted code. Steps up to the last
+	// used one are rejected with ErrInvalidTwoFactorCode, so that a code
+	// can't be replayed.
+	UseStep(ctx context.Context, userID, step int64) error
+	// UseRecoveryCode deletes the code. Unknown codes are rejected with
+	// ErrInvalidTwoFactorCode.
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error
+	// Delete removes the factor and its recovery codes.
+	Delete(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	pTwoFactor "github.com/SlavaShagalov/avito-intern-task/internal/twofactor"
+	"go.uber.org/zap"
+)
+
+type twoFactor struct {
+	tenantID      int64
+	factor        models.TwoFactor
+	recoveryCodes map[string]struct{}
+}
+
+type repository struct {
+	mu      sync.Mutex
+	factors map[int64]*twoFactor
+	log     *zap.Logger
+}
+
+func New(log *zap.Logger) pTwoFactor.Repository {
+	return &repository{
+		factors: make(map[int64]*twoFactor),
+		log:     log,
+	}
+}
+
+func (repo *repository) Create(ctx context.Context, params *pTwoFactor.CreateParams) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if f, exists := repo.factor(ctx, params.UserID); exists && f.factor.IsConfirmed() {
+		return pErrors.ErrTwoFactorAlreadyEnabled
+	}
+
+	recoveryCodes := make(map[string]struct{}, len(params.RecoveryCodeHashes))
+	for _, hash := range params.RecoveryCodeHashes {
+		recoveryCodes[hash] = struct{}{}
+	}
+	repo.factors[params.UserID] = &twoFactor{
+		tenantID: tenant.ID(ctx),
+		factor: models.TwoFactor{
+			UserID:    params.UserID,
+			Secret:    params.Secret,
+			CreatedAt: time.Now(),
+		},
+		recoveryCodes: recoveryCodes,
+	}
+
+	repo.log.Debug("Two-factor created", zap.Int64("user_id", params.UserID))
+	return nil
+}
+
+func (repo *repository) Get(ctx context.Context, userID int64) (*models.TwoFactor, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	f, exists := repo.factor(ctx, userID)
+	if !exists {
+		return nil, pErrors.ErrTwoFactorNotEnrolled
+	}
+	factor := f.factor
+	return &factor, nil
+}
+
+func (repo *repository) Confirm(ctx context.Context, userID int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	f, exists := repo.factor(ctx, userID)
+	if !exists {
+		return pErrors.ErrTwoFactorNotEnrolled
+	}
+	if !f.factor.IsConfirmed() {
+		now := time.Now()
+		f.factor.ConfirmedAt = &now
+	}
+
+	repo.log.Debug("Two-factor confirmed", zap.Int64("user_id", userID))
+	return nil
+}
+
+func (repo *repository) UseStep(ctx context.Context, userID, step int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	f, exists := repo.factor(ctx, userID)
+	if !exists {
+		return pErrors.ErrTwoFactorNotEnrolled
+	}
+	if step <= f.factor.LastUsedStep {
+		return pErrors.ErrInvalidTwoFactorCode
+	}
+	f.factor.LastUsedStep = step
+	return nil
+}
+
+func (repo *repository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	f, exists := repo.factor(ctx, userID)
+	if !exists {
+		return pErrors.ErrTwoFactorNotEnrolled
+	}
+	if _, exists = f.recoveryCodes[codeHash]; !exists {
+		return pErrors.ErrInvalidTwoFactorCode
+	}
+	delete(f.recoveryCodes, codeHash)
+
+	repo.log.Debug("Recovery code used", zap.Int64("user_id", userID), zap.Int("left", len(f.recoveryCodes)))
+	return nil
+}
+
+func (repo *repository) Delete(ctx context.Context, userID int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, exists := repo.factor(ctx, userID); exists {
+		delete(repo.factors, userID)
+	}
+
+	repo.log.Debug("Two-factor deleted", zap.Int64("user_id", userID))
+	return nil
+}
+
+func (repo *repository) factor(ctx context.Context, userID int64) (*twoFactor, bool) {
+	f, exists := repo.factors[userID]
+	if !exists || f.tenantID != tenant.ID(ctx) {
+		return nil, false
+	}
+	return f, true
+}
@@ -0,0 +1,174 @@
+package pgx
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	pTwoFactor "github.com/SlavaShagalov/avito-intern-task/internal/twofactor"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type repository struct {
+	pool *pgxpool.Pool
+	log  *zap.Logger
+}
+
+func New(pool *pgxpool.Pool, log *zap.Logger) pTwoFactor.Repository {
+	return &repository{
+		pool: pool,
+		log:  log,
+	}
+}
+
+// Recovery codes of the pending factor are deleted with it.
+const deletePendingCmd = `
+	DELETE FROM two_factor
+	WHERE tenant_id = $1 AND user_id = $2 AND confirmed_at IS NULL;`
+
+const createCmd = `
+	INSERT INTO two_factor (tenant_id, user_id, secret)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO NOTHING;`
+
+const createRecoveryCodesCmd = `
+	INSERT INTO two_factor_recovery_codes (user_id, code_hash)
+	SELECT $1, unnest($2::text[]);`
+
+func (repo *repository) Create(ctx context.Context, params *pTwoFactor.CreateParams) error {
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	defer tx.Rollback(ctx) // nolint
+
+	_, err = tx.Exec(ctx, deletePendingCmd, tenant.ID(ctx), params.UserID)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+
+	// Only a confirmed factor is left to conflict with.
+	tag, err := tx.Exec(ctx, createCmd, tenant.ID(ctx), params.UserID, params.Secret)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if tag.RowsAffected() == 0 {
+		return pErrors.ErrTwoFactorAlreadyEnabled
+	}
+
+	_, err = tx.Exec(ctx, createRecoveryCodesCmd, params.UserID, params.RecoveryCodeHashes)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+
+	repo.log.Debug("Two-factor created", zap.Int64("user_id", params.UserID))
+	return nil
+}
+
+const getCmd = `
+	SELECT user_id, secret, confirmed_at, last_used_step, created_at
+	FROM two_factor
+	WHERE tenant_id = $1 AND user_id = $2;`
+
+func (repo *repository) Get(ctx context.Context, userID int64) (*models.TwoFactor, error) {
+	factor := new(models.TwoFactor)
+	err := repo.pool.QueryRow(ctx, getCmd, tenant.ID(ctx), userID).Scan(
+		&factor.UserID,
+		&factor.Secret,
+		&factor.ConfirmedAt,
+		&factor.LastUsedStep,
+		&factor.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pErrors.ErrTwoFactorNotEnrolled
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return factor, nil
+}
+
+const confirmCmd = `
+	UPDATE two_factor
+	SET confirmed_at = COALESCE(confirmed_at, now())
+	WHERE tenant_id = $1 AND user_id = $2;`
+
+func (repo *repository) Confirm(ctx context.Context, userID int64) error {
+	tag, err := repo.pool.Exec(ctx, confirmCmd, tenant.ID(ctx), userID)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if tag.RowsAffected() == 0 {
+		return pErrors.ErrTwoFactorNotEnrolled
+	}
+
+	repo.log.Debug("Two-factor confirmed", zap.Int64("user_id", userID))
+	return nil
+}
+
+const useStepCmd = `
+	UPDATE two_factor
+	SET last_used_step = $3
+	WHERE tenant_id = $1 AND user_id = $2 AND last_used_step < $3;`
+
+func (repo *repository) UseStep(ctx context.Context, userID, step int64) error {
+	tag, err := repo.pool.Exec(ctx, useStepCmd, tenant.ID(ctx), userID, step)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if tag.RowsAffected() == 0 {
+		return pErrors.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+const useRecoveryCodeCmd = `
+	DELETE FROM two_factor_recovery_codes rc
+	USING two_factor f
+	WHERE f.user_id = rc.user_id AND f.tenant_id = $1 AND rc.user_id = $2 AND rc.code_hash = $3;`
+
+func (repo *repository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) error {
+	tag, err := repo.pool.Exec(ctx, useRecoveryCodeCmd, tenant.ID(ctx), userID, codeHash)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if tag.RowsAffected() == 0 {
+		return pErrors.ErrInvalidTwoFactorCode
+	}
+
+	repo.log.Debug("Recovery code used", zap.Int64("user_id", userID))
+	return nil
+}
+
+const deleteCmd = `
+	DELETE FROM two_factor
+	WHERE tenant_id = $1 AND user_id = $2;`
+
+func (repo *repository) Delete(ctx context.Context, userID int64) error {
+	_, err := repo.pool.Exec(ctx, deleteCmd, tenant.ID(ctx), userID)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+
+	repo.log.Debug("Two-factor deleted", zap.Int64("user_id", userID))
+	return nil
+}
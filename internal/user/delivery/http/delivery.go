@@ -0,0 +1,194 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	pUser "github.com/SlavaShagalov/avito-intern-task/internal/user"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+const (
+	QueryKey  = "query"
+	RoleKey   = "role"
+	LimitKey  = "limit"
+	OffsetKey = "offset"
+)
+
+type delivery struct {
+	uc  pUser.Usecase
+	log *zap.Logger
+}
+
+func RegisterHandlers(mux *mux.Router, uc pUser.Usecase, log *zap.Logger, checkAuth mw.Middleware, checkPermission mw.PermissionMiddleware) {
+	dlv := delivery{
+		uc:  uc,
+		log: log,
+	}
+
+	const (
+		usersPath   = constants.ApiPrefix + "/users"
+		userPath    = usersPath + "/{id}"
+		resetPath   = userPath + "/password_reset"
+		lockoutPath = userPath + "/lockout"
+
+		invitesPath = constants.ApiPrefix + "/invites"
+	)
+
+	manage := checkPermission(models.PermissionUserManage)
+	mux.HandleFunc(usersPath, checkAuth(manage(dlv.list))).Methods(http.MethodGet)
+	mux.HandleFunc(userPath, checkAuth(manage(dlv.get))).Methods(http.MethodGet)
+	mux.HandleFunc(userPath, checkAuth(manage(dlv.update))).Methods(http.MethodPatch)
+	mux.HandleFunc(userPath, checkAuth(manage(dlv.delete))).Methods(http.MethodDelete)
+	mux.HandleFunc(resetPath, checkAuth(manage(dlv.issueResetToken))).Methods(http.MethodPost)
+	mux.HandleFunc(lockoutPath, checkAuth(manage(dlv.unlock))).Methods(http.MethodDelete)
+	mux.HandleFunc(invitesPath, checkAuth(manage(dlv.issueInviteCode))).Methods(http.MethodPost)
+}
+
+func (d *delivery) list(w http.ResponseWriter, r *http.Request) {
+	queryParams := r.URL.Query()
+	limit, err := strconv.Atoi(queryParams.Get(LimitKey))
+	if queryParams.Get(LimitKey) != "" && err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrBadLimitParam)
+		return
+	}
+	offset, err := strconv.Atoi(queryParams.Get(OffsetKey))
+	if queryParams.Get(OffsetKey) != "" && err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrBadOffsetParam)
+		return
+	}
+
+	params := pUser.ListParams{
+		Query:  queryParams.Get(QueryKey),
+		Role:   queryParams.Get(RoleKey),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	users, err := d.uc.List(r.Context(), &params)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusOK, newListResponse(users))
+}
+
+func (d *delivery) get(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	user, err := d.uc.Get(r.Context(), userID)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusOK, newUserResponse(user))
+}
+
+func (d *delivery) update(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	body, err := pHTTP.ReadBody(r, d.log)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	var request updateRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		pHTTP.HandleError(w, r, pErrors.ErrReadBody)
+		return
+	}
+
+	params := pUser.UpdateParams{
+		ID:         userID,
+		Role:       request.Role,
+		IsDisabled: request.IsDisabled,
+	}
+	user, err := d.uc.Update(r.Context(), mw.GetPrincipal(r.Context()), &params)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusOK, newUserResponse(user))
+}
+
+func (d *delivery) delete(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	if err = d.uc.Delete(r.Context(), mw.GetPrincipal(r.Context()), userID); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (d *delivery) issueResetToken(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	token, err := d.uc.IssueResetToken(r.Context(), mw.GetPrincipal(r.Context()), userID)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusCreated, &resetTokenResponse{
+		ResetToken: token.Token,
+		ExpiresAt:  token.ExpiresAt,
+	})
+}
+
+func (d *delivery) unlock(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r)
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+
+	if err = d.uc.Unlock(r.Context(), mw.GetPrincipal(r.Context()), userID); err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (d *delivery) issueInviteCode(w http.ResponseWriter, r *http.Request) {
+	code, err := d.uc.IssueInviteCode(r.Context(), mw.GetPrincipal(r.Context()))
+	if err != nil {
+		pHTTP.HandleError(w, r, err)
+		return
+	}
+	pHTTP.SendJSON(w, r, http.StatusCreated, &inviteCodeResponse{
+		InviteCode: code.Code,
+		ExpiresAt:  code.ExpiresAt,
+	})
+}
+
+func pathID(r *http.Request) (int64, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, pErrors.ErrBadUserIDParam
+	}
+	return id, nil
+}
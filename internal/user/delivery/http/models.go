@@ -0,0 +1,51 @@
+package http
+
+import (
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"time"
+)
+
+// API requests
+type updateRequest struct {
+	Role       *string `json:"role"`
+	IsDisabled *bool   `json:"is_disabled"`
+}
+
+// API responses
+type inviteCodeResponse struct {
+	InviteCode string    `json:"invite_code"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type resetTokenResponse struct {
+	ResetToken string    `json:"reset_token"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type user struct {
+	ID          int64     `json:"user_id"`
+	Username    string    `json:"username"`
+	Role        string    `json:"role"`
+	Permissions []string  `json:"permissions"`
+	IsDisabled  bool      `json:"is_disabled"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newUserResponse(u *models.User) *user {
+	return &user{
+		ID:          u.ID,
+		Username:    u.Username,
+		Role:        u.Role,
+		Permissions: u.Permissions,
+		IsDisabled:  u.IsDisabled,
+		CreatedAt:   u.CreatedAt,
+	}
+}
+
+func newListResponse(users []models.User) []user {
+	response := make([]user, 0, len(users))
+	for i := range users {
+		response = append(response, *newUserResponse(&users[i]))
+	}
+	return response
+}
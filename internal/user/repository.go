@@ -8,9 +8,34 @@ import (
 type CreateParams struct {
 	Username string
 	Password string
+	// Role defaults to models.RoleUser.
+	Role string
+}
+
+type ListParams struct {
+	// Query matches a part of the username.
+	Query  string
+	Role   string
+	Limit  int
+	Offset int
+}
+
+type UpdateParams struct {
+	ID         int64
+	Role       *string
+	IsDisabled *bool
+	// Password is a hashed password.
+	Password *string
 }
 
 type Repository interface {
 	Create(ctx context.Context, params *CreateParams) (*models.User, error)
+	List(ctx context.Context, params *ListParams) ([]models.User, error)
+	GetByID(ctx context.Context, id int64) (*models.User, error)
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	Update(ctx context.Context, params *UpdateParams) (*models.User, error)
+	Delete(ctx context.Context, id int64) error
+	// RolePermissions returns the permissions of a role of the tenant, or
+	// ErrBadRoleField if the tenant has no such role.
+	RolePermissions(ctx context.Context, role string) ([]string, error)
 }
@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	pUsers "github.com/SlavaShagalov/avito-intern-task/internal/user"
+	"go.uber.org/zap"
+)
+
+// usernames are unique within a tenant.
+type username struct {
+	tenantID int64
+	name     string
+}
+
+type repository struct {
+	mu     sync.RWMutex
+	lastID int64
+	users  map[username]models.User
+	log    *zap.Logger
+}
+
+func New(log *zap.Logger) pUsers.Repository {
+	return &repository{
+		users: make(map[username]models.User),
+		log:   log,
+	}
+}
+
+func (repo *repository) Create(ctx context.Context, params *pUsers.CreateParams) (*models.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	key := username{tenantID: tenant.ID(ctx), name: params.Username}
+	if _, exists := repo.users[key]; exists {
+		return nil, pErrors.ErrUserAlreadyExists
+	}
+
+	role := params.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	if _, exists := models.DefaultRolePermissions[role]; !exists {
+		// Violates the foreign key in Postgres.
+		return nil, pErrors.ErrDb
+	}
+
+	repo.lastID++
+	user := models.User{
+		ID:        repo.lastID,
+		TenantID:  key.tenantID,
+		Username:  params.Username,
+		Password:  params.Password,
+		Role:      role,
+		CreatedAt: time.Now(),
+	}
+	repo.users[key] = user
+
+	repo.log.Debug("User created", zap.Int64("user_id", user.ID))
+	return withPermissions(user), nil
+}
+
+func (repo *repository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	for key, user := range repo.users {
+		if user.ID == id && key.tenantID == tenant.ID(ctx) {
+			return withPermissions(user), nil
+		}
+	}
+	return nil, pErrors.ErrUserNotFound
+}
+
+func (repo *repository) GetByUsername(ctx context.Context, name string) (*models.User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	user, exists := repo.users[username{tenantID: tenant.ID(ctx), name: name}]
+	if !exists {
+		return nil, pErrors.ErrUserNotFound
+	}
+	return withPermissions(user), nil
+}
+
+func (repo *repository) List(ctx context.Context, params *pUsers.ListParams) ([]models.User, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	query := strings.ToLower(params.Query)
+	users := make([]models.User, 0, len(repo.users))
+	for key, user := range repo.users {
+		if key.tenantID != tenant.ID(ctx) {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(user.Username), query) {
+			continue
+		}
+		if params.Role != "" && user.Role != params.Role {
+			continue
+		}
+		users = append(users, *withPermissions(user))
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+
+	if params.Offset > 0 {
+		if params.Offset >= len(users) {
+			users = users[:0]
+		} else {
+			users = users[params.Offset:]
+		}
+	}
+	if params.Limit > 0 && params.Limit < len(users) {
+		users = users[:params.Limit]
+	}
+	return users, nil
+}
+
+func (repo *repository) Update(ctx context.Context, params *pUsers.UpdateParams) (*models.User, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for key, user := range repo.users {
+		if user.ID != params.ID || key.tenantID != tenant.ID(ctx) {
+			continue
+		}
+		if params.Role != nil {
+			if _, exists := models.DefaultRolePermissions[*params.Role]; !exists {
+				return nil, pErrors.ErrBadRoleField
+			}
+			user.Role = *params.Role
+		}
+		if params.IsDisabled != nil {
+			user.IsDisabled = *params.IsDisabled
+		}
+		if params.Password != nil {
+			user.Password = *params.Password
+		}
+		repo.users[key] = user
+
+		repo.log.Debug("User updated", zap.Int64("user_id", user.ID))
+		return withPermissions(user), nil
+	}
+	return nil, pErrors.ErrUserNotFound
+}
+
+func (repo *repository) Delete(ctx context.Context, id int64) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	for key, user := range repo.users {
+		if user.ID == id && key.tenantID == tenant.ID(ctx) {
+			delete(repo.users, key)
+			repo.log.Debug("User deleted", zap.Int64("user_id", id))
+			return nil
+		}
+	}
+	return pErrors.ErrUserNotFound
+}
+
+func (repo *repository) RolePermissions(_ context.Context, role string) ([]string, error) {
+	permissions, exists := models.DefaultRolePermissions[role]
+	if !exists {
+		return nil, pErrors.ErrBadRoleField
+	}
+	return append([]string(nil), permissions...), nil
+}
+
+func withPermissions(user models.User) *models.User {
+	user.Permissions = append([]string(nil), models.DefaultRolePermissions[user.Role]...)
+	return &user
+}
@@ -2,14 +2,19 @@ package pgx
 
 import (
 	"context"
+	"fmt"
 	"github.com/SlavaShagalov/avito-intern-task/internal/models"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/constants"
 	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
 	pUsers "github.com/SlavaShagalov/avito-intern-task/internal/user"
+	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"strings"
 )
 
 type repository struct {
@@ -24,21 +29,37 @@ func New(pool *pgxpool.Pool, log *zap.Logger) pUsers.Repository {
 	}
 }
 
+// Permissions of the user's role are selected with the user. Users are only
+// visible in their tenant.
+const selectPart = `
+	SELECT u.id,
+	       u.tenant_id,
+	       u.username,
+	       u.password,
+	       u.role,
+	       ARRAY_REMOVE(ARRAY_AGG(rp.permission ORDER BY rp.permission), NULL),
+	       u.is_disabled,
+	       u.created_at`
+
+const groupPart = `
+	GROUP BY u.id, u.tenant_id, u.username, u.password, u.role, u.is_disabled, u.created_at`
+
 const createCmd = `
-	INSERT INTO users (username, password)
-	VALUES ($1, $2)
-	RETURNING id, username, password, created_at;`
+	WITH created AS (
+	    INSERT INTO users (tenant_id, username, password, role)
+	    VALUES ($1, $2, $3, $4)
+	    RETURNING id, tenant_id, username, password, role, is_disabled, created_at)` + selectPart + `
+	FROM created u
+	         LEFT JOIN role_permissions rp ON rp.tenant_id = u.tenant_id AND rp.role = u.role` + groupPart + `;`
 
 func (repo *repository) Create(ctx context.Context, params *pUsers.CreateParams) (*models.User, error) {
-	row := repo.pool.QueryRow(ctx, createCmd, params.Username, params.Password)
+	role := params.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	row := repo.pool.QueryRow(ctx, createCmd, tenant.ID(ctx), params.Username, params.Password, role)
 
-	user := new(models.User)
-	err := row.Scan(
-		&user.ID,
-		&user.Username,
-		&user.Password,
-		&user.CreatedAt,
-	)
+	user, err := scanUser(row)
 	if err != nil {
 		repo.log.Error(constants.DBError, zap.Error(err))
 		return nil, pErrors.ErrDb
@@ -48,22 +69,168 @@ func (repo *repository) Create(ctx context.Context, params *pUsers.CreateParams)
 	return user, nil
 }
 
-const getByUsernameCmd = `
-	SELECT id, username, password, is_admin, created_at
-	FROM users
-	WHERE username = $1;`
+const selectUsersPart = selectPart + `
+	FROM users u
+	         LEFT JOIN role_permissions rp ON rp.tenant_id = u.tenant_id AND rp.role = u.role`
+
+const listCmd = selectUsersPart + `
+	WHERE %s` + groupPart + `
+	ORDER BY u.id
+	%s;`
+
+func (repo *repository) List(ctx context.Context, params *pUsers.ListParams) ([]models.User, error) {
+	conditions := []string{"u.tenant_id = $1"}
+	args := []any{tenant.ID(ctx)}
+	if params.Query != "" {
+		conditions = append(conditions, fmt.Sprintf("u.username ILIKE '%%' || $%d || '%%'", len(args)+1))
+		args = append(args, escapeLike(params.Query))
+	}
+	if params.Role != "" {
+		conditions = append(conditions, fmt.Sprintf("u.role = $%d", len(args)+1))
+		args = append(args, params.Role)
+	}
+
+	var limitPart string
+	if params.Limit > 0 {
+		limitPart = fmt.Sprintf(" LIMIT $%d", len(args)+1)
+		args = append(args, params.Limit)
+	}
+	if params.Offset > 0 {
+		limitPart += fmt.Sprintf(" OFFSET $%d", len(args)+1)
+		args = append(args, params.Offset)
+	}
+
+	rows, err := repo.pool.Query(ctx, fmt.Sprintf(listCmd, strings.Join(conditions, " AND "), limitPart), args...)
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, 4)
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			repo.log.Error(constants.DBError, zap.Error(err))
+			return nil, pErrors.ErrDb
+		}
+		users = append(users, *user)
+	}
+	if err = rows.Err(); err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return users, nil
+}
+
+const getByIDCmd = selectUsersPart + `
+	WHERE u.id = $1
+	  AND u.tenant_id = $2` + groupPart + `;`
+
+func (repo *repository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	return repo.get(ctx, getByIDCmd, id)
+}
+
+const getByUsernameCmd = selectUsersPart + `
+	WHERE u.username = $1
+	  AND u.tenant_id = $2` + groupPart + `;`
 
 func (repo *repository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
-	row := repo.pool.QueryRow(ctx, getByUsernameCmd, username)
+	return repo.get(ctx, getByUsernameCmd, username)
+}
 
-	user := new(models.User)
-	err := row.Scan(
-		&user.ID,
-		&user.Username,
-		&user.Password,
-		&user.IsAdmin,
-		&user.CreatedAt,
-	)
+const updateCmd = `
+	WITH updated AS (
+	    UPDATE users
+	    SET %s
+	    WHERE id = $%d
+	      AND tenant_id = $%d
+	    RETURNING id, tenant_id, username, password, role, is_disabled, created_at)` + selectPart + `
+	FROM updated u
+	         LEFT JOIN role_permissions rp ON rp.tenant_id = u.tenant_id AND rp.role = u.role` + groupPart + `;`
+
+func (repo *repository) Update(ctx context.Context, params *pUsers.UpdateParams) (*models.User, error) {
+	setValues := make([]string, 0, 3)
+	args := make([]any, 0, 4)
+	if params.Role != nil {
+		setValues = append(setValues, fmt.Sprintf("role = $%d", len(args)+1))
+		args = append(args, *params.Role)
+	}
+	if params.IsDisabled != nil {
+		setValues = append(setValues, fmt.Sprintf("is_disabled = $%d", len(args)+1))
+		args = append(args, *params.IsDisabled)
+	}
+	if params.Password != nil {
+		setValues = append(setValues, fmt.Sprintf("password = $%d", len(args)+1))
+		args = append(args, *params.Password)
+	}
+	if len(setValues) == 0 {
+		return repo.GetByID(ctx, params.ID)
+	}
+
+	cmd := fmt.Sprintf(updateCmd, strings.Join(setValues, ", "), len(args)+1, len(args)+2)
+	args = append(args, params.ID, tenant.ID(ctx))
+
+	user, err := scanUser(repo.pool.QueryRow(ctx, cmd, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pErrors.ErrUserNotFound
+		}
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return nil, pErrors.ErrBadRoleField
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+
+	repo.log.Debug("User updated", zap.Int64("user_id", user.ID))
+	return user, nil
+}
+
+const deleteCmd = `
+	DELETE FROM users
+	WHERE id = $1
+	  AND tenant_id = $2;`
+
+func (repo *repository) Delete(ctx context.Context, id int64) error {
+	res, err := repo.pool.Exec(ctx, deleteCmd, id, tenant.ID(ctx))
+	if err != nil {
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return pErrors.ErrDb
+	}
+	if res.RowsAffected() == 0 {
+		return pErrors.ErrUserNotFound
+	}
+	repo.log.Debug("User deleted", zap.Int64("user_id", id))
+	return nil
+}
+
+const rolePermissionsCmd = `
+	SELECT ARRAY_REMOVE(ARRAY_AGG(rp.permission ORDER BY rp.permission), NULL)
+	FROM roles r
+	         LEFT JOIN role_permissions rp ON rp.tenant_id = r.tenant_id AND rp.role = r.name
+	WHERE r.tenant_id = $1
+	  AND r.name = $2
+	GROUP BY r.tenant_id, r.name;`
+
+func (repo *repository) RolePermissions(ctx context.Context, role string) ([]string, error) {
+	var permissions []string
+	err := repo.pool.QueryRow(ctx, rolePermissionsCmd, tenant.ID(ctx), role).Scan(&permissions)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pErrors.ErrBadRoleField
+		}
+		repo.log.Error(constants.DBError, zap.Error(err))
+		return nil, pErrors.ErrDb
+	}
+	return permissions, nil
+}
+
+func (repo *repository) get(ctx context.Context, cmd string, arg any) (*models.User, error) {
+	row := repo.pool.QueryRow(ctx, cmd, arg, tenant.ID(ctx))
+
+	user, err := scanUser(row)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, pErrors.ErrUserNotFound
@@ -74,3 +241,22 @@ func (repo *repository) GetByUsername(ctx context.Context, username string) (*mo
 
 	return user, nil
 }
+
+func scanUser(row pgx.Row) (*models.User, error) {
+	user := new(models.User)
+	err := row.Scan(
+		&user.ID,
+		&user.TenantID,
+		&user.Username,
+		&user.Password,
+		&user.Role,
+		&user.Permissions,
+		&user.IsDisabled,
+		&user.CreatedAt,
+	)
+	return user, err
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
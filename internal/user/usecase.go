@@ -0,0 +1,21 @@
+package user
+
+import (
+	"context"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+)
+
+type Usecase interface {
+	List(ctx context.Context, params *ListParams) ([]models.User, error)
+	Get(ctx context.Context, id int64) (*models.User, error)
+	// Update changes the role or disables the account. Sessions of a disabled
+	// user are revoked.
+	Update(ctx context.Context, principal *models.Principal, params *UpdateParams) (*models.User, error)
+	Delete(ctx context.Context, principal *models.Principal, id int64) error
+	// IssueResetToken lets the user set a new password without the old one.
+	IssueResetToken(ctx context.Context, principal *models.Principal, id int64) (*auth.ResetToken, error)
+	IssueInviteCode(ctx context.Context, principal *models.Principal) (*auth.InviteCode, error)
+	// Unlock lifts the sign in lockout of the user.
+	Unlock(ctx context.Context, principal *models.Principal, id int64) error
+}
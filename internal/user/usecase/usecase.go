@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pUser "github.com/SlavaShagalov/avito-intern-task/internal/user"
+	"go.uber.org/zap"
+)
+
+type usecase struct {
+	repo   pUser.Repository
+	authUC auth.Usecase
+	log    *zap.Logger
+}
+
+func New(repo pUser.Repository, authUC auth.Usecase, log *zap.Logger) pUser.Usecase {
+	return &usecase{
+		repo:   repo,
+		authUC: authUC,
+		log:    log,
+	}
+}
+
+func (uc *usecase) List(ctx context.Context, params *pUser.ListParams) ([]models.User, error) {
+	return uc.repo.List(ctx, params)
+}
+
+func (uc *usecase) Get(ctx context.Context, id int64) (*models.User, error) {
+	return uc.repo.GetByID(ctx, id)
+}
+
+func (uc *usecase) Update(ctx context.Context, principal *models.Principal, params *pUser.UpdateParams) (*models.User, error) {
+	if params.Role != nil {
+		permissions, err := uc.repo.RolePermissions(ctx, *params.Role)
+		if err != nil {
+			return nil, err
+		}
+		if !models.HasPermissions(principal.Permissions, permissions) {
+			return nil, pErrors.ErrPermissionDenied
+		}
+	}
+	target, err := uc.target(ctx, principal, params.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.repo.Update(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	// A new role must not be used with the permissions of the old one, which
+	// access tokens carry until refresh.
+	if user.IsDisabled || params.Role != nil && *params.Role != target.Role {
+		if err = uc.authUC.RevokeSessions(ctx, user.ID, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	uc.log.Info("User updated", zap.Int64("user_id", user.ID), zap.String("role", user.Role),
+		zap.Bool("is_disabled", user.IsDisabled), zap.Int64("by", principal.UserID))
+	return user, nil
+}
+
+func (uc *usecase) Delete(ctx context.Context, principal *models.Principal, id int64) error {
+	if _, err := uc.target(ctx, principal, id); err != nil {
+		return err
+	}
+	// Sessions are deleted with the user, but issued access tokens stay valid
+	// until they are deny-listed.
+	if err := uc.authUC.RevokeSessions(ctx, id, 0); err != nil {
+		return err
+	}
+	if err := uc.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	uc.log.Info("User deleted", zap.Int64("user_id", id), zap.Int64("by", principal.UserID))
+	return nil
+}
+
+func (uc *usecase) IssueResetToken(ctx context.Context, principal *models.Principal, id int64) (*auth.ResetToken, error) {
+	if _, err := uc.target(ctx, principal, id); err != nil {
+		return nil, err
+	}
+	return uc.authUC.IssueResetToken(ctx, id)
+}
+
+func (uc *usecase) IssueInviteCode(ctx context.Context, principal *models.Principal) (*auth.InviteCode, error) {
+	return uc.authUC.IssueInviteCode(ctx, principal.UserID)
+}
+
+func (uc *usecase) Unlock(ctx context.Context, principal *models.Principal, id int64) error {
+	user, err := uc.target(ctx, principal, id)
+	if err != nil {
+		return err
+	}
+	return uc.authUC.Unlock(ctx, user.Username)
+}
+
+// target returns the user managed by the principal. Nobody can manage their
+// own account or an account with permissions they don't have.
+func (uc *usecase) target(ctx context.Context, principal *models.Principal, id int64) (*models.User, error) {
+	if id == principal.UserID {
+		return nil, pErrors.ErrPermissionDenied
+	}
+	user, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.HasPermissions(principal.Permissions, user.Permissions) {
+		return nil, pErrors.ErrPermissionDenied
+	}
+	return user, nil
+}
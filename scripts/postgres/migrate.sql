@@ -0,0 +1,75 @@
+-- Upgrades a database created by schema.sql of the first release, where CREATE
+-- TABLE IF NOT EXISTS leaves the existing tables as they were. Run it once with
+-- psql from this directory (make migrate) before starting the new version.
+-- Existing rows go to the default tenant, admins get the admin role. Nobody
+-- becomes a super-admin: promote the owner explicitly afterwards (see README).
+BEGIN;
+
+-- Keys referenced by the tables schema.sql creates.
+ALTER TABLE banners
+    ADD COLUMN tenant_id bigint NOT NULL DEFAULT 1,
+    ADD UNIQUE (tenant_id, id);
+
+ALTER TABLE banner_references
+    ADD COLUMN tenant_id bigint NOT NULL DEFAULT 1,
+    DROP CONSTRAINT banner_references_pkey,
+    DROP CONSTRAINT banner_references_banner_id_fkey,
+    DROP CONSTRAINT banner_references_feature_id_fkey,
+    DROP CONSTRAINT banner_references_tag_id_fkey,
+    ADD PRIMARY KEY (tenant_id, feature_id, tag_id);
+
+-- Features and tags are numbered per tenant.
+ALTER TABLE features
+    ADD COLUMN tenant_id bigint NOT NULL DEFAULT 1,
+    DROP CONSTRAINT features_name_key,
+    DROP CONSTRAINT features_pkey,
+    ADD PRIMARY KEY (tenant_id, id),
+    ADD UNIQUE (tenant_id, name);
+
+ALTER TABLE tags
+    ADD COLUMN tenant_id bigint NOT NULL DEFAULT 1,
+    DROP CONSTRAINT tags_name_key,
+    DROP CONSTRAINT tags_pkey,
+    ADD PRIMARY KEY (tenant_id, id),
+    ADD UNIQUE (tenant_id, name);
+
+ALTER TABLE banner_references
+    ADD CONSTRAINT banner_references_tenant_id_banner_id_fkey
+        FOREIGN KEY (tenant_id, banner_id) REFERENCES banners (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT banner_references_tenant_id_feature_id_fkey
+        FOREIGN KEY (tenant_id, feature_id) REFERENCES features (tenant_id, id) ON DELETE CASCADE,
+    ADD CONSTRAINT banner_references_tenant_id_tag_id_fkey
+        FOREIGN KEY (tenant_id, tag_id) REFERENCES tags (tenant_id, id) ON DELETE CASCADE;
+
+ALTER TABLE users
+    ADD COLUMN tenant_id   bigint  NOT NULL DEFAULT 1,
+    ADD COLUMN role        text    NOT NULL DEFAULT 'user',
+    ADD COLUMN is_disabled boolean NOT NULL DEFAULT false,
+    DROP CONSTRAINT users_username_key,
+    ADD UNIQUE (tenant_id, username),
+    ADD UNIQUE (tenant_id, id);
+
+UPDATE users
+SET role = 'admin'
+WHERE is_admin;
+
+ALTER TABLE users
+    DROP COLUMN is_admin;
+
+-- Creates the tenants, the roles and the tables added since the first release.
+\ir schema.sql
+
+ALTER TABLE banners
+    ADD CONSTRAINT banners_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE CASCADE;
+
+ALTER TABLE features
+    ADD CONSTRAINT features_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE CASCADE;
+
+ALTER TABLE tags
+    ADD CONSTRAINT tags_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE CASCADE;
+
+ALTER TABLE users
+    ADD CONSTRAINT users_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id) ON DELETE CASCADE,
+    ADD CONSTRAINT users_role_fkey FOREIGN KEY (tenant_id, role) REFERENCES roles (tenant_id, name);
+
+COMMIT;
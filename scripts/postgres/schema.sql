@@ -1,39 +1,310 @@
+-- Independent products hosted by the deployment. Tenant-owned tables reference
+-- it and default to the tenant created here, which also hosts the super-admins
+-- provisioning other tenants. Rows of sessions, tokens and second factors
+-- belong to the tenant of their user. Permissions are the only shared rows.
+-- Databases created by the first release are upgraded with migrate.sql.
+CREATE TABLE IF NOT EXISTS tenants
+(
+    id         bigserial NOT NULL PRIMARY KEY,
+    name       text      NOT NULL UNIQUE,
+    created_at timestamp NOT NULL DEFAULT now()
+);
+
+INSERT INTO tenants(id, name)
+VALUES (1, 'default')
+ON CONFLICT DO NOTHING;
+
+SELECT setval('tenants_id_seq', (SELECT max(id) FROM tenants));
+
 CREATE TABLE IF NOT EXISTS banners
 (
     id         bigserial NOT NULL PRIMARY KEY,
+    tenant_id  bigint    NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE,
     content    jsonb     NOT NULL,
     is_active  boolean   NOT NULL DEFAULT true,
     created_at timestamp NOT NULL DEFAULT now(),
-    updated_at timestamp NOT NULL DEFAULT now()
+    updated_at timestamp NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, id)
 );
 
+-- Features and tags are numbered per tenant: a new tenant starts with copies of
+-- the ones of the default tenant with the same ids.
 CREATE TABLE IF NOT EXISTS features
 (
-    id         bigserial NOT NULL PRIMARY KEY,
-    name       varchar   NOT NULL UNIQUE,
-    created_at timestamp NOT NULL DEFAULT now()
+    id         bigserial NOT NULL,
+    tenant_id  bigint    NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE,
+    name       varchar   NOT NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, id),
+    UNIQUE (tenant_id, name)
 );
 
 CREATE TABLE IF NOT EXISTS tags
 (
-    id         bigserial NOT NULL PRIMARY KEY,
-    name       varchar   NOT NULL UNIQUE,
-    created_at timestamp NOT NULL DEFAULT now()
+    id         bigserial NOT NULL,
+    tenant_id  bigint    NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE,
+    name       varchar   NOT NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, id),
+    UNIQUE (tenant_id, name)
 );
 
+-- Composite keys keep a banner, its feature and its tags in the same tenant.
 CREATE TABLE IF NOT EXISTS banner_references
 (
-    banner_id  bigint NOT NULL REFERENCES banners (id) ON DELETE CASCADE,
-    feature_id bigint NOT NULL REFERENCES features (id) ON DELETE CASCADE,
-    tag_id     bigint NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
-    PRIMARY KEY (feature_id, tag_id)
+    tenant_id  bigint NOT NULL DEFAULT 1,
+    banner_id  bigint NOT NULL,
+    feature_id bigint NOT NULL,
+    tag_id     bigint NOT NULL,
+    PRIMARY KEY (tenant_id, feature_id, tag_id),
+    FOREIGN KEY (tenant_id, banner_id) REFERENCES banners (tenant_id, id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id, feature_id) REFERENCES features (tenant_id, id) ON DELETE CASCADE,
+    FOREIGN KEY (tenant_id, tag_id) REFERENCES tags (tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS banner_references_banner_id_idx ON banner_references (banner_id);
+
+CREATE OR REPLACE FUNCTION notify_banners_changed() RETURNS trigger AS
+$$
+BEGIN
+    IF TG_TABLE_NAME = 'banners' THEN
+        PERFORM pg_notify('banners_changed', COALESCE(NEW.id, OLD.id)::text);
+    ELSE
+        PERFORM pg_notify('banners_changed', COALESCE(NEW.banner_id, OLD.banner_id)::text);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER banners_changed
+    AFTER INSERT OR UPDATE OR DELETE
+    ON banners
+    FOR EACH ROW
+EXECUTE FUNCTION notify_banners_changed();
+
+CREATE OR REPLACE TRIGGER banner_references_changed
+    AFTER INSERT OR UPDATE OR DELETE
+    ON banner_references
+    FOR EACH ROW
+EXECUTE FUNCTION notify_banners_changed();
+
+-- Roles of a new tenant are copied from the default tenant.
+CREATE TABLE IF NOT EXISTS roles
+(
+    tenant_id bigint NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE,
+    name      text   NOT NULL,
+    PRIMARY KEY (tenant_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS permissions
+(
+    name text NOT NULL PRIMARY KEY
 );
 
+CREATE TABLE IF NOT EXISTS role_permissions
+(
+    tenant_id  bigint NOT NULL DEFAULT 1,
+    role       text   NOT NULL,
+    permission text   NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (tenant_id, role, permission),
+    CONSTRAINT role_permissions_role_fkey FOREIGN KEY (tenant_id, role) REFERENCES roles (tenant_id, name) ON DELETE CASCADE
+);
+
+INSERT INTO roles(name)
+VALUES ('user'),
+       ('viewer'),
+       ('editor'),
+       ('publisher'),
+       ('admin'),
+       ('superadmin')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO permissions(name)
+VALUES ('banner:read'),
+       ('banner:create'),
+       ('banner:update'),
+       ('banner:publish'),
+       ('banner:delete'),
+       ('cache:manage'),
+       ('team:manage'),
+       ('feature:any'),
+       ('apikey:manage'),
+       ('user:manage'),
+       ('tenant:manage')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions(role, permission)
+VALUES ('viewer', 'banner:read'),
+       ('editor', 'banner:read'),
+       ('editor', 'banner:create'),
+       ('editor', 'banner:update'),
+       ('publisher', 'banner:read'),
+       ('publisher', 'banner:create'),
+       ('publisher', 'banner:update'),
+       ('publisher', 'banner:publish'),
+       ('admin', 'banner:read'),
+       ('admin', 'banner:create'),
+       ('admin', 'banner:update'),
+       ('admin', 'banner:publish'),
+       ('admin', 'banner:delete'),
+       ('admin', 'cache:manage'),
+       ('admin', 'apikey:manage'),
+       ('admin', 'user:manage'),
+       ('superadmin', 'banner:read'),
+       ('superadmin', 'banner:create'),
+       ('superadmin', 'banner:update'),
+       ('superadmin', 'banner:publish'),
+       ('superadmin', 'banner:delete'),
+       ('superadmin', 'cache:manage'),
+       ('superadmin', 'team:manage'),
+       ('superadmin', 'feature:any'),
+       ('superadmin', 'apikey:manage'),
+       ('superadmin', 'user:manage'),
+       ('superadmin', 'tenant:manage')
+ON CONFLICT DO NOTHING;
+
 CREATE TABLE IF NOT EXISTS users
+(
+    id          bigserial NOT NULL PRIMARY KEY,
+    tenant_id   bigint    NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE,
+    username    text      NOT NULL,
+    password    varchar   NOT NULL,
+    role        text      NOT NULL DEFAULT 'user',
+    is_disabled boolean   NOT NULL DEFAULT false,
+    created_at  timestamp NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, username),
+    UNIQUE (tenant_id, id),
+    CONSTRAINT users_role_fkey FOREIGN KEY (tenant_id, role) REFERENCES roles (tenant_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS sessions
 (
     id         bigserial NOT NULL PRIMARY KEY,
-    username   text      NOT NULL UNIQUE,
-    password   varchar   NOT NULL,
-    is_admin   boolean   NOT NULL DEFAULT false,
+    tenant_id  bigint    NOT NULL DEFAULT 1,
+    user_id    bigint    NOT NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    revoked_at timestamp,
+    UNIQUE (tenant_id, id),
+    CONSTRAINT sessions_user_id_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES users (tenant_id, id) ON DELETE CASCADE
+);
+
+-- Only hashes of refresh tokens are stored. A used token is kept to detect its reuse.
+CREATE TABLE IF NOT EXISTS refresh_tokens
+(
+    token_hash text      NOT NULL PRIMARY KEY,
+    tenant_id  bigint    NOT NULL DEFAULT 1,
+    session_id bigint    NOT NULL,
+    expires_at timestamp NOT NULL,
+    used_at    timestamp,
+    created_at timestamp NOT NULL DEFAULT now(),
+    CONSTRAINT refresh_tokens_session_id_fkey FOREIGN KEY (tenant_id, session_id) REFERENCES sessions (tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_id_idx ON refresh_tokens (session_id);
+
+-- One-time tokens issued by admins to reset a password. Only hashes are stored.
+CREATE TABLE IF NOT EXISTS password_reset_tokens
+(
+    token_hash text      NOT NULL PRIMARY KEY,
+    tenant_id  bigint    NOT NULL DEFAULT 1,
+    user_id    bigint    NOT NULL,
+    expires_at timestamp NOT NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    CONSTRAINT password_reset_tokens_user_id_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES users (tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id_idx ON password_reset_tokens (user_id);
+
+-- One-time codes required to sign up with AUTH_SIGNUP_MODE: invite. Only hashes are stored.
+CREATE TABLE IF NOT EXISTS invite_codes
+(
+    code_hash  text      NOT NULL PRIMARY KEY,
+    tenant_id  bigint    NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE,
+    created_by bigint    REFERENCES users (id) ON DELETE SET NULL,
+    expires_at timestamp NOT NULL,
     created_at timestamp NOT NULL DEFAULT now()
 );
+
+-- Keys signing access tokens of their tenant, published without private parts
+-- at /.well-known/jwks.json. private_key is PKCS8 encrypted with AES-256-GCM
+-- (nonce followed by ciphertext, kid as additional data).
+CREATE TABLE IF NOT EXISTS signing_keys
+(
+    id          text      NOT NULL PRIMARY KEY,
+    tenant_id   bigint    NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE,
+    algorithm   text      NOT NULL,
+    private_key bytea     NOT NULL,
+    created_at  timestamp NOT NULL DEFAULT now(),
+    expires_at  timestamp NOT NULL
+);
+
+-- TOTP second factors, pending until confirmed with the first code.
+CREATE TABLE IF NOT EXISTS two_factor
+(
+    user_id        bigint    NOT NULL PRIMARY KEY,
+    tenant_id      bigint    NOT NULL DEFAULT 1,
+    secret         text      NOT NULL,
+    confirmed_at   timestamp,
+    last_used_step bigint    NOT NULL DEFAULT 0,
+    created_at     timestamp NOT NULL DEFAULT now(),
+    CONSTRAINT two_factor_user_id_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES users (tenant_id, id) ON DELETE CASCADE
+);
+
+-- One-time recovery codes replacing a TOTP code. Only hashes are stored.
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes
+(
+    user_id   bigint NOT NULL REFERENCES two_factor (user_id) ON DELETE CASCADE,
+    code_hash text   NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
+
+CREATE TABLE IF NOT EXISTS teams
+(
+    id         bigserial NOT NULL PRIMARY KEY,
+    tenant_id  bigint    NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE,
+    name       text      NOT NULL,
+    created_at timestamp NOT NULL DEFAULT now(),
+    UNIQUE (tenant_id, name),
+    UNIQUE (tenant_id, id)
+);
+
+CREATE TABLE IF NOT EXISTS team_members
+(
+    tenant_id bigint NOT NULL DEFAULT 1,
+    team_id   bigint NOT NULL,
+    user_id   bigint NOT NULL,
+    PRIMARY KEY (team_id, user_id),
+    CONSTRAINT team_members_team_id_fkey FOREIGN KEY (tenant_id, team_id) REFERENCES teams (tenant_id, id) ON DELETE CASCADE,
+    CONSTRAINT team_members_user_id_fkey FOREIGN KEY (tenant_id, user_id) REFERENCES users (tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS team_members_user_id_idx ON team_members (user_id);
+
+-- A feature is owned by at most one team.
+CREATE TABLE IF NOT EXISTS team_features
+(
+    tenant_id  bigint NOT NULL DEFAULT 1,
+    feature_id bigint NOT NULL,
+    team_id    bigint NOT NULL,
+    PRIMARY KEY (tenant_id, feature_id),
+    CONSTRAINT team_features_feature_id_fkey FOREIGN KEY (tenant_id, feature_id) REFERENCES features (tenant_id, id) ON DELETE CASCADE,
+    CONSTRAINT team_features_team_id_fkey FOREIGN KEY (tenant_id, team_id) REFERENCES teams (tenant_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS team_features_team_id_idx ON team_features (team_id);
+
+-- Only hashes of API keys are stored.
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           bigserial NOT NULL PRIMARY KEY,
+    tenant_id    bigint    NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE,
+    name         text      NOT NULL,
+    key_hash     text      NOT NULL UNIQUE,
+    prefix       text      NOT NULL,
+    scopes       text[]    NOT NULL DEFAULT '{}',
+    created_by   bigint    REFERENCES users (id) ON DELETE SET NULL,
+    expires_at   timestamp,
+    last_used_at timestamp,
+    revoked_at   timestamp,
+    created_at   timestamp NOT NULL DEFAULT now()
+);
@@ -0,0 +1,187 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/breaker"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const (
+	breakerThreshold     = 3
+	breakerProbeInterval = 10 * time.Millisecond
+)
+
+var errUnavailable = errors.New("connection refused")
+
+// flakyCache returns err from every call and counts the calls.
+type flakyCache struct {
+	mu    sync.Mutex
+	err   error
+	calls int
+}
+
+func (c *flakyCache) setErr(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.err = err
+}
+
+func (c *flakyCache) called() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+func (c *flakyCache) call(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.err
+}
+
+func (c *flakyCache) Set(ctx context.Context, _ string, _ *pCache.Value) error {
+	return c.call(ctx)
+}
+
+func (c *flakyCache) Get(ctx context.Context, _ string) (*pCache.Value, error) {
+	if err := c.call(ctx); err != nil {
+		return nil, err
+	}
+	return &pCache.Value{Code: 200}, nil
+}
+
+func (c *flakyCache) TTL(ctx context.Context, _ string) (time.Duration, error) {
+	return time.Minute, c.call(ctx)
+}
+
+func (c *flakyCache) Delete(ctx context.Context, _ string) (int64, error) {
+	return 0, c.call(ctx)
+}
+
+type BreakerSuite struct {
+	suite.Suite
+	log      *zap.Logger
+	cache    *flakyCache
+	probeMu  sync.Mutex
+	probeErr error
+	breaker  *breaker.Breaker
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func (s *BreakerSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.cache = &flakyCache{}
+	s.probeErr = nil
+	s.breaker = breaker.New(s.cache, s.probe, breakerThreshold, breakerProbeInterval, s.log)
+
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		s.breaker.Run(ctx)
+	}()
+}
+
+func (s *BreakerSuite) TearDownTest() {
+	s.cancel()
+	<-s.done
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *BreakerSuite) probe(context.Context) error {
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+	return s.probeErr
+}
+
+func (s *BreakerSuite) setProbeErr(err error) {
+	s.probeMu.Lock()
+	defer s.probeMu.Unlock()
+	s.probeErr = err
+}
+
+func (s *BreakerSuite) get(ctx context.Context) error {
+	_, err := s.breaker.Get(ctx, pCache.Key(1, 1, 1, false))
+	return err
+}
+
+func (s *BreakerSuite) TestOpenProbeClose() {
+	ctx := context.Background()
+	s.cache.setErr(errUnavailable)
+	s.setProbeErr(errUnavailable)
+
+	for i := 0; i < breakerThreshold; i++ {
+		s.Require().ErrorIs(s.get(ctx), errUnavailable)
+	}
+	s.ErrorIs(s.get(ctx), pErrors.ErrCacheUnavailable)
+	s.Equal(breakerThreshold, s.cache.called(), "the open breaker doesn't call the cache")
+
+	// Failed probes keep it open.
+	time.Sleep(5 * breakerProbeInterval)
+	s.ErrorIs(s.get(ctx), pErrors.ErrCacheUnavailable)
+
+	s.cache.setErr(nil)
+	s.setProbeErr(nil)
+	s.Eventually(func() bool { return s.get(ctx) == nil }, time.Second, breakerProbeInterval)
+
+	// Failures are counted from zero again.
+	s.cache.setErr(errUnavailable)
+	for i := 0; i < breakerThreshold; i++ {
+		s.Require().ErrorIs(s.get(ctx), errUnavailable)
+	}
+	s.ErrorIs(s.get(ctx), pErrors.ErrCacheUnavailable)
+}
+
+func (s *BreakerSuite) TestOpen() {
+	s.setProbeErr(errUnavailable)
+	s.breaker.Open()
+
+	s.ErrorIs(s.get(context.Background()), pErrors.ErrCacheUnavailable)
+	s.Zero(s.cache.called())
+
+	s.setProbeErr(nil)
+	s.Eventually(func() bool { return s.get(context.Background()) == nil }, time.Second, breakerProbeInterval)
+}
+
+func (s *BreakerSuite) TestMissIsNotFailure() {
+	s.cache.setErr(pErrors.ErrCacheMiss)
+
+	for i := 0; i < 2*breakerThreshold; i++ {
+		s.Require().ErrorIs(s.get(context.Background()), pErrors.ErrCacheMiss)
+	}
+}
+
+func (s *BreakerSuite) TestCallerGaveUp() {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	for i := 0; i < breakerThreshold; i++ {
+		s.Require().ErrorIs(s.get(canceled), context.Canceled)
+		s.Require().ErrorIs(s.get(expired), context.DeadlineExceeded)
+	}
+	s.NoError(s.get(context.Background()), "requests abandoned by callers aren't failures of the cache")
+}
+
+func TestBreakerSuite(t *testing.T) {
+	suite.Run(t, new(BreakerSuite))
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	"github.com/stretchr/testify/suite"
+)
+
+type CodecSuite struct {
+	suite.Suite
+}
+
+func (s *CodecSuite) TestRoundTrip() {
+	small := []byte(`{"title":"small"}`)
+	large := []byte(`{"text":"` + string(bytes.Repeat([]byte("banner "), 200)) + `"}`)
+
+	type testCase struct {
+		compression string
+		value       *pCache.Value
+	}
+
+	tests := map[string]testCase{
+		"no body": {
+			compression: pCache.CompressionZstd,
+			value:       &pCache.Value{Code: http.StatusForbidden},
+		},
+		"uncompressed": {
+			compression: pCache.CompressionNone,
+			value:       &pCache.Value{Code: http.StatusOK, Body: large},
+		},
+		"below threshold": {
+			compression: pCache.CompressionZstd,
+			value:       &pCache.Value{Code: http.StatusOK, Body: small},
+		},
+		"zstd": {
+			compression: pCache.CompressionZstd,
+			value:       &pCache.Value{Code: http.StatusOK, Body: large},
+		},
+		"snappy": {
+			compression: pCache.CompressionSnappy,
+			value:       &pCache.Value{Code: http.StatusNotFound, Body: large},
+		},
+	}
+
+	for name, test := range tests {
+		s.Run(name, func() {
+			codec, err := pCache.NewCodec(test.compression, 64)
+			s.Require().NoError(err)
+
+			data := codec.Encode(test.value)
+			if test.compression != pCache.CompressionNone && len(test.value.Body) >= 64 {
+				s.Less(len(data), len(test.value.Body), "body must be compressed")
+			}
+
+			value, err := codec.Decode(data)
+			s.Require().NoError(err)
+			s.Equal(test.value.Code, value.Code)
+			s.Equal(test.value.Body, value.Body)
+		})
+	}
+}
+
+func (s *CodecSuite) TestDecodeWithOtherSettings() {
+	zstdCodec, err := pCache.NewCodec(pCache.CompressionZstd, 0)
+	s.Require().NoError(err)
+	plainCodec, err := pCache.NewCodec(pCache.CompressionNone, 0)
+	s.Require().NoError(err)
+
+	body := []byte(`{"title":"banner"}`)
+	value, err := plainCodec.Decode(zstdCodec.Encode(&pCache.Value{Code: http.StatusOK, Body: body}))
+	s.Require().NoError(err)
+	s.Equal(body, value.Body)
+}
+
+func (s *CodecSuite) TestUnsupportedEncoding() {
+	codec, err := pCache.NewCodec(pCache.CompressionNone, 0)
+	s.Require().NoError(err)
+
+	_, err = codec.Decode([]byte(`{"code":200,"body":{"title":"legacy json"}}`))
+	s.ErrorIs(err, pErrors.ErrCacheMiss)
+
+	_, err = pCache.NewCodec("lz4", 0)
+	s.Error(err)
+}
+
+func (s *CodecSuite) TestCorrupted() {
+	codec, err := pCache.NewCodec(pCache.CompressionNone, 0)
+	s.Require().NoError(err)
+	large := bytes.Repeat([]byte("banner "), 200)
+
+	for _, compression := range []string{pCache.CompressionZstd, pCache.CompressionSnappy} {
+		other, err := pCache.NewCodec(compression, 0)
+		s.Require().NoError(err)
+		data := other.Encode(&pCache.Value{Code: http.StatusOK, Body: large})
+
+		_, err = codec.Decode(data[:len(data)/2])
+		s.ErrorIs(err, pErrors.ErrCacheMiss, compression)
+	}
+}
+
+func TestCodecSuite(t *testing.T) {
+	suite.Run(t, new(CodecSuite))
+}
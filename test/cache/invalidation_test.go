@@ -0,0 +1,258 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/invalidation"
+	memoryCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/memory"
+	redisCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/redis"
+	tieredCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/tiered"
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	invalidatingRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/invalidating"
+	bannerMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/memory"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const (
+	invalidationTimeout = 5 * time.Second
+	invalidationTick    = 10 * time.Millisecond
+)
+
+type InvalidationSuite struct {
+	suite.Suite
+	log       *zap.Logger
+	server    *redisStandIn
+	remote    pCache.Cache
+	instances []*instance
+}
+
+// instance is a replica of the service with its own local cache.
+type instance struct {
+	rdb    *redis.Client
+	local  pCache.Cache
+	bus    *invalidation.Bus
+	cache  pCache.Cache
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (s *InvalidationSuite) SetupTest() {
+	s.log = pLog.NewDev()
+
+	var err error
+	s.server, err = startRedisStandIn()
+	s.Require().NoError(err)
+	codec, err := pCache.NewCodec(pCache.CompressionNone, 0)
+	s.Require().NoError(err)
+
+	remoteClient := redis.NewClient(&redis.Options{Addr: s.server.addr()})
+	s.T().Cleanup(func() { _ = remoteClient.Close() })
+	s.remote = redisCache.New(remoteClient, codec, s.log)
+
+	s.instances = []*instance{s.startInstance(), s.startInstance()}
+}
+
+func (s *InvalidationSuite) TearDownTest() {
+	for _, inst := range s.instances {
+		inst.cancel()
+		<-inst.done
+		_ = inst.rdb.Close()
+	}
+	s.server.close()
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// startInstance runs the bus of a new instance and waits until it's subscribed.
+func (s *InvalidationSuite) startInstance() *instance {
+	inst := &instance{
+		rdb:   redis.NewClient(&redis.Options{Addr: s.server.addr()}),
+		local: memoryCache.New(s.log),
+		done:  make(chan struct{}),
+	}
+	inst.bus = invalidation.New(inst.rdb, inst.local, s.log)
+	inst.cache = tieredCache.New(inst.local, s.remote, inst.bus, s.log)
+
+	// The local cache is flushed once subscribed.
+	marker := pCache.Key(1, 0, 0, false)
+	s.Require().NoError(inst.local.Set(context.Background(), marker, &pCache.Value{Code: 200}))
+
+	var ctx context.Context
+	ctx, inst.cancel = context.WithCancel(context.Background())
+	go func() {
+		defer close(inst.done)
+		inst.bus.Run(ctx)
+	}()
+	s.Eventually(func() bool { return !s.cached(inst.local, marker) }, invalidationTimeout, invalidationTick)
+	return inst
+}
+
+func (s *InvalidationSuite) set(c pCache.Cache, featureID, tagID int64) string {
+	key := pCache.Key(1, featureID, tagID, false)
+	err := c.Set(context.Background(), key, &pCache.Value{
+		Code: 200,
+		Body: []byte(`{"title":"` + key + `"}`),
+	})
+	s.Require().NoError(err)
+	return key
+}
+
+func (s *InvalidationSuite) cached(c pCache.Cache, key string) bool {
+	_, err := c.Get(context.Background(), key)
+	if err != nil {
+		s.Require().ErrorIs(err, pErrors.ErrCacheMiss)
+		return false
+	}
+	return true
+}
+
+func (s *InvalidationSuite) evicted(c pCache.Cache, key string) {
+	s.Eventually(func() bool { return !s.cached(c, key) }, invalidationTimeout, invalidationTick, key)
+}
+
+func (s *InvalidationSuite) TestPropagation() {
+	first, second := s.instances[0], s.instances[1]
+	for _, inst := range s.instances {
+		s.set(inst.local, 1, 1)
+		s.set(inst.local, 2, 1)
+	}
+
+	err := first.bus.Invalidate(context.Background(), pCache.FeaturePattern(1, 1))
+	s.Require().NoError(err)
+
+	s.False(s.cached(first.local, pCache.Key(1, 1, 1, false)), "own entries are evicted synchronously")
+	s.evicted(second.local, pCache.Key(1, 1, 1, false))
+	s.True(s.cached(first.local, pCache.Key(1, 2, 1, false)))
+	s.True(s.cached(second.local, pCache.Key(1, 2, 1, false)))
+}
+
+func (s *InvalidationSuite) TestBadMessage() {
+	inst := s.instances[0]
+	key := s.set(inst.local, 1, 1)
+
+	err := inst.rdb.Publish(context.Background(), "banner:invalidation", "not json").Err()
+	s.Require().NoError(err)
+
+	s.evicted(inst.local, key)
+}
+
+func (s *InvalidationSuite) TestResubscribe() {
+	inst := s.instances[0]
+	key := s.set(inst.local, 1, 1)
+
+	// Messages published while disconnected are lost, so the local cache is
+	// flushed when the subscription is back.
+	s.server.dropConns()
+
+	s.evicted(inst.local, key)
+}
+
+func (s *InvalidationSuite) TestTieredGet() {
+	inst := s.instances[0]
+	key := s.set(s.remote, 1, 1)
+
+	s.True(s.cached(inst.cache, key))
+	s.True(s.cached(inst.local, key), "remote entries are kept locally")
+
+	_, err := inst.cache.Get(context.Background(), pCache.Key(1, 2, 1, false))
+	s.ErrorIs(err, pErrors.ErrCacheMiss)
+}
+
+func (s *InvalidationSuite) TestTieredDelete() {
+	first, second := s.instances[0], s.instances[1]
+	key := s.set(first.cache, 1, 1)
+	other := s.set(first.cache, 2, 1)
+	s.Require().True(s.cached(second.cache, key))
+
+	deleted, err := first.cache.Delete(context.Background(), pCache.FeaturePattern(1, 1))
+	s.Require().NoError(err)
+	s.Equal(int64(1), deleted)
+
+	s.False(s.cached(s.remote, key))
+	s.False(s.cached(first.local, key))
+	s.evicted(second.local, key)
+	s.False(s.cached(second.cache, key), "evicted entries aren't filled again from the remote cache")
+	s.True(s.cached(s.remote, other))
+}
+
+// failingDelete is a remote cache that can't delete keys.
+type failingDelete struct {
+	pCache.Cache
+}
+
+func (c failingDelete) Delete(context.Context, string) (int64, error) {
+	return 0, errors.New("remote is down")
+}
+
+func (s *InvalidationSuite) TestTieredDeleteRemoteError() {
+	first, second := s.instances[0], s.instances[1]
+	key := s.set(first.local, 1, 1)
+	s.set(second.local, 1, 1)
+	c := tieredCache.New(first.local, failingDelete{s.remote}, first.bus, s.log)
+
+	_, err := c.Delete(context.Background(), pCache.FeaturePattern(1, 1))
+	s.EqualError(err, "remote is down")
+
+	s.False(s.cached(first.local, key), "local entries are evicted anyway")
+	s.evicted(second.local, key)
+}
+
+func (s *InvalidationSuite) TestInvalidatingRepository() {
+	first, second := s.instances[0], s.instances[1]
+	repo := invalidatingRepository.New(bannerMemoryRepository.New(s.log), first.cache, s.log)
+	ctx := context.Background()
+
+	id, err := repo.Create(ctx, &pBannerRepo.CreateParams{
+		TagIDs:    []int64{1},
+		FeatureID: 1,
+		Content:   map[string]any{"title": "banner"},
+		IsActive:  true,
+	})
+	s.Require().NoError(err)
+
+	key := s.set(first.cache, 1, 1)
+	s.Require().True(s.cached(second.cache, key))
+
+	isActive := false
+	err = repo.PartialUpdate(ctx, &pBannerRepo.PartialUpdateParams{ID: id, IsActive: &isActive})
+	s.Require().NoError(err)
+	s.False(s.cached(s.remote, key))
+	s.evicted(second.local, key)
+
+	key = s.set(first.cache, 1, 1)
+	s.Require().True(s.cached(second.cache, key))
+
+	s.Require().NoError(repo.Delete(ctx, id))
+	s.False(s.cached(s.remote, key))
+	s.evicted(second.local, key)
+}
+
+func (s *InvalidationSuite) TestInvalidatingRepositoryMissingBanner() {
+	repo := invalidatingRepository.New(bannerMemoryRepository.New(s.log), s.instances[0].cache, s.log)
+	ctx := context.Background()
+
+	err := repo.PartialUpdate(ctx, &pBannerRepo.PartialUpdateParams{ID: 1})
+	s.NoError(err, "an empty update of a missing banner succeeds")
+
+	isActive := true
+	err = repo.PartialUpdate(ctx, &pBannerRepo.PartialUpdateParams{ID: 1, IsActive: &isActive})
+	s.ErrorIs(err, pErrors.ErrBannerNotFound)
+
+	err = repo.Delete(ctx, 1)
+	s.ErrorIs(err, pErrors.ErrBannerNotFound)
+}
+
+func TestInvalidationSuite(t *testing.T) {
+	suite.Run(t, new(InvalidationSuite))
+}
@@ -0,0 +1,326 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	cacheBackend "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/backend"
+	memcachedCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/memcached"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type MemcachedCacheSuite struct {
+	suite.Suite
+	log    *zap.Logger
+	server *standIn
+	client *memcache.Client
+	cache  pCache.Cache
+}
+
+func (s *MemcachedCacheSuite) SetupTest() {
+	s.log = pLog.NewDev()
+
+	var err error
+	s.server, err = startStandIn()
+	s.Require().NoError(err)
+	s.client = memcache.New(s.server.addr())
+	codec, err := pCache.NewCodec(pCache.CompressionNone, 0)
+	s.Require().NoError(err)
+	s.cache = memcachedCache.New(s.client, codec, s.log)
+}
+
+func (s *MemcachedCacheSuite) TearDownTest() {
+	_ = s.client.Close()
+	s.server.close()
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *MemcachedCacheSuite) set(tenantID, featureID, tagID int64) string {
+	key := pCache.Key(tenantID, featureID, tagID, false)
+	err := s.cache.Set(context.Background(), key, &pCache.Value{
+		Code: 200,
+		Body: []byte(`{"title":"` + key + `"}`),
+	})
+	s.Require().NoError(err)
+	return key
+}
+
+func (s *MemcachedCacheSuite) cached(key string) bool {
+	_, err := s.cache.Get(context.Background(), key)
+	if err != nil {
+		s.Require().ErrorIs(err, pErrors.ErrCacheMiss)
+		return false
+	}
+	return true
+}
+
+func (s *MemcachedCacheSuite) TestSetGet() {
+	ctx := context.Background()
+	key := s.set(1, 1, 2)
+
+	value, err := s.cache.Get(ctx, key)
+	s.Require().NoError(err)
+	s.Equal(200, value.Code)
+	s.JSONEq(`{"title":"`+key+`"}`, string(value.Body))
+
+	_, err = s.cache.Get(ctx, pCache.Key(1, 1, 2, true))
+	s.ErrorIs(err, pErrors.ErrCacheMiss)
+}
+
+func (s *MemcachedCacheSuite) TestTTL() {
+	ctx := context.Background()
+	key := s.set(1, 1, 2)
+
+	ttl, err := s.cache.TTL(ctx, key)
+	s.Require().NoError(err)
+	s.Greater(ttl, 4*time.Minute)
+	s.LessOrEqual(ttl, 5*time.Minute)
+
+	_, err = s.cache.TTL(ctx, pCache.Key(1, 3, 4, false))
+	s.ErrorIs(err, pErrors.ErrCacheMiss)
+}
+
+func (s *MemcachedCacheSuite) TestDelete() {
+	type testCase struct {
+		pattern string
+		deleted []bool
+	}
+
+	// Keys of tenant 1: (1, 1), (1, 2), (2, 1), (2, 2), and (1, 1) of tenant 2.
+	tests := map[string]testCase{
+		"feature": {
+			pattern: pCache.FeaturePattern(1, 1),
+			deleted: []bool{true, true, false, false, false},
+		},
+		"tag": {
+			pattern: pCache.TagPattern(1, 1),
+			deleted: []bool{true, false, true, false, false},
+		},
+		"feature and tag": {
+			pattern: pCache.FeatureTagPattern(1, 2, 1),
+			deleted: []bool{false, false, true, false, false},
+		},
+		"tenant": {
+			pattern: pCache.TenantPattern(2),
+			deleted: []bool{false, false, false, false, true},
+		},
+		"all": {
+			pattern: pCache.AllPattern(),
+			deleted: []bool{true, true, true, true, true},
+		},
+		"unknown pattern": {
+			pattern: "banner:1?:*",
+			deleted: []bool{true, true, true, true, true},
+		},
+	}
+
+	for name, test := range tests {
+		s.Run(name, func() {
+			keys := []string{s.set(1, 1, 1), s.set(1, 1, 2), s.set(1, 2, 1), s.set(1, 2, 2), s.set(2, 1, 1)}
+
+			deleted, err := s.cache.Delete(context.Background(), test.pattern)
+			s.Require().NoError(err)
+			s.Equal(int64(-1), deleted, "the number of invalidated keys is unknown")
+
+			for i, key := range keys {
+				s.Equal(!test.deleted[i], s.cached(key), key)
+			}
+		})
+	}
+}
+
+func (s *MemcachedCacheSuite) TestDeleteBeforeSet() {
+	_, err := s.cache.Delete(context.Background(), pCache.FeaturePattern(1, 1))
+	s.Require().NoError(err)
+
+	key := s.set(1, 1, 1)
+	s.True(s.cached(key))
+}
+
+func (s *MemcachedCacheSuite) TestUnavailable() {
+	s.server.close()
+	_ = s.client.Close()
+
+	_, err := s.cache.Get(context.Background(), pCache.Key(1, 1, 1, false))
+	s.Error(err)
+	s.NotErrorIs(err, pErrors.ErrCacheMiss)
+}
+
+func (s *MemcachedCacheSuite) TestBackend() {
+	viper.Set(config.MemcachedAddrs, []string{s.server.addr()})
+	defer viper.Set(config.MemcachedAddrs, nil)
+	codec, err := pCache.NewCodec(pCache.CompressionNone, 0)
+	s.Require().NoError(err)
+
+	remote, err := cacheBackend.New(config.BackendMemcached, nil, codec, s.log)
+	s.Require().NoError(err)
+	defer remote.Close() // nolint
+	s.NoError(remote.Ping(context.Background()))
+
+	key := s.set(1, 1, 1)
+	_, err = remote.Cache.Get(context.Background(), key)
+	s.NoError(err)
+
+	_, err = cacheBackend.New(config.BackendMemory, nil, codec, s.log)
+	s.Error(err)
+	_, err = cacheBackend.New(config.BackendRedis, nil, codec, s.log)
+	s.Error(err)
+}
+
+func TestMemcachedCacheSuite(t *testing.T) {
+	suite.Run(t, new(MemcachedCacheSuite))
+}
+
+// standIn implements the subset of the memcached text protocol used by the cache.
+type standIn struct {
+	listener net.Listener
+	mu       sync.Mutex
+	items    map[string]standInItem
+}
+
+type standInItem struct {
+	flags     uint32
+	value     []byte
+	expiresAt time.Time
+}
+
+func startStandIn() (*standIn, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	srv := &standIn{
+		listener: listener,
+		items:    make(map[string]standInItem),
+	}
+	go srv.serve()
+	return srv, nil
+}
+
+func (srv *standIn) addr() string {
+	return srv.listener.Addr().String()
+}
+
+func (srv *standIn) close() {
+	_ = srv.listener.Close()
+}
+
+func (srv *standIn) serve() {
+	for {
+		conn, err := srv.listener.Accept()
+		if err != nil {
+			return
+		}
+		go srv.handle(conn)
+	}
+}
+
+func (srv *standIn) handle(conn net.Conn) {
+	defer conn.Close() // nolint
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if err = srv.exec(rw, fields); err != nil {
+			return
+		}
+		if err = rw.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (srv *standIn) exec(rw *bufio.ReadWriter, fields []string) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	switch cmd := fields[0]; cmd {
+	case "get", "gets":
+		for _, key := range fields[1:] {
+			if item, ok := srv.get(key); ok {
+				fmt.Fprintf(rw, "VALUE %s %d %d 1\r\n%s\r\n", key, item.flags, len(item.value), item.value)
+			}
+		}
+		_, err := rw.WriteString("END\r\n")
+		return err
+	case "set", "add":
+		flags, _ := strconv.ParseUint(fields[2], 10, 32)
+		exptime, _ := strconv.Atoi(fields[3])
+		size, _ := strconv.Atoi(fields[4])
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(rw, value); err != nil {
+			return err
+		}
+		if _, exists := srv.get(fields[1]); cmd == "add" && exists {
+			_, err := rw.WriteString("NOT_STORED\r\n")
+			return err
+		}
+		item := standInItem{flags: uint32(flags), value: value[:size]}
+		if exptime > 0 {
+			item.expiresAt = time.Now().Add(time.Duration(exptime) * time.Second)
+		}
+		srv.items[fields[1]] = item
+		_, err := rw.WriteString("STORED\r\n")
+		return err
+	case "incr":
+		item, ok := srv.get(fields[1])
+		if !ok {
+			_, err := rw.WriteString("NOT_FOUND\r\n")
+			return err
+		}
+		n, _ := strconv.ParseUint(string(item.value), 10, 64)
+		delta, _ := strconv.ParseUint(fields[2], 10, 64)
+		item.value = []byte(strconv.FormatUint(n+delta, 10))
+		srv.items[fields[1]] = item
+		_, err := fmt.Fprintf(rw, "%s\r\n", item.value)
+		return err
+	case "delete":
+		if _, ok := srv.get(fields[1]); !ok {
+			_, err := rw.WriteString("NOT_FOUND\r\n")
+			return err
+		}
+		delete(srv.items, fields[1])
+		_, err := rw.WriteString("DELETED\r\n")
+		return err
+	case "version":
+		_, err := rw.WriteString("VERSION stand-in\r\n")
+		return err
+	default:
+		_, err := rw.WriteString("ERROR\r\n")
+		return err
+	}
+}
+
+func (srv *standIn) get(key string) (standInItem, bool) {
+	item, ok := srv.items[key]
+	if ok && !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		delete(srv.items, key)
+		return standInItem{}, false
+	}
+	return item, ok
+}
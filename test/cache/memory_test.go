@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	memoryCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/memory"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const memoryExpiration = 20 * time.Millisecond
+
+type MemoryCacheSuite struct {
+	suite.Suite
+	log   *zap.Logger
+	cache pCache.Cache
+}
+
+func (s *MemoryCacheSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.cache = memoryCache.NewWithExpiration(memoryExpiration, s.log)
+}
+
+func (s *MemoryCacheSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *MemoryCacheSuite) set(featureID int64) string {
+	key := pCache.Key(1, featureID, 1, false)
+	s.Require().NoError(s.cache.Set(context.Background(), key, &pCache.Value{Code: 200}))
+	return key
+}
+
+func (s *MemoryCacheSuite) TestExpiration() {
+	key := s.set(1)
+	_, err := s.cache.Get(context.Background(), key)
+	s.Require().NoError(err)
+
+	time.Sleep(2 * memoryExpiration)
+	_, err = s.cache.Get(context.Background(), key)
+	s.ErrorIs(err, pErrors.ErrCacheMiss)
+}
+
+func (s *MemoryCacheSuite) TestSweep() {
+	s.set(1)
+	s.set(2)
+
+	time.Sleep(2 * memoryExpiration)
+	s.set(3)
+
+	// Delete counts the entries left in the map, expired ones included.
+	deleted, err := s.cache.Delete(context.Background(), pCache.AllPattern())
+	s.Require().NoError(err)
+	s.Equal(int64(1), deleted, "entries never read again are swept on writes")
+}
+
+func TestMemoryCacheSuite(t *testing.T) {
+	suite.Run(t, new(MemoryCacheSuite))
+}
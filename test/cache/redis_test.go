@@ -0,0 +1,435 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/breaker"
+	redisCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/redis"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const clusterSlots = 16384
+
+type RedisCacheSuite struct {
+	suite.Suite
+	log   *zap.Logger
+	codec *pCache.Codec
+	nodes []*redisStandIn
+}
+
+func (s *RedisCacheSuite) SetupTest() {
+	s.log = pLog.NewDev()
+
+	var err error
+	s.codec, err = pCache.NewCodec(pCache.CompressionNone, 0)
+	s.Require().NoError(err)
+	s.nodes = nil
+	for i := 0; i < 2; i++ {
+		node, err := startRedisStandIn()
+		s.Require().NoError(err)
+		s.nodes = append(s.nodes, node)
+	}
+}
+
+func (s *RedisCacheSuite) TearDownTest() {
+	for _, node := range s.nodes {
+		node.close()
+	}
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// set stores the key on the node directly, whatever its hash slot is.
+func (s *RedisCacheSuite) set(node *redisStandIn, tenantID, featureID, tagID int64) string {
+	rdb := redis.NewClient(&redis.Options{Addr: node.addr()})
+	defer rdb.Close() // nolint
+
+	key := pCache.Key(tenantID, featureID, tagID, false)
+	err := redisCache.New(rdb, s.codec, s.log).Set(context.Background(), key, &pCache.Value{Code: 200})
+	s.Require().NoError(err)
+	return key
+}
+
+func (s *RedisCacheSuite) stored(node *redisStandIn, key string) bool {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	_, ok := node.get(key)
+	return ok
+}
+
+func (s *RedisCacheSuite) TestUser() {
+	host, port, err := net.SplitHostPort(s.nodes[0].addr())
+	s.Require().NoError(err)
+	viper.Set(config.RedisMode, config.RedisModeStandalone)
+	viper.Set(config.RedisHost, host)
+	viper.Set(config.RedisPort, port)
+	viper.Set(config.RedisUser, "moderator")
+	viper.Set(config.RedisPassword, "2222")
+	defer func() {
+		for _, key := range []string{config.RedisMode, config.RedisHost, config.RedisPort, config.RedisUser, config.RedisPassword} {
+			viper.Set(key, nil)
+		}
+	}()
+
+	rdb := storage.NewRedisClient(s.log)
+	defer rdb.Close() // nolint
+	s.Require().NoError(rdb.Ping(context.Background()).Err())
+	s.Equal([]string{"moderator", "2222"}, s.nodes[0].lastAuth())
+}
+
+func (s *RedisCacheSuite) TestClusterDelete() {
+	cluster(s.nodes...)
+	rdb := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{s.nodes[0].addr()}})
+	defer rdb.Close() // nolint
+	c := redisCache.New(rdb, s.codec, s.log)
+
+	keys := []string{s.set(s.nodes[0], 1, 1, 1), s.set(s.nodes[1], 1, 1, 2), s.set(s.nodes[1], 1, 2, 1),
+		s.set(s.nodes[1], 2, 1, 1)}
+
+	deleted, err := c.Delete(context.Background(), pCache.FeaturePattern(1, 1))
+	s.Require().NoError(err)
+	s.Equal(int64(2), deleted, "keys are deleted on every master")
+	s.False(s.stored(s.nodes[0], keys[0]))
+	s.False(s.stored(s.nodes[1], keys[1]))
+	s.True(s.stored(s.nodes[1], keys[2]))
+	s.True(s.stored(s.nodes[1], keys[3]))
+
+	deleted, err = c.Delete(context.Background(), pCache.TenantPattern(1))
+	s.Require().NoError(err)
+	s.Equal(int64(1), deleted)
+	s.False(s.stored(s.nodes[1], keys[2]))
+	s.True(s.stored(s.nodes[1], keys[3]))
+}
+
+func (s *RedisCacheSuite) TestClusterDeleteUnavailableNode() {
+	cluster(s.nodes...)
+	rdb := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{s.nodes[0].addr()}, MaxRetries: -1})
+	defer rdb.Close() // nolint
+	c := redisCache.New(rdb, s.codec, s.log)
+
+	s.set(s.nodes[0], 1, 1, 1)
+	s.nodes[1].close()
+
+	_, err := c.Delete(context.Background(), pCache.FeaturePattern(1, 1))
+	s.Error(err)
+	s.NotErrorIs(err, pErrors.ErrCacheMiss)
+}
+
+func (s *RedisCacheSuite) TestCorruptedValue() {
+	rdb := redis.NewClient(&redis.Options{Addr: s.nodes[0].addr()})
+	defer rdb.Close() // nolint
+	c := breaker.New(redisCache.New(rdb, s.codec, s.log), func(context.Context) error { return nil },
+		breakerThreshold, breakerProbeInterval, s.log)
+
+	// Current version, zstd compression, code 200 and a body that isn't zstd.
+	key := pCache.Key(1, 1, 1, false)
+	s.Require().NoError(rdb.Set(context.Background(), key, []byte{1, 1, 0, 200, 'x', 'x'}, 0).Err())
+
+	for i := 0; i < 2*breakerThreshold; i++ {
+		_, err := c.Get(context.Background(), key)
+		s.Require().ErrorIs(err, pErrors.ErrCacheMiss, "corrupted entries aren't failures of the cache")
+	}
+
+	s.Require().NoError(c.Set(context.Background(), key, &pCache.Value{Code: 200}))
+	value, err := c.Get(context.Background(), key)
+	s.Require().NoError(err)
+	s.Equal(200, value.Code)
+}
+
+func TestRedisCacheSuite(t *testing.T) {
+	suite.Run(t, new(RedisCacheSuite))
+}
+
+// redisStandIn implements the subset of RESP2 used by the cache and the
+// invalidation bus. HELLO is refused, so clients fall back to RESP2.
+type redisStandIn struct {
+	listener net.Listener
+	mu       sync.Mutex
+	items    map[string]standInItem
+	conns    map[*redisConn]struct{}
+	// auth holds the arguments of the last AUTH command.
+	auth []string
+	// slots is the reply to CLUSTER SLOTS, nodes don't redirect keys of other slots.
+	slots string
+}
+
+type redisConn struct {
+	conn     net.Conn
+	mu       sync.Mutex
+	w        *bufio.Writer
+	channels map[string]struct{}
+}
+
+func startRedisStandIn() (*redisStandIn, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	srv := &redisStandIn{
+		listener: listener,
+		items:    make(map[string]standInItem),
+		conns:    make(map[*redisConn]struct{}),
+	}
+	go srv.serve()
+	return srv, nil
+}
+
+func (srv *redisStandIn) addr() string {
+	return srv.listener.Addr().String()
+}
+
+func (srv *redisStandIn) close() {
+	_ = srv.listener.Close()
+	srv.dropConns()
+}
+
+// cluster assigns hash slots evenly to the nodes.
+func cluster(nodes ...*redisStandIn) {
+	var slots strings.Builder
+	fmt.Fprintf(&slots, "*%d\r\n", len(nodes))
+	size := clusterSlots / len(nodes)
+	for i, node := range nodes {
+		end := (i+1)*size - 1
+		if i == len(nodes)-1 {
+			end = clusterSlots - 1
+		}
+		host, port, _ := net.SplitHostPort(node.addr())
+		fmt.Fprintf(&slots, "*3\r\n:%d\r\n:%d\r\n*2\r\n%s:%s\r\n", i*size, end, bulk(host), port)
+	}
+	for _, node := range nodes {
+		node.mu.Lock()
+		node.slots = slots.String()
+		node.mu.Unlock()
+	}
+}
+
+func (srv *redisStandIn) lastAuth() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return srv.auth
+}
+
+// dropConns closes connections of all clients, keeping the data.
+func (srv *redisStandIn) dropConns() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	for c := range srv.conns {
+		_ = c.conn.Close()
+	}
+}
+
+func (srv *redisStandIn) serve() {
+	for {
+		conn, err := srv.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &redisConn{
+			conn:     conn,
+			w:        bufio.NewWriter(conn),
+			channels: make(map[string]struct{}),
+		}
+		srv.mu.Lock()
+		srv.conns[c] = struct{}{}
+		srv.mu.Unlock()
+		go srv.handle(c)
+	}
+}
+
+func (srv *redisStandIn) handle(c *redisConn) {
+	defer func() {
+		srv.mu.Lock()
+		delete(srv.conns, c)
+		srv.mu.Unlock()
+		_ = c.conn.Close()
+	}()
+	r := bufio.NewReader(c.conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if err = srv.exec(c, args); err != nil {
+			return
+		}
+	}
+}
+
+// readCommand reads an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		if line, err = r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		arg := make([]byte, size+2)
+		if _, err = io.ReadFull(r, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+	return args, nil
+}
+
+func (srv *redisStandIn) exec(c *redisConn, args []string) error {
+	reply := &strings.Builder{}
+	var subscribers []*redisConn
+
+	srv.mu.Lock()
+	switch cmd := strings.ToUpper(args[0]); cmd {
+	case "AUTH":
+		srv.auth = args[1:]
+		reply.WriteString("+OK\r\n")
+	case "CLUSTER":
+		if srv.slots == "" || strings.ToUpper(args[1]) != "SLOTS" {
+			reply.WriteString("-ERR This instance has cluster support disabled\r\n")
+		} else {
+			reply.WriteString(srv.slots)
+		}
+	case "PING":
+		if len(c.channels) > 0 {
+			reply.WriteString("*2\r\n$4\r\npong\r\n$0\r\n\r\n")
+		} else {
+			reply.WriteString("+PONG\r\n")
+		}
+	case "SUBSCRIBE":
+		for _, channel := range args[1:] {
+			c.channels[channel] = struct{}{}
+			fmt.Fprintf(reply, "*3\r\n%s%s:%d\r\n", bulk("subscribe"), bulk(channel), len(c.channels))
+		}
+	case "UNSUBSCRIBE":
+		for _, channel := range args[1:] {
+			delete(c.channels, channel)
+			fmt.Fprintf(reply, "*3\r\n%s%s:%d\r\n", bulk("unsubscribe"), bulk(channel), len(c.channels))
+		}
+	case "PUBLISH":
+		for other := range srv.conns {
+			if _, ok := other.channels[args[1]]; ok {
+				subscribers = append(subscribers, other)
+			}
+		}
+		fmt.Fprintf(reply, ":%d\r\n", len(subscribers))
+	case "SET":
+		item := standInItem{value: []byte(args[2])}
+		for i := 3; i+1 < len(args); i += 2 {
+			n, _ := strconv.Atoi(args[i+1])
+			switch strings.ToUpper(args[i]) {
+			case "EX":
+				item.expiresAt = time.Now().Add(time.Duration(n) * time.Second)
+			case "PX":
+				item.expiresAt = time.Now().Add(time.Duration(n) * time.Millisecond)
+			}
+		}
+		srv.items[args[1]] = item
+		reply.WriteString("+OK\r\n")
+	case "GET":
+		if item, ok := srv.get(args[1]); ok {
+			reply.WriteString(bulk(string(item.value)))
+		} else {
+			reply.WriteString("$-1\r\n")
+		}
+	case "TTL":
+		item, ok := srv.get(args[1])
+		switch {
+		case !ok:
+			reply.WriteString(":-2\r\n")
+		case item.expiresAt.IsZero():
+			reply.WriteString(":-1\r\n")
+		default:
+			fmt.Fprintf(reply, ":%d\r\n", int64(time.Until(item.expiresAt).Seconds()))
+		}
+	case "SCAN":
+		// All keys are returned by the first call.
+		pattern := "*"
+		for i := 2; i+1 < len(args); i += 2 {
+			if strings.ToUpper(args[i]) == "MATCH" {
+				pattern = args[i+1]
+			}
+		}
+		var keys []string
+		for key := range srv.items {
+			if _, ok := srv.get(key); !ok {
+				continue
+			}
+			if matched, _ := path.Match(pattern, key); matched {
+				keys = append(keys, key)
+			}
+		}
+		fmt.Fprintf(reply, "*2\r\n%s*%d\r\n", bulk("0"), len(keys))
+		for _, key := range keys {
+			reply.WriteString(bulk(key))
+		}
+	case "UNLINK", "DEL":
+		var deleted int
+		for _, key := range args[1:] {
+			if _, ok := srv.get(key); ok {
+				delete(srv.items, key)
+				deleted++
+			}
+		}
+		fmt.Fprintf(reply, ":%d\r\n", deleted)
+	default:
+		fmt.Fprintf(reply, "-ERR unknown command '%s'\r\n", cmd)
+	}
+	srv.mu.Unlock()
+
+	for _, other := range subscribers {
+		_ = other.write(fmt.Sprintf("*3\r\n%s%s%s", bulk("message"), bulk(args[1]), bulk(args[2])))
+	}
+	return c.write(reply.String())
+}
+
+func (srv *redisStandIn) get(key string) (standInItem, bool) {
+	item, ok := srv.items[key]
+	if ok && !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		delete(srv.items, key)
+		return standInItem{}, false
+	}
+	return item, ok
+}
+
+func (c *redisConn) write(data string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.w.WriteString(data); err != nil {
+		return err
+	}
+	return c.w.Flush()
+}
+
+func bulk(s string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
+}
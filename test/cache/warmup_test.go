@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"sync"
+	"testing"
+	"time"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	memoryCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/memory"
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	bannerMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/memory"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/warmup"
+	"github.com/SlavaShagalov/avito-intern-task/internal/health"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// slowCache holds every Set until release is closed or ctx is done, and
+// tracks how many of them run at once.
+type slowCache struct {
+	pCache.Cache
+	release chan struct{}
+
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (c *slowCache) Set(ctx context.Context, key string, value *pCache.Value) error {
+	c.mu.Lock()
+	c.running++
+	if c.running > c.peak {
+		c.peak = c.running
+	}
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.running--
+		c.mu.Unlock()
+	}()
+
+	select {
+	case <-c.release:
+		return c.Cache.Set(ctx, key, value)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *slowCache) maxRunning() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.peak
+}
+
+type WarmupSuite struct {
+	suite.Suite
+	log   *zap.Logger
+	repo  pBannerRepo.Repository
+	cache pCache.Cache
+}
+
+func (s *WarmupSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.repo = bannerMemoryRepository.New(s.log)
+	s.cache = memoryCache.New(s.log)
+}
+
+func (s *WarmupSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *WarmupSuite) create(ctx context.Context, featureID int64, tagIDs []int64, isActive bool) {
+	_, err := s.repo.Create(ctx, &pBannerRepo.CreateParams{
+		TagIDs:    tagIDs,
+		FeatureID: featureID,
+		Content:   map[string]any{"title": "banner"},
+		IsActive:  isActive,
+	})
+	s.Require().NoError(err)
+}
+
+func (s *WarmupSuite) cached(key string) bool {
+	_, err := s.cache.Get(context.Background(), key)
+	if err != nil {
+		s.Require().ErrorIs(err, pErrors.ErrCacheMiss)
+		return false
+	}
+	return true
+}
+
+func (s *WarmupSuite) TestRun() {
+	ctx := context.Background()
+	s.create(ctx, 1, []int64{1, 2}, true)
+	s.create(ctx, 2, []int64{1}, false)
+	s.create(tenant.WithID(ctx, 2), 1, []int64{1}, true)
+
+	s.Require().NoError(warmup.New(s.repo, s.cache, 2, s.log).Run(ctx))
+
+	for _, isAdmin := range []bool{false, true} {
+		s.True(s.cached(pCache.Key(1, 1, 1, isAdmin)))
+		s.True(s.cached(pCache.Key(1, 1, 2, isAdmin)))
+		s.True(s.cached(pCache.Key(2, 1, 1, isAdmin)), "banners of all tenants are cached")
+		s.False(s.cached(pCache.Key(1, 2, 1, isAdmin)), "inactive banners aren't cached")
+	}
+
+	value, err := s.cache.Get(ctx, pCache.Key(1, 1, 1, false))
+	s.Require().NoError(err)
+	s.Equal(200, value.Code)
+	s.JSONEq(`{"title":"banner"}`, string(value.Body))
+}
+
+func (s *WarmupSuite) TestConcurrency() {
+	const concurrency = 3
+	ctx := context.Background()
+	s.create(ctx, 1, []int64{1, 2, 3, 4, 5}, true)
+
+	slow := &slowCache{Cache: s.cache, release: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		done <- warmup.New(s.repo, slow, concurrency, s.log).Run(ctx)
+	}()
+
+	s.Eventually(func() bool { return slow.maxRunning() == concurrency }, time.Second, time.Millisecond)
+	close(slow.release)
+	s.Require().NoError(<-done)
+	s.Equal(concurrency, slow.maxRunning())
+	s.True(s.cached(pCache.Key(1, 1, 5, true)))
+}
+
+func (s *WarmupSuite) TestTimeout() {
+	const timeout = 50 * time.Millisecond
+	s.create(context.Background(), 1, []int64{1, 2}, true)
+
+	slow := &slowCache{Cache: s.cache, release: make(chan struct{})}
+	readiness := health.NewReadiness(time.Second)
+	done := make(chan struct{})
+	start := time.Now()
+	go func() {
+		defer close(done)
+		warmup.New(s.repo, slow, 1, s.log).WarmUp(context.Background(), timeout, readiness)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		s.FailNow("warm-up isn't interrupted by the timeout")
+	}
+	s.GreaterOrEqual(time.Since(start), timeout)
+	s.True(readiness.IsReady(), "the instance serves with a cold cache")
+	s.False(s.cached(pCache.Key(1, 1, 1, false)))
+}
+
+func (s *WarmupSuite) TestReadiness() {
+	s.create(context.Background(), 1, []int64{1}, true)
+
+	slow := &slowCache{Cache: s.cache, release: make(chan struct{})}
+	readiness := health.NewReadiness(time.Second)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		warmup.New(s.repo, slow, 1, s.log).WarmUp(context.Background(), time.Minute, readiness)
+	}()
+
+	s.Eventually(func() bool { return slow.maxRunning() > 0 }, time.Second, time.Millisecond)
+	s.False(readiness.IsReady(), "the instance isn't ready during warm-up")
+
+	close(slow.release)
+	<-done
+	s.True(readiness.IsReady())
+	s.True(s.cached(pCache.Key(1, 1, 1, false)))
+}
+
+func TestWarmupSuite(t *testing.T) {
+	suite.Run(t, new(WarmupSuite))
+}
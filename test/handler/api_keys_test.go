@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type createdKey struct {
+	ID         int64      `json:"api_key_id"`
+	Key        string     `json:"key"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type APIKeyHandlersSuite struct {
+	suite.Suite
+	log        *zap.Logger
+	router     http.Handler
+	adminToken string
+}
+
+func (s *APIKeyHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+
+	api := newAPI(s.T(), s.log, apiOptions{})
+	api.createUser("admin", models.RoleAdmin)
+	_, err := api.bannerRepo.Create(context.Background(), &pBannerRepo.CreateParams{
+		TagIDs:    []int64{1},
+		FeatureID: 1,
+		Content:   map[string]any{"title": "inactive"},
+		IsActive:  false,
+	})
+	s.Require().NoError(err)
+	s.router = api.handler
+
+	rec := s.do(http.MethodPost, "/api/v1/auth/signin", nil, map[string]string{
+		"username": "admin",
+		"password": password,
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.adminToken = rec.Header().Get("token")
+}
+
+func (s *APIKeyHandlersSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *APIKeyHandlersSuite) do(method, target string, headers map[string]string, body any) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&reqBody).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &reqBody)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *APIKeyHandlersSuite) admin() map[string]string {
+	return map[string]string{"token": s.adminToken}
+}
+
+func (s *APIKeyHandlersSuite) create(body map[string]any) createdKey {
+	rec := s.do(http.MethodPost, "/api/v1/api_keys", s.admin(), body)
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var key createdKey
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &key))
+	s.Require().NotEmpty(key.Key)
+	return key
+}
+
+func (s *APIKeyHandlersSuite) list() []createdKey {
+	rec := s.do(http.MethodGet, "/api/v1/api_keys", s.admin(), nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var keys []createdKey
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &keys))
+	return keys
+}
+
+func (s *APIKeyHandlersSuite) TestAuthenticate() {
+	key := s.create(map[string]any{"name": "service", "scopes": []string{models.PermissionBannerRead}})
+	const target = "/api/v1/user_banner?feature_id=1&tag_id=1"
+
+	rec := s.do(http.MethodGet, target, map[string]string{"X-API-Key": key.Key}, nil)
+	s.Equal(http.StatusOK, rec.Code, "key with banner:read sees inactive banners")
+	rec = s.do(http.MethodGet, target, map[string]string{"Authorization": "ApiKey " + key.Key}, nil)
+	s.Equal(http.StatusOK, rec.Code)
+	rec = s.do(http.MethodGet, "/api/v1/banner", map[string]string{"X-API-Key": key.Key}, nil)
+	s.Equal(http.StatusOK, rec.Code)
+	rec = s.do(http.MethodGet, "/api/v1/api_keys", map[string]string{"X-API-Key": key.Key}, nil)
+	s.Equal(http.StatusForbidden, rec.Code, "scopes limit the key")
+
+	keys := s.list()
+	s.Require().Len(keys, 1)
+	s.Empty(keys[0].Key, "key can't be read after creation")
+	s.NotNil(keys[0].LastUsedAt)
+
+	rec = s.do(http.MethodGet, target, map[string]string{"X-API-Key": key.Key + "x"}, nil)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+	rec = s.do(http.MethodPost, "/api/v1/auth/logout", map[string]string{"X-API-Key": key.Key}, nil)
+	s.Equal(http.StatusUnauthorized, rec.Code, "API keys have no session")
+
+	rec = s.do(http.MethodGet, "/api/v1/auth/me", map[string]string{"X-API-Key": key.Key}, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var me struct {
+		UserID      int64    `json:"user_id"`
+		APIKeyID    int64    `json:"api_key_id"`
+		Permissions []string `json:"permissions"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &me))
+	s.Zero(me.UserID)
+	s.Equal(key.ID, me.APIKeyID)
+	s.Equal([]string{models.PermissionBannerRead}, me.Permissions)
+}
+
+func (s *APIKeyHandlersSuite) TestRevoke() {
+	key := s.create(map[string]any{"name": "service", "scopes": []string{models.PermissionBannerRead}})
+
+	rec := s.do(http.MethodDelete, fmt.Sprintf("/api/v1/api_keys/%d", key.ID), s.admin(), nil)
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+	rec = s.do(http.MethodDelete, fmt.Sprintf("/api/v1/api_keys/%d", key.ID), s.admin(), nil)
+	s.Equal(http.StatusNotFound, rec.Code)
+
+	rec = s.do(http.MethodGet, "/api/v1/banner", map[string]string{"X-API-Key": key.Key}, nil)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+	s.NotNil(s.list()[0].RevokedAt)
+}
+
+func (s *APIKeyHandlersSuite) TestCreateValidation() {
+	type testCase struct {
+		body map[string]any
+		code int
+	}
+
+	tests := map[string]testCase{
+		"no name": {
+			body: map[string]any{"scopes": []string{models.PermissionBannerRead}},
+			code: http.StatusBadRequest,
+		},
+		"no scopes": {
+			body: map[string]any{"name": "service"},
+			code: http.StatusBadRequest,
+		},
+		"unknown scope": {
+			body: map[string]any{"name": "service", "scopes": []string{"banner:everything"}},
+			code: http.StatusBadRequest,
+		},
+		"scope not held by creator": {
+			body: map[string]any{"name": "service", "scopes": []string{models.PermissionTeamManage}},
+			code: http.StatusForbidden,
+		},
+		"expired": {
+			body: map[string]any{
+				"name":       "service",
+				"scopes":     []string{models.PermissionBannerRead},
+				"expires_at": time.Now().Add(-time.Hour),
+			},
+			code: http.StatusBadRequest,
+		},
+	}
+
+	for name, test := range tests {
+		s.Run(name, func() {
+			rec := s.do(http.MethodPost, "/api/v1/api_keys", s.admin(), test.body)
+			s.Equal(test.code, rec.Code)
+		})
+	}
+}
+
+func TestAPIKeyHandlersSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyHandlersSuite))
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	pAPIKey "github.com/SlavaShagalov/avito-intern-task/internal/apikey"
+	apiKeyDelivery "github.com/SlavaShagalov/avito-intern-task/internal/apikey/delivery/http"
+	apiKeyMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/apikey/repository/memory"
+	apiKeyUsecase "github.com/SlavaShagalov/avito-intern-task/internal/apikey/usecase"
+	memoryAttempts "github.com/SlavaShagalov/avito-intern-task/internal/auth/attempts/memory"
+	authDelivery "github.com/SlavaShagalov/avito-intern-task/internal/auth/delivery/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist"
+	memoryDenyList "github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist/memory"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+	keyringDelivery "github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring/delivery/http"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/mtls"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/policy"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/totp"
+	authUsecase "github.com/SlavaShagalov/avito-intern-task/internal/auth/usecase"
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	cacheDelivery "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/delivery/http"
+	memoryCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/memory"
+	bannerDelivery "github.com/SlavaShagalov/avito-intern-task/internal/banner/delivery/http"
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	bannerMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/memory"
+	bannerUsecase "github.com/SlavaShagalov/avito-intern-task/internal/banner/usecase"
+	inviteMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/invite/repository/memory"
+	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/background"
+	pHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher"
+	bcryptHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher/bcrypt"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	resetTokenMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/resettoken/repository/memory"
+	sessionMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/session/repository/memory"
+	signingKeyMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/signingkey/repository/memory"
+	pTeam "github.com/SlavaShagalov/avito-intern-task/internal/team"
+	teamDelivery "github.com/SlavaShagalov/avito-intern-task/internal/team/delivery/http"
+	teamMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/team/repository/memory"
+	teamUsecase "github.com/SlavaShagalov/avito-intern-task/internal/team/usecase"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	tenantDelivery "github.com/SlavaShagalov/avito-intern-task/internal/tenant/delivery/http"
+	tenantMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/tenant/repository/memory"
+	tenantUsecase "github.com/SlavaShagalov/avito-intern-task/internal/tenant/usecase"
+	twoFactorMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/twofactor/repository/memory"
+	pUser "github.com/SlavaShagalov/avito-intern-task/internal/user"
+	userDelivery "github.com/SlavaShagalov/avito-intern-task/internal/user/delivery/http"
+	userMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/user/repository/memory"
+	userUsecase "github.com/SlavaShagalov/avito-intern-task/internal/user/usecase"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const password = "password"
+
+var (
+	hashPasswordOnce sync.Once
+	hashedPassword   string
+)
+
+// apiOptions replace parts of the API built by newAPI, zero fields keep the defaults.
+type apiOptions struct {
+	credentials *policy.Policy
+	denyList    denylist.DenyList
+	hasher      pHasher.Hasher
+	keys        *keyring.Keyring
+	otp         *totp.TOTP
+	services    *mtls.Services
+	// trustedProxies report client addresses of sign in requests.
+	trustedProxies pHTTP.TrustedProxies
+	usersRepo      pUser.Repository
+	apiKeysRepo    pAPIKey.Repository
+	bannerRepo     pBannerRepo.Repository
+	cache          pCache.Cache
+}
+
+// api is wired like cmd/api, with in-memory storages and cache.
+type api struct {
+	t           *testing.T
+	handler     http.Handler
+	usersRepo   pUser.Repository
+	bannerRepo  pBannerRepo.Repository
+	teamsRepo   pTeam.Repository
+	cache       pCache.Cache
+	cacheStats  *pCache.Stats
+	cacheWrites *background.Group
+	keys        *keyring.Keyring
+}
+
+func newAPI(t *testing.T, log *zap.Logger, opts apiOptions) *api {
+	if opts.credentials == nil {
+		opts.credentials = policy.New(policy.Config{})
+	}
+	if opts.hasher == nil {
+		opts.hasher = bcryptHasher.New()
+	}
+	tenantsRepo := tenantMemoryRepository.New(log)
+	if opts.keys == nil {
+		opts.keys = newKeyring(t, tenantsRepo, keyring.Config{}, log)
+	}
+	if opts.denyList == nil {
+		opts.denyList = memoryDenyList.New()
+	}
+	if opts.otp == nil {
+		opts.otp = totp.New(totp.Config{})
+	}
+	if opts.usersRepo == nil {
+		opts.usersRepo = userMemoryRepository.New(log)
+	}
+	if opts.apiKeysRepo == nil {
+		opts.apiKeysRepo = apiKeyMemoryRepository.New(log)
+	}
+	if opts.bannerRepo == nil {
+		opts.bannerRepo = bannerMemoryRepository.New(log)
+	}
+	if opts.cache == nil {
+		opts.cache = memoryCache.New(log)
+	}
+
+	a := &api{
+		t:           t,
+		usersRepo:   opts.usersRepo,
+		bannerRepo:  opts.bannerRepo,
+		teamsRepo:   teamMemoryRepository.New(log),
+		cache:       opts.cache,
+		cacheStats:  pCache.NewStats(),
+		cacheWrites: new(background.Group),
+		keys:        opts.keys,
+	}
+
+	apiKeyUC := apiKeyUsecase.New(opts.apiKeysRepo, time.Minute, log)
+	authUC := authUsecase.New(a.usersRepo, sessionMemoryRepository.New(log), resetTokenMemoryRepository.New(log),
+		inviteMemoryRepository.New(log), twoFactorMemoryRepository.New(log), opts.denyList, memoryAttempts.New(),
+		opts.credentials, opts.hasher, a.keys, opts.otp, log)
+	checkAuth := mw.NewCheckAuth(opts.denyList, apiKeyUC, a.keys, opts.services, log)
+	checkPermission := mw.NewCheckPermission(log)
+
+	router := mux.NewRouter()
+	authDelivery.RegisterHandlers(router, authUC, opts.trustedProxies, log, checkAuth)
+	keyringDelivery.RegisterHandlers(router, a.keys, log)
+	bannerDelivery.RegisterHandlers(router, bannerUsecase.New(a.bannerRepo, a.teamsRepo, log), a.cache, a.cacheStats,
+		a.cacheWrites, log, checkAuth, checkPermission)
+	cacheDelivery.RegisterHandlers(router, a.cache, a.cacheStats, log, checkAuth, checkPermission)
+	teamDelivery.RegisterHandlers(router, teamUsecase.New(a.teamsRepo, log), log, checkAuth, checkPermission)
+	apiKeyDelivery.RegisterHandlers(router, apiKeyUC, log, checkAuth, checkPermission)
+	userDelivery.RegisterHandlers(router, userUsecase.New(a.usersRepo, authUC, log), log, checkAuth, checkPermission)
+	tenantDelivery.RegisterHandlers(router, tenantUsecase.New(tenantsRepo, a.usersRepo, opts.credentials,
+		opts.hasher, a.keys, log), log, checkAuth, checkPermission)
+	a.handler = mw.NewTenant()(router)
+
+	return a
+}
+
+// encryptionKey encrypts signing keys of test keyrings.
+var encryptionKey = bytes.Repeat([]byte{1}, keyring.EncryptionKeySize)
+
+// newKeyring creates a keyring with signing keys of the tenants in memory.
+func newKeyring(t *testing.T, tenants tenant.Repository, cfg keyring.Config, log *zap.Logger) *keyring.Keyring {
+	if cfg.EncryptionKey == nil {
+		cfg.EncryptionKey = encryptionKey
+	}
+	keys, err := keyring.New(signingKeyMemoryRepository.New(log), tenants, cfg, log)
+	require.NoError(t, err)
+	require.NoError(t, keys.Rotate(context.Background()))
+	return keys
+}
+
+// createUser creates a user of the default tenant with the test password.
+func (a *api) createUser(username, role string) *models.User {
+	return a.createTenantUser(tenant.DefaultID, username, role)
+}
+
+func (a *api) createTenantUser(tenantID int64, username, role string) *models.User {
+	hashPasswordOnce.Do(func() {
+		var err error
+		hashedPassword, err = bcryptHasher.New().GetHashedPassword(context.Background(), password)
+		require.NoError(a.t, err)
+	})
+
+	user, err := a.usersRepo.Create(tenant.WithID(context.Background(), tenantID), &pUser.CreateParams{
+		Username: username,
+		Password: hashedPassword,
+		Role:     role,
+	})
+	require.NoError(a.t, err)
+	return user
+}
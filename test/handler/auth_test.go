@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// Any authenticated endpoint.
+const protectedTarget = "/api/v1/user_banner?feature_id=1&tag_id=1"
+
+type tokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type AuthHandlersSuite struct {
+	suite.Suite
+	log    *zap.Logger
+	router http.Handler
+	keys   *keyring.Keyring
+	userID int64
+}
+
+func (s *AuthHandlersSuite) SetupSuite() {
+	s.log = pLog.NewDev()
+
+	api := newAPI(s.T(), s.log, apiOptions{})
+	s.userID = api.createUser("user", "").ID
+	s.keys = api.keys
+	s.router = api.handler
+}
+
+func (s *AuthHandlersSuite) TearDownSuite() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *AuthHandlersSuite) do(method, target, token string, body any) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&reqBody).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &reqBody)
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *AuthHandlersSuite) decode(rec *httptest.ResponseRecorder) tokens {
+	var t tokens
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &t))
+	s.Require().NotEmpty(t.AccessToken)
+	s.Require().NotEmpty(t.RefreshToken)
+	s.Equal(t.AccessToken, rec.Header().Get("token"))
+	return t
+}
+
+func (s *AuthHandlersSuite) signIn() tokens {
+	rec := s.do(http.MethodPost, "/api/v1/auth/signin", "", map[string]string{
+		"username": "user",
+		"password": password,
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	return s.decode(rec)
+}
+
+func (s *AuthHandlersSuite) refresh(refreshToken string) *httptest.ResponseRecorder {
+	return s.do(http.MethodPost, "/api/v1/auth/refresh", "", map[string]string{
+		"refresh_token": refreshToken,
+	})
+}
+
+func (s *AuthHandlersSuite) authorized(accessToken string) bool {
+	rec := s.do(http.MethodGet, protectedTarget, accessToken, nil)
+	return rec.Code != http.StatusUnauthorized
+}
+
+func (s *AuthHandlersSuite) TestRefreshRotatesTokens() {
+	first := s.signIn()
+
+	rec := s.refresh(first.RefreshToken)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	second := s.decode(rec)
+	s.NotEqual(first.RefreshToken, second.RefreshToken)
+	s.True(s.authorized(second.AccessToken))
+
+	rec = s.refresh(second.RefreshToken)
+	s.Require().Equal(http.StatusOK, rec.Code)
+}
+
+func (s *AuthHandlersSuite) TestRefreshTokenReuseRevokesSession() {
+	first := s.signIn()
+
+	rec := s.refresh(first.RefreshToken)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	second := s.decode(rec)
+
+	rec = s.refresh(first.RefreshToken)
+	s.Equal(http.StatusUnauthorized, rec.Code, "reused refresh token must be rejected")
+
+	rec = s.refresh(second.RefreshToken)
+	s.Equal(http.StatusUnauthorized, rec.Code, "refresh tokens of the revoked session must be rejected")
+	s.False(s.authorized(first.AccessToken), "access tokens of the revoked session must be rejected")
+	s.False(s.authorized(second.AccessToken), "access tokens of the revoked session must be rejected")
+}
+
+func (s *AuthHandlersSuite) TestLogout() {
+	current := s.signIn()
+	other := s.signIn()
+
+	rec := s.do(http.MethodPost, "/api/v1/auth/logout", current.AccessToken, nil)
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+
+	s.False(s.authorized(current.AccessToken))
+	rec = s.refresh(current.RefreshToken)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	s.True(s.authorized(other.AccessToken), "other sessions must stay valid")
+}
+
+func (s *AuthHandlersSuite) TestInvalidRefreshToken() {
+	rec := s.refresh("unknown")
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/auth/refresh", "", map[string]string{})
+	s.Equal(http.StatusBadRequest, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/auth/logout", "", nil)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+}
+
+func (s *AuthHandlersSuite) TestDenyListUnavailable() {
+	api := newAPI(s.T(), s.log, apiOptions{denyList: unavailableDenyList{}})
+	api.createUser("user", "")
+	router := s.router
+	s.router = api.handler
+	defer func() { s.router = router }()
+
+	accessToken := s.signIn().AccessToken
+	rec := s.do(http.MethodGet, "/api/v1/auth/me", accessToken, nil)
+	s.Equal(http.StatusServiceUnavailable, rec.Code, "revoked tokens can't be told apart from valid ones")
+	rec = s.do(http.MethodGet, protectedTarget, accessToken, nil)
+	s.Equal(http.StatusNotFound, rec.Code, "banners are still served")
+}
+
+func (s *AuthHandlersSuite) TestBearer() {
+	accessToken := s.signIn().AccessToken
+
+	for _, scheme := range []string{"Bearer ", "bearer "} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/me", nil)
+		req.Header.Set("Authorization", scheme+accessToken)
+		rec := httptest.NewRecorder()
+		s.router.ServeHTTP(rec, req)
+		s.Equal(http.StatusOK, rec.Code, scheme)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/me", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken+"x")
+	req.Header.Set("token", accessToken)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	s.Equal(http.StatusUnauthorized, rec.Code, "Authorization header takes precedence")
+}
+
+func (s *AuthHandlersSuite) TestMe() {
+	rec := s.do(http.MethodGet, "/api/v1/auth/me", s.signIn().AccessToken, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var me struct {
+		UserID      int64      `json:"user_id"`
+		Username    string     `json:"username"`
+		Role        string     `json:"role"`
+		Permissions []string   `json:"permissions"`
+		SessionID   int64      `json:"session_id"`
+		ExpiresAt   *time.Time `json:"expires_at"`
+		APIKeyID    int64      `json:"api_key_id"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &me))
+	s.Equal(s.userID, me.UserID)
+	s.Equal("user", me.Username)
+	s.Equal(models.RoleUser, me.Role)
+	s.NotNil(me.Permissions)
+	s.Empty(me.Permissions, "users can only get active banners")
+	s.NotZero(me.SessionID)
+	s.NotNil(me.ExpiresAt)
+	s.Zero(me.APIKeyID)
+
+	rec = s.do(http.MethodGet, "/api/v1/auth/me", "", nil)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+}
+
+func (s *AuthHandlersSuite) TestClaimsValidation() {
+	valid := func() *auth.Claims {
+		now := time.Now()
+		return &auth.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        "token",
+				Issuer:    auth.DefaultTokenIssuer,
+				Audience:  jwt.ClaimStrings{auth.DefaultTokenAudience},
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+			UserID:    s.userID,
+			Role:      models.RoleUser,
+			SessionID: 1,
+		}
+	}
+
+	tests := map[string]func(c *auth.Claims){
+		"other issuer":   func(c *auth.Claims) { c.Issuer = "other" },
+		"other audience": func(c *auth.Claims) { c.Audience = jwt.ClaimStrings{"other"} },
+		"no expiration":  func(c *auth.Claims) { c.ExpiresAt = nil },
+		"expired":        func(c *auth.Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) },
+		"no token id":    func(c *auth.Claims) { c.ID = "" },
+		"no session":     func(c *auth.Claims) { c.SessionID = 0 },
+		"no user":        func(c *auth.Claims) { c.UserID = 0 },
+	}
+
+	token, err := s.keys.Sign(valid())
+	s.Require().NoError(err)
+	s.True(s.authorized(token))
+
+	for name, modify := range tests {
+		s.Run(name, func() {
+			claims := valid()
+			modify(claims)
+			token, err := s.keys.Sign(claims)
+			s.Require().NoError(err)
+			s.False(s.authorized(token))
+		})
+	}
+}
+
+func TestAuthHandlersSuite(t *testing.T) {
+	suite.Run(t, new(AuthHandlersSuite))
+}
+
+// unavailableDenyList fails like the Redis deny list when Redis is down.
+type unavailableDenyList struct{}
+
+func (unavailableDenyList) Add(context.Context, string, time.Duration) error {
+	return errors.New("connection refused")
+}
+
+func (unavailableDenyList) Contains(context.Context, ...string) (bool, error) {
+	return false, errors.New("connection refused")
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/background"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type BannerHandlersSuite struct {
+	suite.Suite
+	log         *zap.Logger
+	router      http.Handler
+	cache       pCache.Cache
+	cacheWrites *background.Group
+	adminToken  string
+	editorToken string
+	viewerToken string
+	userToken   string
+}
+
+func (s *BannerHandlersSuite) SetupSuite() {
+	ctx := context.Background()
+	s.log = pLog.NewDev()
+
+	api := newAPI(s.T(), s.log, apiOptions{})
+	api.createUser("admin", models.RoleSuperAdmin)
+	editor := api.createUser("editor", models.RoleEditor)
+	api.createUser("viewer", models.RoleViewer)
+	api.createUser("user", models.RoleUser)
+
+	team, err := api.teamsRepo.Create(ctx, "editors")
+	s.Require().NoError(err)
+	s.Require().NoError(api.teamsRepo.AddMember(ctx, team.ID, editor.ID))
+	s.Require().NoError(api.teamsRepo.AddFeature(ctx, team.ID, 10))
+
+	s.cache = api.cache
+	s.cacheWrites = api.cacheWrites
+	s.router = api.handler
+
+	s.adminToken = s.signIn("admin")
+	s.editorToken = s.signIn("editor")
+	s.viewerToken = s.signIn("viewer")
+	s.userToken = s.signIn("user")
+}
+
+func (s *BannerHandlersSuite) TearDownSuite() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *BannerHandlersSuite) do(method, target, token string, body any) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&reqBody).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &reqBody)
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *BannerHandlersSuite) signIn(username string) string {
+	rec := s.do(http.MethodPost, "/api/v1/auth/signin", "", map[string]string{
+		"username": username,
+		"password": password,
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	return rec.Header().Get("token")
+}
+
+func (s *BannerHandlersSuite) TestUserBanner() {
+	rec := s.do(http.MethodPost, "/api/v1/banner", s.adminToken, map[string]any{
+		"tag_ids":    []int64{1, 2},
+		"feature_id": 1,
+		"content":    map[string]any{"title": "active"},
+		"is_active":  true,
+	})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/banner", s.adminToken, map[string]any{
+		"tag_ids":    []int64{3},
+		"feature_id": 1,
+		"content":    map[string]any{"title": "inactive"},
+		"is_active":  false,
+	})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/banner", s.adminToken, map[string]any{
+		"tag_ids":    []int64{2},
+		"feature_id": 1,
+		"content":    map[string]any{"title": "duplicate"},
+		"is_active":  true,
+	})
+	s.Equal(http.StatusBadRequest, rec.Code, "banner with such feature and tag must be rejected")
+
+	type testCase struct {
+		target string
+		token  string
+		code   int
+		body   map[string]any
+	}
+
+	tests := map[string]testCase{
+		"active banner": {
+			target: "/api/v1/user_banner?feature_id=1&tag_id=2",
+			token:  s.userToken,
+			code:   http.StatusOK,
+			body:   map[string]any{"title": "active"},
+		},
+		"inactive banner for user": {
+			target: "/api/v1/user_banner?feature_id=1&tag_id=3",
+			token:  s.userToken,
+			code:   http.StatusForbidden,
+		},
+		"inactive banner for admin": {
+			target: "/api/v1/user_banner?feature_id=1&tag_id=3&use_last_revision=true",
+			token:  s.adminToken,
+			code:   http.StatusOK,
+			body:   map[string]any{"title": "inactive"},
+		},
+		"banner not found": {
+			target: "/api/v1/user_banner?feature_id=2&tag_id=1",
+			token:  s.userToken,
+			code:   http.StatusNotFound,
+		},
+		"no token": {
+			target: "/api/v1/user_banner?feature_id=1&tag_id=2",
+			code:   http.StatusUnauthorized,
+		},
+	}
+
+	for name, test := range tests {
+		s.Run(name, func() {
+			rec := s.do(http.MethodGet, test.target, test.token, nil)
+			s.Equal(test.code, rec.Code, "unexpected status code")
+			if test.body != nil {
+				var body map[string]any
+				s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &body))
+				s.Equal(test.body, body, "incorrect content")
+			}
+		})
+	}
+}
+
+func (s *BannerHandlersSuite) TestListRequiresAdmin() {
+	rec := s.do(http.MethodGet, "/api/v1/banner", s.userToken, nil)
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	rec = s.do(http.MethodGet, "/api/v1/banner", s.adminToken, nil)
+	s.Equal(http.StatusOK, rec.Code)
+}
+
+func (s *BannerHandlersSuite) TestRolePermissions() {
+	rec := s.do(http.MethodGet, "/api/v1/banner", s.viewerToken, nil)
+	s.Equal(http.StatusOK, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/banner", s.viewerToken, map[string]any{
+		"tag_ids":    []int64{10},
+		"feature_id": 10,
+		"content":    map[string]any{"title": "viewer"},
+	})
+	s.Equal(http.StatusForbidden, rec.Code, "viewer can't create banners")
+
+	rec = s.do(http.MethodPost, "/api/v1/banner", s.editorToken, map[string]any{
+		"tag_ids":    []int64{10},
+		"feature_id": 10,
+		"content":    map[string]any{"title": "editor"},
+		"is_active":  true,
+	})
+	s.Equal(http.StatusForbidden, rec.Code, "editor can't publish banners")
+
+	rec = s.do(http.MethodPost, "/api/v1/banner", s.editorToken, map[string]any{
+		"tag_ids":    []int64{10},
+		"feature_id": 10,
+		"content":    map[string]any{"title": "editor"},
+	})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var created struct {
+		BannerID int64 `json:"banner_id"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &created))
+	target := "/api/v1/banner/" + strconv.FormatInt(created.BannerID, 10)
+
+	rec = s.do(http.MethodPatch, target, s.editorToken, map[string]any{
+		"content": map[string]any{"title": "edited"},
+	})
+	s.Equal(http.StatusOK, rec.Code)
+
+	rec = s.do(http.MethodPatch, target, s.editorToken, map[string]any{"is_active": true})
+	s.Equal(http.StatusForbidden, rec.Code, "editor can't publish banners")
+
+	rec = s.do(http.MethodDelete, target, s.editorToken, nil)
+	s.Equal(http.StatusForbidden, rec.Code, "editor can't delete banners")
+
+	rec = s.do(http.MethodDelete, target, s.adminToken, nil)
+	s.Equal(http.StatusNoContent, rec.Code)
+}
+
+func (s *BannerHandlersSuite) TestCacheWritesDrained() {
+	rec := s.do(http.MethodPost, "/api/v1/banner", s.adminToken, map[string]any{
+		"tag_ids":    []int64{20},
+		"feature_id": 20,
+		"content":    map[string]any{"title": "cached"},
+		"is_active":  true,
+	})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+
+	rec = s.do(http.MethodGet, "/api/v1/user_banner?feature_id=20&tag_id=20", s.userToken, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.Require().NoError(s.cacheWrites.Wait(ctx))
+
+	value, err := s.cache.Get(context.Background(), pCache.Key(tenant.DefaultID, 20, 20, false))
+	s.Require().NoError(err)
+	s.Equal(http.StatusOK, value.Code)
+}
+
+func TestBannerHandlersSuite(t *testing.T) {
+	suite.Run(t, new(BannerHandlersSuite))
+}
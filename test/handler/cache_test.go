@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	pCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache"
+	memoryCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/memory"
+	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type cacheEntry struct {
+	Key        string          `json:"key"`
+	Code       int             `json:"code"`
+	Body       json.RawMessage `json:"body"`
+	TTLSeconds float64         `json:"ttl_seconds"`
+}
+
+// expiringCache loses entries right after they are read.
+type expiringCache struct {
+	pCache.Cache
+}
+
+func (c expiringCache) TTL(context.Context, string) (time.Duration, error) {
+	return 0, pErrors.ErrCacheMiss
+}
+
+// uncountedCache can't count deleted keys, like the memcached one.
+type uncountedCache struct {
+	pCache.Cache
+}
+
+func (c uncountedCache) Delete(ctx context.Context, pattern string) (int64, error) {
+	_, err := c.Cache.Delete(ctx, pattern)
+	return -1, err
+}
+
+type CacheHandlersSuite struct {
+	suite.Suite
+	log        *zap.Logger
+	api        *api
+	adminToken string
+	userToken  string
+	// tenantID and tenantToken belong to the admin of another tenant.
+	tenantID    int64
+	tenantToken string
+}
+
+func (s *CacheHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.setupAPI(apiOptions{})
+}
+
+func (s *CacheHandlersSuite) setupAPI(opts apiOptions) {
+	s.api = newAPI(s.T(), s.log, opts)
+	s.api.createUser("admin", models.RoleAdmin)
+	s.api.createUser("user", models.RoleUser)
+	s.api.createUser("superadmin", models.RoleSuperAdmin)
+	s.adminToken = s.signIn("", "admin")
+	s.userToken = s.signIn("", "user")
+
+	rec := s.do(http.MethodPost, "/api/v1/tenants", s.signIn("", "superadmin"), map[string]string{
+		"name":           "acme",
+		"admin_username": "owner",
+		"admin_password": password,
+	})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var created struct {
+		ID int64 `json:"tenant_id"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &created))
+	s.tenantID = created.ID
+	s.tenantToken = s.signIn(strconv.FormatInt(s.tenantID, 10), "owner")
+}
+
+func (s *CacheHandlersSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *CacheHandlersSuite) do(method, target, token string, body any) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&reqBody).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &reqBody)
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	rec := httptest.NewRecorder()
+	s.api.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *CacheHandlersSuite) signIn(tenantID, username string) string {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signin",
+		bytes.NewBufferString(fmt.Sprintf(`{"username":%q,"password":%q}`, username, password)))
+	if tenantID != "" {
+		req.Header.Set(mw.TenantHeader, tenantID)
+	}
+	rec := httptest.NewRecorder()
+	s.api.handler.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	return rec.Header().Get("token")
+}
+
+func (s *CacheHandlersSuite) set(tenantID, featureID, tagID int64) string {
+	key := pCache.Key(tenantID, featureID, tagID, false)
+	err := s.api.cache.Set(context.Background(), key, &pCache.Value{
+		Code: http.StatusOK,
+		Body: []byte(`{"title":"` + key + `"}`),
+	})
+	s.Require().NoError(err)
+	return key
+}
+
+func (s *CacheHandlersSuite) cached(key string) bool {
+	_, err := s.api.cache.Get(context.Background(), key)
+	return err == nil
+}
+
+func (s *CacheHandlersSuite) flush(query, token string) int64 {
+	rec := s.do(http.MethodDelete, "/api/v1/cache"+query, token, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var response struct {
+		Deleted int64 `json:"deleted"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	return response.Deleted
+}
+
+func (s *CacheHandlersSuite) TestEntry() {
+	key := s.set(tenant.DefaultID, 1, 2)
+
+	rec := s.do(http.MethodGet, "/api/v1/cache/entry?feature_id=1&tag_id=2", s.adminToken, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var entry cacheEntry
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &entry))
+	s.Equal(key, entry.Key)
+	s.Equal(http.StatusOK, entry.Code)
+	s.JSONEq(`{"title":"`+key+`"}`, string(entry.Body))
+	s.Greater(entry.TTLSeconds, float64(0))
+	s.LessOrEqual(entry.TTLSeconds, memoryCache.DefaultExpiration.Seconds())
+
+	rec = s.do(http.MethodGet, "/api/v1/cache/entry?feature_id=1&tag_id=2&is_admin=true", s.adminToken, nil)
+	s.Equal(http.StatusNotFound, rec.Code)
+	rec = s.do(http.MethodGet, "/api/v1/cache/entry?feature_id=1&tag_id=2", s.tenantToken, nil)
+	s.Equal(http.StatusNotFound, rec.Code, "entries of other tenants aren't visible")
+
+	for _, query := range []string{"tag_id=2", "feature_id=x&tag_id=2", "feature_id=1", "feature_id=1&tag_id=2&is_admin=x"} {
+		rec = s.do(http.MethodGet, "/api/v1/cache/entry?"+query, s.adminToken, nil)
+		s.Equal(http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func (s *CacheHandlersSuite) TestEntryExpiredAfterRead() {
+	s.setupAPI(apiOptions{cache: expiringCache{Cache: memoryCache.New(s.log)}})
+	s.set(tenant.DefaultID, 1, 2)
+
+	rec := s.do(http.MethodGet, "/api/v1/cache/entry?feature_id=1&tag_id=2", s.adminToken, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var entry cacheEntry
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &entry))
+	s.Zero(entry.TTLSeconds)
+}
+
+func (s *CacheHandlersSuite) TestFlush() {
+	keys := []string{s.set(tenant.DefaultID, 1, 1), s.set(tenant.DefaultID, 1, 2), s.set(tenant.DefaultID, 2, 1),
+		s.set(tenant.DefaultID, 2, 2), s.set(s.tenantID, 1, 1)}
+
+	s.Equal(int64(1), s.flush("?feature_id=1&tag_id=2", s.adminToken))
+	s.False(s.cached(keys[1]))
+
+	s.Equal(int64(1), s.flush("?feature_id=1", s.adminToken))
+	s.False(s.cached(keys[0]))
+	s.True(s.cached(keys[4]), "entries of other tenants aren't flushed")
+
+	s.Equal(int64(1), s.flush("?tag_id=2", s.adminToken))
+	s.False(s.cached(keys[3]))
+
+	s.Equal(int64(1), s.flush("", s.adminToken))
+	s.False(s.cached(keys[2]))
+	s.True(s.cached(keys[4]), "entries of other tenants aren't flushed")
+
+	s.Equal(int64(1), s.flush("", s.tenantToken))
+	s.False(s.cached(keys[4]))
+
+	for _, query := range []string{"?feature_id=x", "?tag_id=x"} {
+		rec := s.do(http.MethodDelete, "/api/v1/cache"+query, s.adminToken, nil)
+		s.Equal(http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func (s *CacheHandlersSuite) TestFlushUnknownCount() {
+	s.setupAPI(apiOptions{cache: uncountedCache{Cache: memoryCache.New(s.log)}})
+	key := s.set(tenant.DefaultID, 1, 1)
+
+	rec := s.do(http.MethodDelete, "/api/v1/cache", s.adminToken, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.JSONEq(`{}`, rec.Body.String())
+	s.False(s.cached(key))
+}
+
+func (s *CacheHandlersSuite) TestStats() {
+	s.api.cacheStats.Hit()
+	s.api.cacheStats.Hit()
+	s.api.cacheStats.Hit()
+	s.api.cacheStats.Miss()
+	s.api.cacheStats.Error()
+
+	rec := s.do(http.MethodGet, "/api/v1/cache/stats", s.adminToken, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.JSONEq(`{"hits":3,"misses":1,"errors":1,"hit_ratio":0.75}`, rec.Body.String())
+}
+
+func (s *CacheHandlersSuite) TestPermissions() {
+	key := s.set(tenant.DefaultID, 1, 1)
+
+	requests := map[string]string{
+		"entry": "/api/v1/cache/entry?feature_id=1&tag_id=1",
+		"stats": "/api/v1/cache/stats",
+	}
+	for name, target := range requests {
+		rec := s.do(http.MethodGet, target, s.userToken, nil)
+		s.Equal(http.StatusForbidden, rec.Code, name)
+		rec = s.do(http.MethodGet, target, "", nil)
+		s.Equal(http.StatusUnauthorized, rec.Code, name)
+	}
+
+	rec := s.do(http.MethodDelete, "/api/v1/cache", s.userToken, nil)
+	s.Equal(http.StatusForbidden, rec.Code)
+	rec = s.do(http.MethodDelete, "/api/v1/cache", "", nil)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+	s.True(s.cached(key))
+}
+
+func TestCacheHandlersSuite(t *testing.T) {
+	suite.Run(t, new(CacheHandlersSuite))
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/health"
+	healthDelivery "github.com/SlavaShagalov/avito-intern-task/internal/health/delivery/http"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const healthCheckTimeout = 50 * time.Millisecond
+
+type HealthHandlersSuite struct {
+	suite.Suite
+	log *zap.Logger
+	// Errors returned by the dependencies, a nil error means the dependency is up.
+	postgresErr error
+	redisErr    error
+	redisDelay  time.Duration
+	readiness   *health.Readiness
+	router      *mux.Router
+}
+
+type readyResponse struct {
+	Status       string `json:"status"`
+	Dependencies []struct {
+		Name      string  `json:"name"`
+		Hard      bool    `json:"hard"`
+		Status    string  `json:"status"`
+		LatencyMs float64 `json:"latency_ms"`
+	} `json:"dependencies"`
+}
+
+func (s *HealthHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.postgresErr = nil
+	s.redisErr = nil
+	s.redisDelay = 0
+
+	s.readiness = health.NewReadiness(healthCheckTimeout,
+		health.Dependency{
+			Name: "postgres",
+			Hard: true,
+			Ping: func(ctx context.Context) error { return s.postgresErr },
+		},
+		health.Dependency{
+			Name: "redis",
+			Ping: func(ctx context.Context) error {
+				select {
+				case <-time.After(s.redisDelay):
+					return s.redisErr
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+		},
+	)
+	s.readiness.SetReady(true)
+
+	s.router = mux.NewRouter()
+	healthDelivery.RegisterHandlers(s.router, s.readiness, s.log)
+}
+
+func (s *HealthHandlersSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *HealthHandlersSuite) ready() (int, *readyResponse) {
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	var response readyResponse
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	return rec.Code, &response
+}
+
+// statuses maps dependencies to their statuses.
+func (s *HealthHandlersSuite) statuses(response *readyResponse) map[string]string {
+	statuses := make(map[string]string, len(response.Dependencies))
+	for _, dependency := range response.Dependencies {
+		statuses[dependency.Name] = dependency.Status
+	}
+	return statuses
+}
+
+func (s *HealthHandlersSuite) TestLive() {
+	s.readiness.SetReady(false)
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	s.Equal(http.StatusOK, rec.Code, "liveness doesn't depend on readiness")
+}
+
+func (s *HealthHandlersSuite) TestReady() {
+	code, response := s.ready()
+	s.Equal(http.StatusOK, code)
+	s.Equal("ready", response.Status)
+	s.Equal(map[string]string{"postgres": "up", "redis": "up"}, s.statuses(response))
+}
+
+func (s *HealthHandlersSuite) TestSoftDependencyDown() {
+	s.redisErr = errors.New("connection refused")
+
+	code, response := s.ready()
+	s.Equal(http.StatusOK, code)
+	s.Equal("degraded", response.Status)
+	s.Equal(map[string]string{"postgres": "up", "redis": "down"}, s.statuses(response))
+}
+
+func (s *HealthHandlersSuite) TestHardDependencyDown() {
+	s.postgresErr = errors.New("connection refused")
+	s.redisErr = errors.New("connection refused")
+
+	code, response := s.ready()
+	s.Equal(http.StatusServiceUnavailable, code)
+	s.Equal("not ready", response.Status)
+	s.Equal(map[string]string{"postgres": "down", "redis": "down"}, s.statuses(response))
+}
+
+func (s *HealthHandlersSuite) TestTimeout() {
+	s.redisDelay = time.Second
+
+	start := time.Now()
+	code, response := s.ready()
+	s.Less(time.Since(start), s.redisDelay)
+	s.Equal(http.StatusOK, code)
+	s.Equal("degraded", response.Status)
+	for _, dependency := range response.Dependencies {
+		if dependency.Name == "redis" {
+			s.GreaterOrEqual(dependency.LatencyMs, float64(healthCheckTimeout.Milliseconds()))
+		}
+	}
+}
+
+func (s *HealthHandlersSuite) TestNotReady() {
+	s.readiness.SetReady(false)
+
+	code, response := s.ready()
+	s.Equal(http.StatusServiceUnavailable, code)
+	s.Equal("not ready", response.Status)
+	s.Empty(response.Dependencies)
+}
+
+func (s *HealthHandlersSuite) TestDraining() {
+	s.readiness.Drain()
+	s.readiness.SetReady(true)
+
+	code, response := s.ready()
+	s.Equal(http.StatusServiceUnavailable, code, "warm-up finished during shutdown doesn't make the instance ready")
+	s.Equal("not ready", response.Status)
+}
+
+func TestHealthHandlersSuite(t *testing.T) {
+	suite.Run(t, new(HealthHandlersSuite))
+}
@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	signingKeyMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/signingkey/repository/memory"
+	tenantMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/tenant/repository/memory"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type jwk struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+}
+
+type JWKSHandlersSuite struct {
+	suite.Suite
+	log    *zap.Logger
+	router http.Handler
+	keys   *keyring.Keyring
+}
+
+func (s *JWKSHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+}
+
+func (s *JWKSHandlersSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// start creates the router with the keyring config.
+func (s *JWKSHandlersSuite) start(cfg keyring.Config) {
+	s.keys = newKeyring(s.T(), tenantMemoryRepository.New(s.log), cfg, s.log)
+	api := newAPI(s.T(), s.log, apiOptions{keys: s.keys})
+	api.createUser("admin", models.RoleAdmin)
+	s.router = api.handler
+}
+
+func (s *JWKSHandlersSuite) signIn() string {
+	body := strings.NewReader(`{"username": "admin", "password": "` + password + `"}`)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/signin", body))
+	s.Require().Equal(http.StatusOK, rec.Code)
+	return rec.Header().Get("token")
+}
+
+// authenticated makes a request which requires a valid token.
+func (s *JWKSHandlersSuite) authenticated(token string) int {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	req.Header.Set("token", token)
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func (s *JWKSHandlersSuite) jwks() []jwk {
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	var response struct {
+		Keys []jwk `json:"keys"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	return response.Keys
+}
+
+// verify checks the token offline with the published keys only.
+func (s *JWKSHandlersSuite) verify(token string) *jwt.Token {
+	keys := s.jwks()
+	parsed, err := jwt.Parse(token, func(token *jwt.Token) (interface{}, error) {
+		for _, key := range keys {
+			if key.KeyID != token.Header["kid"] {
+				continue
+			}
+			s.Equal("sig", key.Use)
+			s.Equal(key.Algorithm, token.Method.Alg())
+			switch key.KeyType {
+			case "RSA":
+				n, err := base64.RawURLEncoding.DecodeString(key.N)
+				s.Require().NoError(err)
+				e, err := base64.RawURLEncoding.DecodeString(key.E)
+				s.Require().NoError(err)
+				return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+			case "OKP":
+				s.Equal("Ed25519", key.Curve)
+				x, err := base64.RawURLEncoding.DecodeString(key.X)
+				s.Require().NoError(err)
+				return ed25519.PublicKey(x), nil
+			}
+		}
+		return nil, jwt.ErrTokenUnverifiable
+	})
+	s.Require().NoError(err)
+	return parsed
+}
+
+func (s *JWKSHandlersSuite) TestRS256() {
+	s.start(keyring.Config{})
+	token := s.signIn()
+
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	s.Equal("public, max-age=60", rec.Header().Get("Cache-Control"))
+
+	keys := s.jwks()
+	s.Require().Len(keys, 1)
+	s.Equal("RSA", keys[0].KeyType)
+	s.Equal(keyring.AlgorithmRS256, keys[0].Algorithm)
+
+	parsed := s.verify(token)
+	s.Equal(keys[0].KeyID, parsed.Header["kid"])
+	s.Equal(http.StatusOK, s.authenticated(token))
+}
+
+func (s *JWKSHandlersSuite) TestEdDSA() {
+	s.start(keyring.Config{Algorithm: keyring.AlgorithmEdDSA})
+	token := s.signIn()
+
+	keys := s.jwks()
+	s.Require().Len(keys, 1)
+	s.Equal("OKP", keys[0].KeyType)
+
+	parsed := s.verify(token)
+	s.Equal(keyring.AlgorithmEdDSA, parsed.Method.Alg())
+	s.Equal(http.StatusOK, s.authenticated(token))
+}
+
+func (s *JWKSHandlersSuite) TestForeignTokens() {
+	s.start(keyring.Config{})
+	kid := s.jwks()[0].KeyID
+
+	claims := jwt.MapClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(s.signIn(), claims)
+	s.Require().NoError(err)
+
+	sign := func(method jwt.SigningMethod, kid string, key any) string {
+		token := jwt.NewWithClaims(method, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		signed, err := token.SignedString(key)
+		s.Require().NoError(err)
+		return signed
+	}
+
+	foreignKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	s.Require().NoError(err)
+
+	tests := map[string]string{
+		"no kid":      sign(jwt.SigningMethodRS256, "", foreignKey),
+		"unknown kid": sign(jwt.SigningMethodRS256, "unknown", foreignKey),
+		"foreign key": sign(jwt.SigningMethodRS256, kid, foreignKey),
+		"hmac":        sign(jwt.SigningMethodHS256, kid, []byte(kid)),
+	}
+
+	for name, token := range tests {
+		s.Run(name, func() {
+			s.Equal(http.StatusUnauthorized, s.authenticated(token))
+		})
+	}
+}
+
+func (s *JWKSHandlersSuite) TestRotation() {
+	const rotation = 500 * time.Millisecond
+	s.start(keyring.Config{
+		Algorithm:        keyring.AlgorithmEdDSA,
+		RotationInterval: rotation,
+		ReloadInterval:   time.Nanosecond,
+		TokenTTL:         2 * rotation,
+	})
+	ctx := context.Background()
+
+	oldToken := s.signIn()
+	s.Require().NoError(s.keys.Rotate(ctx))
+	s.Len(s.jwks(), 1, "key isn't rotated before the interval")
+
+	time.Sleep(rotation + rotation/5)
+	s.Require().NoError(s.keys.Rotate(ctx))
+	s.Len(s.jwks(), 2)
+
+	newToken := s.signIn()
+	s.NotEqual(s.verify(oldToken).Header["kid"], s.verify(newToken).Header["kid"])
+	s.Equal(http.StatusOK, s.authenticated(oldToken), "old key verifies until it ages out")
+	s.Equal(http.StatusOK, s.authenticated(newToken))
+
+	time.Sleep(2 * rotation)
+	s.Require().NoError(s.keys.Rotate(ctx))
+	s.Equal(http.StatusUnauthorized, s.authenticated(oldToken))
+	s.Equal(http.StatusOK, s.authenticated(newToken))
+}
+
+func (s *JWKSHandlersSuite) TestNewTenantOnOtherReplica() {
+	ctx := context.Background()
+	signingKeys := signingKeyMemoryRepository.New(s.log)
+	tenants := tenantMemoryRepository.New(s.log)
+	first, err := keyring.New(signingKeys, tenants, keyring.Config{EncryptionKey: encryptionKey}, s.log)
+	s.Require().NoError(err)
+	s.Require().NoError(first.Rotate(ctx))
+	second, err := keyring.New(signingKeys, tenants, keyring.Config{EncryptionKey: encryptionKey}, s.log)
+	s.Require().NoError(err)
+	s.Require().NoError(second.Reload(ctx))
+
+	// The first replica creates a tenant and signs its tokens with the new key at once.
+	created, err := tenants.Create(ctx, "acme")
+	s.Require().NoError(err)
+	s.Require().NoError(first.Rotate(ctx))
+	token, err := first.Sign(&auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{ID: "jti", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))},
+		UserID:           1,
+		SessionID:        1,
+		TenantID:         created.ID,
+	})
+	s.Require().NoError(err)
+
+	_, err = jwt.ParseWithClaims(token, &auth.Claims{}, second.Keyfunc)
+	s.NoError(err, "the other replica reloads keys it doesn't know")
+	_, err = jwt.ParseWithClaims(token+"x", &auth.Claims{}, second.Keyfunc)
+	s.Error(err)
+}
+
+func (s *JWKSHandlersSuite) TestEncryptedKeys() {
+	ctx := context.Background()
+	signingKeys := signingKeyMemoryRepository.New(s.log)
+	tenants := tenantMemoryRepository.New(s.log)
+	keys, err := keyring.New(signingKeys, tenants, keyring.Config{EncryptionKey: encryptionKey}, s.log)
+	s.Require().NoError(err)
+	s.Require().NoError(keys.Rotate(ctx))
+
+	stored, err := signingKeys.List(ctx)
+	s.Require().NoError(err)
+	s.Require().Len(stored, 1)
+	_, err = x509.ParsePKCS8PrivateKey(stored[0].PrivateKey)
+	s.Error(err, "private keys aren't stored in plaintext")
+
+	otherKey := bytes.Repeat([]byte{2}, keyring.EncryptionKeySize)
+	other, err := keyring.New(signingKeys, tenants, keyring.Config{EncryptionKey: otherKey}, s.log)
+	s.Require().NoError(err)
+	s.Require().NoError(other.Reload(ctx))
+	s.Empty(other.JWKS(), "keys can't be read without the encryption key")
+
+	_, err = keyring.New(signingKeys, tenants, keyring.Config{}, s.log)
+	s.Error(err, "the encryption key is required")
+}
+
+func TestJWKSHandlersSuite(t *testing.T) {
+	suite.Run(t, new(JWKSHandlersSuite))
+}
@@ -0,0 +1,295 @@
+package handler
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/mtls"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// testCA issues certificates for the tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(s *suite.Suite) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	s.Require().NoError(err)
+	cert, err := x509.ParseCertificate(der)
+	s.Require().NoError(err)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(s *suite.Suite, template *x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	s.Require().NoError(err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	s.Require().NoError(err)
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	s.Require().NoError(err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func (ca *testCA) client(s *suite.Suite, template *x509.Certificate) tls.Certificate {
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	return ca.issue(s, template)
+}
+
+func writePEM(s *suite.Suite, path, blockType string, der []byte) {
+	s.Require().NoError(os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+}
+
+type MTLSHandlersSuite struct {
+	suite.Suite
+	log    *zap.Logger
+	ca     *testCA
+	server *httptest.Server
+}
+
+func (s *MTLSHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.ca = newTestCA(&s.Suite)
+}
+
+func (s *MTLSHandlersSuite) TearDownTest() {
+	if s.server != nil {
+		s.server.Close()
+		s.server = nil
+	}
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// start serves the API over TLS with the server certificate and the client CA
+// written to files, as they are configured in production.
+func (s *MTLSHandlersSuite) start(requireClientCert bool) {
+	dir := s.T().TempDir()
+
+	serverCert := s.ca.issue(&s.Suite, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "api"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	keyDER, err := x509.MarshalPKCS8PrivateKey(serverCert.PrivateKey)
+	s.Require().NoError(err)
+	cfg := mtls.Config{
+		CertFile:          filepath.Join(dir, "server.crt"),
+		KeyFile:           filepath.Join(dir, "server.key"),
+		ClientCAFile:      filepath.Join(dir, "ca.crt"),
+		RequireClientCert: requireClientCert,
+	}
+	writePEM(&s.Suite, cfg.CertFile, "CERTIFICATE", serverCert.Certificate[0])
+	writePEM(&s.Suite, cfg.KeyFile, "PRIVATE KEY", keyDER)
+	writePEM(&s.Suite, cfg.ClientCAFile, "CERTIFICATE", s.ca.cert.Raw)
+
+	tlsConfig, err := mtls.ServerTLSConfig(cfg)
+	s.Require().NoError(err)
+	services, err := mtls.NewServices([]mtls.Service{
+		{
+			Name:        "recommendations",
+			Subjects:    []string{"spiffe://internal/recommendations"},
+			Permissions: []string{models.PermissionBannerRead},
+		},
+		{
+			Name:        "backoffice",
+			Subjects:    []string{"backoffice.internal"},
+			Permissions: []string{models.PermissionBannerRead, models.PermissionUserManage},
+		},
+	})
+	s.Require().NoError(err)
+
+	api := newAPI(s.T(), s.log, apiOptions{services: services})
+	api.createUser("user", "")
+
+	s.server = httptest.NewUnstartedServer(api.handler)
+	s.server.TLS = tlsConfig
+	s.server.StartTLS()
+}
+
+// client trusts the server and presents the certificates.
+func (s *MTLSHandlersSuite) client(certs ...tls.Certificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(s.ca.cert)
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		Certificates: certs,
+	}}}
+}
+
+func (s *MTLSHandlersSuite) get(client *http.Client, path, token string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, s.server.URL+path, nil)
+	s.Require().NoError(err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err == nil {
+		s.T().Cleanup(func() { _ = resp.Body.Close() })
+	}
+	return resp, err
+}
+
+func (s *MTLSHandlersSuite) me(client *http.Client, token string) (int, map[string]any) {
+	resp, err := s.get(client, "/api/v1/auth/me", token)
+	s.Require().NoError(err)
+	var body map[string]any
+	if resp.StatusCode == http.StatusOK {
+		s.Require().NoError(json.NewDecoder(resp.Body).Decode(&body))
+	}
+	return resp.StatusCode, body
+}
+
+func (s *MTLSHandlersSuite) signIn() string {
+	resp, err := s.client().Post(s.server.URL+"/api/v1/auth/signin", "application/json",
+		strings.NewReader(`{"username": "user", "password": "`+password+`"}`))
+	s.Require().NoError(err)
+	defer resp.Body.Close()
+	s.Require().Equal(http.StatusOK, resp.StatusCode)
+	return resp.Header.Get("token")
+}
+
+func (s *MTLSHandlersSuite) TestServices() {
+	s.start(false)
+	spiffeID, err := url.Parse("spiffe://internal/recommendations")
+	s.Require().NoError(err)
+
+	tests := map[string]struct {
+		cert        *x509.Certificate
+		service     string
+		permissions []any
+		users       int
+	}{
+		"uri san": {
+			cert:        &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}, URIs: []*url.URL{spiffeID}},
+			service:     "recommendations",
+			permissions: []any{models.PermissionBannerRead},
+			users:       http.StatusForbidden,
+		},
+		"dns san": {
+			cert:        &x509.Certificate{DNSNames: []string{"backoffice.internal"}},
+			service:     "backoffice",
+			permissions: []any{models.PermissionBannerRead, models.PermissionUserManage},
+			users:       http.StatusOK,
+		},
+		"common name": {
+			cert:        &x509.Certificate{Subject: pkix.Name{CommonName: "backoffice.internal"}},
+			service:     "backoffice",
+			permissions: []any{models.PermissionBannerRead, models.PermissionUserManage},
+			users:       http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		s.Run(name, func() {
+			client := s.client(s.ca.client(&s.Suite, test.cert))
+			code, body := s.me(client, "")
+			s.Require().Equal(http.StatusOK, code)
+			s.Equal(test.service, body["service"])
+			s.Equal(test.permissions, body["permissions"])
+			s.NotContains(body, "user_id")
+
+			resp, err := s.get(client, "/api/v1/users", "")
+			s.Require().NoError(err)
+			s.Equal(test.users, resp.StatusCode)
+		})
+	}
+}
+
+func (s *MTLSHandlersSuite) TestUnknownCertificate() {
+	s.start(false)
+
+	code, _ := s.me(s.client(s.ca.client(&s.Suite, &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}})), "")
+	s.Equal(http.StatusUnauthorized, code, "verified certificate of an unknown service")
+
+	code, _ = s.me(s.client(), "")
+	s.Equal(http.StatusUnauthorized, code, "no certificate")
+
+	foreignCA := newTestCA(&s.Suite)
+	foreign := foreignCA.client(&s.Suite, &x509.Certificate{DNSNames: []string{"backoffice.internal"}})
+	_, err := s.get(s.client(foreign), "/api/v1/auth/me", "")
+	s.Error(err, "certificate of another CA fails the handshake")
+}
+
+func (s *MTLSHandlersSuite) TestTokenTakesPrecedence() {
+	s.start(false)
+	token := s.signIn()
+
+	client := s.client(s.ca.client(&s.Suite, &x509.Certificate{DNSNames: []string{"backoffice.internal"}}))
+	code, body := s.me(client, token)
+	s.Require().Equal(http.StatusOK, code)
+	s.Equal("user", body["username"])
+	s.NotContains(body, "service")
+
+	code, _ = s.me(client, "invalid")
+	s.Equal(http.StatusUnauthorized, code, "invalid token isn't replaced by the certificate")
+}
+
+func (s *MTLSHandlersSuite) TestRequireClientCert() {
+	s.start(true)
+
+	_, err := s.get(s.client(), "/api/v1/auth/me", "")
+	s.Error(err)
+
+	code, body := s.me(s.client(s.ca.client(&s.Suite, &x509.Certificate{DNSNames: []string{"backoffice.internal"}})), "")
+	s.Equal(http.StatusOK, code)
+	s.Equal("backoffice", body["service"])
+}
+
+func (s *MTLSHandlersSuite) TestInvalidServices() {
+	tests := map[string][]mtls.Service{
+		"no name":            {{Subjects: []string{"a"}}},
+		"no subjects":        {{Name: "a"}},
+		"unknown permission": {{Name: "a", Subjects: []string{"a"}, Permissions: []string{"banner:steal"}}},
+		"taken subject":      {{Name: "a", Subjects: []string{"a"}}, {Name: "b", Subjects: []string{"a"}}},
+	}
+
+	for name, services := range tests {
+		s.Run(name, func() {
+			_, err := mtls.NewServices(services)
+			s.Error(err)
+		})
+	}
+}
+
+func TestMTLSHandlersSuite(t *testing.T) {
+	suite.Run(t, new(MTLSHandlersSuite))
+}
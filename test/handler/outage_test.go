@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pAPIKey "github.com/SlavaShagalov/avito-intern-task/internal/apikey"
+	apiKeyMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/apikey/repository/memory"
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist"
+	cachedDenyList "github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist/cached"
+	memoryDenyList "github.com/SlavaShagalov/avito-intern-task/internal/auth/denylist/memory"
+	noopCache "github.com/SlavaShagalov/avito-intern-task/internal/banner/cache/noop"
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	bannerMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/memory"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/snapshot"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// outage makes the wrapped storages fail like unreachable Redis and Postgres.
+type outage struct {
+	down atomic.Bool
+}
+
+func (o *outage) err() error {
+	if o.down.Load() {
+		return pErrors.ErrDb
+	}
+	return nil
+}
+
+type outageDenyList struct {
+	denylist.DenyList
+	outage *outage
+}
+
+func (d outageDenyList) Add(ctx context.Context, key string, ttl time.Duration) error {
+	if err := d.outage.err(); err != nil {
+		return err
+	}
+	return d.DenyList.Add(ctx, key, ttl)
+}
+
+func (d outageDenyList) Contains(ctx context.Context, keys ...string) (bool, error) {
+	if err := d.outage.err(); err != nil {
+		return false, err
+	}
+	return d.DenyList.Contains(ctx, keys...)
+}
+
+type outageAPIKeys struct {
+	pAPIKey.Repository
+	outage *outage
+}
+
+func (r outageAPIKeys) GetByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	if err := r.outage.err(); err != nil {
+		return nil, err
+	}
+	return r.Repository.GetByHash(ctx, keyHash)
+}
+
+func (r outageAPIKeys) Touch(ctx context.Context, id int64, usedAt time.Time) error {
+	if err := r.outage.err(); err != nil {
+		return err
+	}
+	return r.Repository.Touch(ctx, id, usedAt)
+}
+
+type outageBanners struct {
+	pBannerRepo.Repository
+	outage *outage
+}
+
+func (r outageBanners) Get(ctx context.Context, params *pBannerRepo.GetParams) (*models.Banner, error) {
+	if err := r.outage.err(); err != nil {
+		return nil, err
+	}
+	return r.Repository.Get(ctx, params)
+}
+
+func (r outageBanners) List(ctx context.Context, params *pBannerRepo.FilterParams) ([]models.Banner, error) {
+	if err := r.outage.err(); err != nil {
+		return nil, err
+	}
+	return r.Repository.List(ctx, params)
+}
+
+// OutageSuite serves banners in snapshot mode while Redis and Postgres are
+// unreachable.
+type OutageSuite struct {
+	suite.Suite
+	log    *zap.Logger
+	outage *outage
+	api    *api
+	token  string
+	apiKey string
+}
+
+const outageTarget = "/api/v1/user_banner?feature_id=1&tag_id=1"
+
+func (s *OutageSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.outage = &outage{}
+	s.setupAPI(time.Minute)
+}
+
+// setupAPI builds the API with deny list answers kept for maxStale while Redis is down.
+func (s *OutageSuite) setupAPI(maxStale time.Duration) {
+	ctx := context.Background()
+	banners := outageBanners{Repository: bannerMemoryRepository.New(s.log), outage: s.outage}
+	_, err := banners.Create(ctx, &pBannerRepo.CreateParams{
+		TagIDs:    []int64{1},
+		FeatureID: 1,
+		Content:   map[string]any{"title": "banner"},
+		IsActive:  true,
+	})
+	s.Require().NoError(err)
+	snap := snapshot.New(banners, nil, time.Hour, s.log)
+	s.Require().NoError(snap.Reload(ctx))
+
+	s.api = newAPI(s.T(), s.log, apiOptions{
+		denyList: cachedDenyList.New(outageDenyList{DenyList: memoryDenyList.New(), outage: s.outage},
+			0, maxStale, s.log),
+		apiKeysRepo: outageAPIKeys{Repository: apiKeyMemoryRepository.New(s.log), outage: s.outage},
+		bannerRepo:  snap,
+		cache:       noopCache.New(),
+	})
+	s.api.createUser("admin", models.RoleAdmin)
+	s.api.createUser("user", models.RoleUser)
+
+	s.token = s.signIn("user")
+	rec := s.do(http.MethodPost, "/api/v1/api_keys", map[string]string{"token": s.signIn("admin")}, map[string]any{
+		"name":   "service",
+		"scopes": []string{models.PermissionBannerRead},
+	})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var created struct {
+		Key string `json:"key"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &created))
+	s.apiKey = created.Key
+}
+
+func (s *OutageSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *OutageSuite) do(method, target string, headers map[string]string, body any) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&reqBody).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &reqBody)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	s.api.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *OutageSuite) signIn(username string) string {
+	rec := s.do(http.MethodPost, "/api/v1/auth/signin", nil, map[string]string{
+		"username": username,
+		"password": password,
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	return rec.Header().Get("token")
+}
+
+func (s *OutageSuite) TestSnapshotServes() {
+	jwt := map[string]string{"token": s.token}
+	apiKey := map[string]string{"X-API-Key": s.apiKey}
+	for _, headers := range []map[string]string{jwt, apiKey} {
+		s.Require().Equal(http.StatusOK, s.do(http.MethodGet, outageTarget, headers, nil).Code)
+	}
+
+	s.outage.down.Store(true)
+	for i := 0; i < 3; i++ {
+		rec := s.do(http.MethodGet, outageTarget, jwt, nil)
+		s.Require().Equal(http.StatusOK, rec.Code)
+		s.JSONEq(`{"title":"banner"}`, rec.Body.String())
+		rec = s.do(http.MethodGet, outageTarget, apiKey, nil)
+		s.Require().Equal(http.StatusOK, rec.Code, "authenticated API keys are kept in memory")
+	}
+	s.Equal(http.StatusOK, s.do(http.MethodGet, "/api/v1/auth/me", jwt, nil).Code,
+		"deny list answers are kept while Redis is down")
+}
+
+func (s *OutageSuite) TestStaleDenyList() {
+	s.setupAPI(0)
+	jwt := map[string]string{"token": s.token}
+	s.outage.down.Store(true)
+
+	s.Equal(http.StatusServiceUnavailable, s.do(http.MethodGet, "/api/v1/auth/me", jwt, nil).Code)
+	s.Equal(http.StatusOK, s.do(http.MethodGet, outageTarget, jwt, nil).Code,
+		"banners are served with tokens that can't be checked")
+}
+
+func TestOutageSuite(t *testing.T) {
+	suite.Run(t, new(OutageSuite))
+}
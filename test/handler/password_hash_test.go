@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/keyring"
+	pHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher"
+	argon2idHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher/argon2id"
+	bcryptHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher/bcrypt"
+	multiHasher "github.com/SlavaShagalov/avito-intern-task/internal/pkg/hasher/multi"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	tenantMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/tenant/repository/memory"
+	pUser "github.com/SlavaShagalov/avito-intern-task/internal/user"
+	userMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/user/repository/memory"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+// Cheap parameters keep the tests fast.
+var testArgon2idParams = argon2idHasher.Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+type PasswordHashSuite struct {
+	suite.Suite
+	log       *zap.Logger
+	usersRepo pUser.Repository
+	keys      *keyring.Keyring
+	router    http.Handler
+}
+
+func (s *PasswordHashSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.usersRepo = userMemoryRepository.New(s.log)
+	s.keys = newKeyring(s.T(), tenantMemoryRepository.New(s.log), keyring.Config{}, s.log)
+}
+
+func (s *PasswordHashSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// start creates the router hashing new passwords with the current format.
+func (s *PasswordHashSuite) start(current multiHasher.Format, legacy ...multiHasher.Format) pHasher.Hasher {
+	hasher := multiHasher.New(current, legacy...)
+	s.router = newAPI(s.T(), s.log, apiOptions{hasher: hasher, keys: s.keys, usersRepo: s.usersRepo}).handler
+	return hasher
+}
+
+func (s *PasswordHashSuite) signIn(password string) int {
+	var body bytes.Buffer
+	s.Require().NoError(json.NewEncoder(&body).Encode(map[string]string{
+		"username": "user",
+		"password": password,
+	}))
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/auth/signin", &body))
+	return rec.Code
+}
+
+func (s *PasswordHashSuite) storedHash() string {
+	user, err := s.usersRepo.GetByUsername(context.Background(), "user")
+	s.Require().NoError(err)
+	return user.Password
+}
+
+func bcryptFormat() multiHasher.Format {
+	return multiHasher.Format{Prefix: bcryptHasher.Prefix, Hasher: bcryptHasher.New()}
+}
+
+func argon2idFormat(params argon2idHasher.Params) multiHasher.Format {
+	return multiHasher.Format{Prefix: argon2idHasher.Prefix, Hasher: argon2idHasher.New(params)}
+}
+
+func (s *PasswordHashSuite) TestRehashOnSignIn() {
+	ctx := context.Background()
+	hashedPassword, err := bcryptHasher.New().GetHashedPassword(ctx, password)
+	s.Require().NoError(err)
+	_, err = s.usersRepo.Create(ctx, &pUser.CreateParams{Username: "user", Password: hashedPassword})
+	s.Require().NoError(err)
+
+	hasher := s.start(argon2idFormat(testArgon2idParams), bcryptFormat())
+
+	s.Equal(http.StatusBadRequest, s.signIn("wrong"))
+	s.Equal(hashedPassword, s.storedHash(), "hash isn't replaced on a failed sign in")
+
+	s.Require().Equal(http.StatusOK, s.signIn(password))
+	rehashed := s.storedHash()
+	s.True(strings.HasPrefix(rehashed, "$argon2id$v=19$m=1024,t=1,p=1$"), rehashed)
+	s.False(hasher.NeedsRehash(rehashed))
+
+	s.Require().Equal(http.StatusOK, s.signIn(password))
+	s.Equal(rehashed, s.storedHash(), "current hash is kept")
+	s.Equal(http.StatusBadRequest, s.signIn("wrong"))
+
+	params := testArgon2idParams
+	params.Iterations = 2
+	s.start(argon2idFormat(params), bcryptFormat())
+	s.Require().Equal(http.StatusOK, s.signIn(password))
+	s.True(strings.HasPrefix(s.storedHash(), "$argon2id$v=19$m=1024,t=2,p=1$"), "hash follows new parameters")
+
+	s.start(bcryptFormat(), argon2idFormat(params))
+	s.Require().Equal(http.StatusOK, s.signIn(password))
+	s.True(strings.HasPrefix(s.storedHash(), bcryptHasher.Prefix), "hash follows the current algorithm")
+}
+
+func (s *PasswordHashSuite) TestArgon2id() {
+	ctx := context.Background()
+	hasher := argon2idHasher.New(testArgon2idParams)
+
+	hash, err := hasher.GetHashedPassword(ctx, password)
+	s.Require().NoError(err)
+	other, err := hasher.GetHashedPassword(ctx, password)
+	s.Require().NoError(err)
+	s.NotEqual(hash, other, "salt is random")
+
+	s.NoError(hasher.CompareHashAndPassword(ctx, hash, password))
+	s.ErrorIs(hasher.CompareHashAndPassword(ctx, hash, "wrong"), argon2idHasher.ErrMismatchedPassword)
+	for _, invalid := range []string{"", "$argon2id$", "$argon2id$v=19$m=0,t=1,p=1$c2FsdA$a2V5", hash[:len(hash)-44] + "!"} {
+		s.Error(hasher.CompareHashAndPassword(ctx, invalid, password), invalid)
+	}
+	s.ErrorIs(multiHasher.New(bcryptFormat()).CompareHashAndPassword(ctx, hash, password), multiHasher.ErrUnknownFormat)
+}
+
+func TestPasswordHashSuite(t *testing.T) {
+	suite.Run(t, new(PasswordHashSuite))
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+	pHTTP "github.com/SlavaShagalov/avito-intern-task/internal/pkg/http"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const (
+	signInBaseDelay         = 100 * time.Millisecond
+	signInFreeAttempts      = 2
+	signInLockoutAttempts   = 5
+	signInIPLockoutAttempts = 8
+
+	proxyIP = "10.0.0.1"
+)
+
+type SignInHandlersSuite struct {
+	suite.Suite
+	log    *zap.Logger
+	router http.Handler
+	userID int64
+}
+
+func (s *SignInHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	viper.Set(config.AuthSignInFreeAttempts, signInFreeAttempts)
+	viper.Set(config.AuthSignInBaseDelay, signInBaseDelay)
+	viper.Set(config.AuthSignInMaxDelay, signInBaseDelay)
+	viper.Set(config.AuthSignInLockoutAttempts, signInLockoutAttempts)
+	viper.Set(config.AuthSignInIPLockoutAttempts, signInIPLockoutAttempts)
+
+	proxies, err := pHTTP.ParseTrustedProxies([]string{"10.0.0.0/24"})
+	s.Require().NoError(err)
+	api := newAPI(s.T(), s.log, apiOptions{trustedProxies: proxies})
+	api.createUser("admin", models.RoleAdmin)
+	s.userID = api.createUser("user", "").ID
+	s.router = api.handler
+}
+
+func (s *SignInHandlersSuite) TearDownTest() {
+	for _, key := range []string{
+		config.AuthSignInFreeAttempts,
+		config.AuthSignInBaseDelay,
+		config.AuthSignInMaxDelay,
+		config.AuthSignInLockoutAttempts,
+		config.AuthSignInIPLockoutAttempts,
+	} {
+		viper.Set(key, 0)
+	}
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *SignInHandlersSuite) signIn(ip, username, password string) *httptest.ResponseRecorder {
+	return s.signInWithHeaders(ip, nil, username, password)
+}
+
+func (s *SignInHandlersSuite) signInWithHeaders(ip string, headers map[string]string, username, password string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	s.Require().NoError(json.NewEncoder(&body).Encode(map[string]string{
+		"username": username,
+		"password": password,
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/signin", &body)
+	req.RemoteAddr = ip + ":1234"
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *SignInHandlersSuite) TestUnknownUser() {
+	unknown := s.signIn("192.0.2.1", "nobody", password)
+	wrong := s.signIn("192.0.2.2", "user", "wrong")
+
+	s.Equal(http.StatusBadRequest, unknown.Code)
+	s.Equal(wrong.Code, unknown.Code)
+	s.Equal(wrong.Body.String(), unknown.Body.String())
+}
+
+func (s *SignInHandlersSuite) TestProgressiveDelay() {
+	for i := 0; i < signInFreeAttempts; i++ {
+		s.Equal(http.StatusBadRequest, s.signIn("192.0.2.1", "user", "wrong").Code)
+	}
+	s.Equal(http.StatusBadRequest, s.signIn("192.0.2.1", "user", "wrong").Code)
+
+	rec := s.signIn("192.0.2.2", "user", password)
+	s.Equal(http.StatusTooManyRequests, rec.Code, "username is blocked from any IP")
+
+	time.Sleep(signInBaseDelay)
+	rec = s.signIn("192.0.2.2", "user", password)
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	for i := 0; i < signInFreeAttempts; i++ {
+		s.Equal(http.StatusBadRequest, s.signIn("192.0.2.1", "user", "wrong").Code, "successful sign in resets failures")
+	}
+}
+
+func (s *SignInHandlersSuite) TestLockoutAndUnlock() {
+	for i := 0; i < signInLockoutAttempts; i++ {
+		time.Sleep(signInBaseDelay)
+		s.Equal(http.StatusBadRequest, s.signIn("192.0.2.1", "user", "wrong").Code)
+	}
+	time.Sleep(signInBaseDelay)
+	s.Equal(http.StatusTooManyRequests, s.signIn("192.0.2.1", "user", password).Code)
+
+	rec := s.signIn("192.0.2.2", "admin", password)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d/lockout", s.userID), nil)
+	req.Header.Set("token", rec.Header().Get("token"))
+	rec = httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+
+	s.Equal(http.StatusOK, s.signIn("192.0.2.1", "user", password).Code)
+}
+
+func (s *SignInHandlersSuite) TestIPLockout() {
+	for i := 0; i < signInIPLockoutAttempts; i++ {
+		s.Equal(http.StatusBadRequest, s.signIn("192.0.2.1", fmt.Sprintf("user%d", i), "wrong").Code)
+	}
+
+	s.Equal(http.StatusTooManyRequests, s.signIn("192.0.2.1", "user", password).Code)
+	s.Equal(http.StatusOK, s.signIn("192.0.2.2", "user", password).Code)
+}
+
+func (s *SignInHandlersSuite) TestIPLockoutBehindProxy() {
+	forwarded := func(ip string) map[string]string {
+		// The client prepends a fake address, the proxies append the real ones.
+		return map[string]string{"X-Forwarded-For": "198.51.100.1, " + ip + ", 10.0.0.2"}
+	}
+	for i := 0; i < signInIPLockoutAttempts; i++ {
+		rec := s.signInWithHeaders(proxyIP, forwarded("192.0.2.1"), fmt.Sprintf("user%d", i), "wrong")
+		s.Equal(http.StatusBadRequest, rec.Code)
+	}
+
+	s.Equal(http.StatusTooManyRequests, s.signInWithHeaders(proxyIP, forwarded("192.0.2.1"), "user", password).Code)
+	s.Equal(http.StatusOK, s.signInWithHeaders(proxyIP, forwarded("192.0.2.2"), "user", password).Code,
+		"clients behind the same proxy are limited separately")
+	s.Equal(http.StatusOK, s.signInWithHeaders(proxyIP, map[string]string{"X-Real-IP": "192.0.2.3"}, "user", password).Code)
+}
+
+func (s *SignInHandlersSuite) TestForwardedFromUntrustedPeer() {
+	for i := 0; i < signInIPLockoutAttempts; i++ {
+		rec := s.signInWithHeaders("192.0.2.1", map[string]string{"X-Forwarded-For": fmt.Sprintf("198.51.100.%d", i)},
+			fmt.Sprintf("user%d", i), "wrong")
+		s.Equal(http.StatusBadRequest, rec.Code)
+	}
+
+	s.Equal(http.StatusTooManyRequests, s.signIn("192.0.2.1", "user", password).Code,
+		"forwarding headers of clients are ignored")
+}
+
+func TestSignInHandlersSuite(t *testing.T) {
+	suite.Run(t, new(SignInHandlersSuite))
+}
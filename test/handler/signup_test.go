@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/policy"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const strongPassword = "Correct-Horse-42"
+
+type SignUpHandlersSuite struct {
+	suite.Suite
+	log    *zap.Logger
+	router http.Handler
+}
+
+func (s *SignUpHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+}
+
+func (s *SignUpHandlersSuite) TearDownTest() {
+	viper.Set(config.AuthSignUpMode, "")
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// start creates the router with the sign up mode.
+func (s *SignUpHandlersSuite) start(mode string) {
+	viper.Set(config.AuthSignUpMode, mode)
+
+	credentials := policy.New(policy.Config{
+		ReservedUsernames:     []string{"root", "Support"},
+		PasswordMinLength:     10,
+		PasswordMinClasses:    3,
+		RejectCommonPasswords: true,
+	})
+
+	api := newAPI(s.T(), s.log, apiOptions{credentials: credentials})
+	api.createUser("admin", models.RoleAdmin)
+	s.router = api.handler
+}
+
+func (s *SignUpHandlersSuite) do(method, target, token string, body any) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&reqBody).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &reqBody)
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *SignUpHandlersSuite) signUp(body map[string]string) *httptest.ResponseRecorder {
+	return s.do(http.MethodPost, "/api/v1/auth/signup", "", body)
+}
+
+func (s *SignUpHandlersSuite) inviteCode() string {
+	rec := s.do(http.MethodPost, "/api/v1/auth/signin", "", map[string]string{
+		"username": "admin",
+		"password": password,
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/invites", rec.Header().Get("token"), nil)
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var response struct {
+		InviteCode string `json:"invite_code"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	s.Require().NotEmpty(response.InviteCode)
+	return response.InviteCode
+}
+
+func (s *SignUpHandlersSuite) TestCredentialPolicy() {
+	s.start(config.SignUpOpen)
+
+	type testCase struct {
+		username string
+		password string
+		error    string
+	}
+
+	tests := map[string]testCase{
+		"empty username":       {username: "", password: strongPassword, error: "bad username length"},
+		"short username":       {username: "ab", password: strongPassword, error: "bad username length"},
+		"long username":        {username: "abcdefghijklmnopqrstuvwxyz0123456", password: strongPassword, error: "bad username length"},
+		"forbidden characters": {username: "john doe", password: strongPassword, error: "username contains forbidden characters"},
+		"reserved username":    {username: "support", password: strongPassword, error: "username reserved"},
+		"empty password":       {username: "john", password: "", error: "password too short"},
+		"short password":       {username: "john", password: "Abc-123", error: "password too short"},
+		"simple password":      {username: "john", password: "correcthorse", error: "password contains too few character classes"},
+		"common password":      {username: "john", password: "Qwerty12345", error: "password too common"},
+	}
+
+	for name, test := range tests {
+		s.Run(name, func() {
+			rec := s.signUp(map[string]string{"username": test.username, "password": test.password})
+			s.Require().Equal(http.StatusBadRequest, rec.Code)
+			var response struct {
+				Error string `json:"error"`
+			}
+			s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+			s.Equal(test.error, response.Error)
+		})
+	}
+
+	rec := s.signUp(map[string]string{"username": "john.doe", "password": strongPassword})
+	s.Equal(http.StatusOK, rec.Code)
+}
+
+func (s *SignUpHandlersSuite) TestDisabled() {
+	s.start(config.SignUpDisabled)
+
+	rec := s.signUp(map[string]string{"username": "john", "password": strongPassword})
+	s.Equal(http.StatusForbidden, rec.Code)
+}
+
+func (s *SignUpHandlersSuite) TestInvite() {
+	s.start(config.SignUpInvite)
+
+	rec := s.signUp(map[string]string{"username": "john", "password": strongPassword})
+	s.Equal(http.StatusForbidden, rec.Code)
+	rec = s.signUp(map[string]string{"username": "john", "password": strongPassword, "invite_code": "unknown"})
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	code := s.inviteCode()
+	rec = s.signUp(map[string]string{"username": "admin", "password": strongPassword, "invite_code": code})
+	s.Equal(http.StatusConflict, rec.Code, "code isn't spent on a failed sign up")
+	rec = s.signUp(map[string]string{"username": "john", "password": strongPassword, "invite_code": code})
+	s.Equal(http.StatusOK, rec.Code)
+	rec = s.signUp(map[string]string{"username": "jane", "password": strongPassword, "invite_code": code})
+	s.Equal(http.StatusForbidden, rec.Code, "code is one-time")
+}
+
+func TestSignUpHandlersSuite(t *testing.T) {
+	suite.Run(t, new(SignUpHandlersSuite))
+}
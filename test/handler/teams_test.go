@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type TeamHandlersSuite struct {
+	suite.Suite
+	log             *zap.Logger
+	router          http.Handler
+	superAdminToken string
+	adminToken      string
+	adminID         int64
+}
+
+func (s *TeamHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+
+	api := newAPI(s.T(), s.log, apiOptions{})
+	api.createUser("superadmin", models.RoleSuperAdmin)
+	s.adminID = api.createUser("admin", models.RoleAdmin).ID
+	s.router = api.handler
+
+	s.superAdminToken = s.signIn("superadmin")
+	s.adminToken = s.signIn("admin")
+}
+
+func (s *TeamHandlersSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *TeamHandlersSuite) do(method, target, token string, body any) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&reqBody).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &reqBody)
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *TeamHandlersSuite) signIn(username string) string {
+	rec := s.do(http.MethodPost, "/api/v1/auth/signin", "", map[string]string{
+		"username": username,
+		"password": password,
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	return rec.Header().Get("token")
+}
+
+// createTeam creates a team with the admin owning the features.
+func (s *TeamHandlersSuite) createTeam(name string, featureIDs ...int64) int64 {
+	rec := s.do(http.MethodPost, "/api/v1/teams", s.superAdminToken, map[string]string{"name": name})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var team struct {
+		ID int64 `json:"team_id"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &team))
+
+	rec = s.do(http.MethodPut, fmt.Sprintf("/api/v1/teams/%d/members/%d", team.ID, s.adminID), s.superAdminToken, nil)
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+	for _, featureID := range featureIDs {
+		rec = s.do(http.MethodPut, fmt.Sprintf("/api/v1/teams/%d/features/%d", team.ID, featureID), s.superAdminToken, nil)
+		s.Require().Equal(http.StatusNoContent, rec.Code)
+	}
+	return team.ID
+}
+
+func (s *TeamHandlersSuite) createBanner(token string, featureID int64) *httptest.ResponseRecorder {
+	return s.do(http.MethodPost, "/api/v1/banner", token, map[string]any{
+		"tag_ids":    []int64{1},
+		"feature_id": featureID,
+		"content":    map[string]any{"title": "banner"},
+		"is_active":  true,
+	})
+}
+
+func (s *TeamHandlersSuite) bannerID(rec *httptest.ResponseRecorder) int64 {
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var created struct {
+		BannerID int64 `json:"banner_id"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &created))
+	return created.BannerID
+}
+
+func (s *TeamHandlersSuite) listFeatures(token string) []int64 {
+	rec := s.do(http.MethodGet, "/api/v1/banner", token, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var banners []struct {
+		FeatureID int64 `json:"feature_id"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &banners))
+	featureIDs := make([]int64, 0, len(banners))
+	for _, b := range banners {
+		featureIDs = append(featureIDs, b.FeatureID)
+	}
+	return featureIDs
+}
+
+func (s *TeamHandlersSuite) TestOwnership() {
+	s.createTeam("team", 1)
+
+	owned := s.bannerID(s.createBanner(s.adminToken, 1))
+	rec := s.createBanner(s.adminToken, 2)
+	s.Equal(http.StatusForbidden, rec.Code, "admin can't create banners of other features")
+	foreign := s.bannerID(s.createBanner(s.superAdminToken, 2))
+
+	s.Equal([]int64{1}, s.listFeatures(s.adminToken))
+	s.Equal([]int64{1, 2}, s.listFeatures(s.superAdminToken))
+
+	rec = s.do(http.MethodPatch, fmt.Sprintf("/api/v1/banner/%d", foreign), s.adminToken, map[string]any{
+		"content": map[string]any{"title": "edited"},
+	})
+	s.Equal(http.StatusForbidden, rec.Code)
+	rec = s.do(http.MethodPatch, fmt.Sprintf("/api/v1/banner/%d", owned), s.adminToken, map[string]any{
+		"feature_id": 3,
+	})
+	s.Equal(http.StatusForbidden, rec.Code, "banner can't be moved to a feature not owned")
+	rec = s.do(http.MethodPatch, fmt.Sprintf("/api/v1/banner/%d", owned), s.adminToken, map[string]any{
+		"content": map[string]any{"title": "edited"},
+	})
+	s.Equal(http.StatusOK, rec.Code)
+
+	rec = s.do(http.MethodDelete, fmt.Sprintf("/api/v1/banner/%d", foreign), s.adminToken, nil)
+	s.Equal(http.StatusForbidden, rec.Code)
+	rec = s.do(http.MethodDelete, fmt.Sprintf("/api/v1/banner/%d", owned), s.adminToken, nil)
+	s.Equal(http.StatusNoContent, rec.Code)
+}
+
+func (s *TeamHandlersSuite) TestMemberRemoved() {
+	teamID := s.createTeam("team", 1)
+
+	s.bannerID(s.createBanner(s.superAdminToken, 1))
+	s.Equal([]int64{1}, s.listFeatures(s.adminToken))
+
+	rec := s.do(http.MethodDelete, fmt.Sprintf("/api/v1/teams/%d/members/%d", teamID, s.adminID), s.superAdminToken, nil)
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+	s.Empty(s.listFeatures(s.adminToken))
+}
+
+func (s *TeamHandlersSuite) TestManageRequiresPermission() {
+	rec := s.do(http.MethodPost, "/api/v1/teams", s.adminToken, map[string]string{"name": "team"})
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/teams", s.superAdminToken, map[string]string{"name": " "})
+	s.Equal(http.StatusBadRequest, rec.Code)
+
+	s.createTeam("team")
+	rec = s.do(http.MethodPost, "/api/v1/teams", s.superAdminToken, map[string]string{"name": "team"})
+	s.Equal(http.StatusConflict, rec.Code)
+
+	rec = s.do(http.MethodPut, "/api/v1/teams/100/features/1", s.superAdminToken, nil)
+	s.Equal(http.StatusNotFound, rec.Code)
+}
+
+func TestTeamHandlersSuite(t *testing.T) {
+	suite.Run(t, new(TeamHandlersSuite))
+}
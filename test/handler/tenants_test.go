@@ -0,0 +1,288 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	mw "github.com/SlavaShagalov/avito-intern-task/internal/middleware"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type TenantHandlersSuite struct {
+	suite.Suite
+	log             *zap.Logger
+	api             *api
+	superAdminToken string
+}
+
+func (s *TenantHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+
+	s.api = newAPI(s.T(), s.log, apiOptions{})
+	s.api.createUser("superadmin", models.RoleSuperAdmin)
+
+	s.superAdminToken = s.signIn("", "superadmin")
+}
+
+func (s *TenantHandlersSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *TenantHandlersSuite) do(method, target, token, tenantID string, body any) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&reqBody).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &reqBody)
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	if tenantID != "" {
+		req.Header.Set(mw.TenantHeader, tenantID)
+	}
+	rec := httptest.NewRecorder()
+	s.api.handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *TenantHandlersSuite) signIn(tenantID, username string) string {
+	rec := s.do(http.MethodPost, "/api/v1/auth/signin", "", tenantID, map[string]string{
+		"username": username,
+		"password": password,
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	return rec.Header().Get("token")
+}
+
+// createTenant provisions a tenant and returns its id and the token of its admin.
+func (s *TenantHandlersSuite) createTenant(name string) (string, string) {
+	rec := s.do(http.MethodPost, "/api/v1/tenants", s.superAdminToken, "", map[string]string{
+		"name":           name,
+		"admin_username": "owner",
+		"admin_password": password,
+	})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var created struct {
+		ID    int64 `json:"tenant_id"`
+		Admin struct {
+			Role string `json:"role"`
+		} `json:"admin"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &created))
+	s.Require().Equal(models.RoleSuperAdmin, created.Admin.Role)
+
+	tenantID := fmt.Sprint(created.ID)
+	return tenantID, s.signIn(tenantID, "owner")
+}
+
+func (s *TenantHandlersSuite) createBanner(token string, title string) {
+	rec := s.do(http.MethodPost, "/api/v1/banner", token, "", map[string]any{
+		"tag_ids":    []int64{1},
+		"feature_id": 1,
+		"content":    map[string]any{"title": title},
+		"is_active":  true,
+	})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+}
+
+func (s *TenantHandlersSuite) userBanner(token string) map[string]any {
+	rec := s.do(http.MethodGet, "/api/v1/user_banner?feature_id=1&tag_id=1", token, "", nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var content map[string]any
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &content))
+	return content
+}
+
+func (s *TenantHandlersSuite) TestProvision() {
+	tenantID, _ := s.createTenant("acme")
+
+	rec := s.do(http.MethodGet, "/api/v1/tenants", s.superAdminToken, "", nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var tenants []struct {
+		Name string `json:"name"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &tenants))
+	s.Len(tenants, 2)
+
+	rec = s.do(http.MethodGet, "/api/v1/tenants/"+tenantID, s.superAdminToken, "", nil)
+	s.Equal(http.StatusOK, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/tenants", s.superAdminToken, "", map[string]string{
+		"name":           "acme",
+		"admin_username": "owner",
+		"admin_password": password,
+	})
+	s.Equal(http.StatusConflict, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/auth/signin", "", "", map[string]string{
+		"username": "owner",
+		"password": password,
+	})
+	s.Equal(http.StatusBadRequest, rec.Code, "users of a tenant can't sign in to the default one")
+
+	rec = s.do(http.MethodDelete, "/api/v1/tenants/1", s.superAdminToken, "", nil)
+	s.Equal(http.StatusConflict, rec.Code)
+	rec = s.do(http.MethodDelete, "/api/v1/tenants/"+tenantID, s.superAdminToken, "", nil)
+	s.Equal(http.StatusNoContent, rec.Code)
+	rec = s.do(http.MethodGet, "/api/v1/tenants/"+tenantID, s.superAdminToken, "", nil)
+	s.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (s *TenantHandlersSuite) TestIsolation() {
+	_, ownerToken := s.createTenant("acme")
+
+	s.createBanner(s.superAdminToken, "default")
+	s.createBanner(ownerToken, "acme")
+
+	s.Equal(map[string]any{"title": "default"}, s.userBanner(s.superAdminToken))
+	s.Equal(map[string]any{"title": "acme"}, s.userBanner(ownerToken))
+
+	rec := s.do(http.MethodGet, "/api/v1/banner", ownerToken, "", nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var banners []map[string]any
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &banners))
+	s.Len(banners, 1)
+
+	rec = s.do(http.MethodGet, "/api/v1/users", ownerToken, "", nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var users []struct {
+		Username string `json:"username"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &users))
+	s.Require().Len(users, 1)
+	s.Equal("owner", users[0].Username)
+
+	rec = s.do(http.MethodGet, "/api/v1/users/1", ownerToken, "", nil)
+	s.Equal(http.StatusNotFound, rec.Code, "users of other tenants aren't visible")
+}
+
+func (s *TenantHandlersSuite) TestFeaturesPerTenant() {
+	_, ownerToken := s.createTenant("acme")
+
+	// Both tenants have the feature 1, owned by a team of each of them.
+	createTeam := func(token string) string {
+		rec := s.do(http.MethodPost, "/api/v1/teams", token, "", map[string]string{"name": "team"})
+		s.Require().Equal(http.StatusCreated, rec.Code)
+		var team struct {
+			ID int64 `json:"team_id"`
+		}
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &team))
+		path := fmt.Sprintf("/api/v1/teams/%d", team.ID)
+		rec = s.do(http.MethodPut, path+"/features/1", token, "", nil)
+		s.Require().Equal(http.StatusNoContent, rec.Code)
+		return path
+	}
+	featureIDs := func(token, path string) []int64 {
+		rec := s.do(http.MethodGet, path, token, "", nil)
+		s.Require().Equal(http.StatusOK, rec.Code)
+		var team struct {
+			FeatureIDs []int64 `json:"feature_ids"`
+		}
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &team))
+		return team.FeatureIDs
+	}
+
+	defaultTeam := createTeam(s.superAdminToken)
+	acmeTeam := createTeam(ownerToken)
+	s.Equal([]int64{1}, featureIDs(s.superAdminToken, defaultTeam))
+	s.Equal([]int64{1}, featureIDs(ownerToken, acmeTeam))
+}
+
+func (s *TenantHandlersSuite) TestSigningKeys() {
+	_, ownerToken := s.createTenant("acme")
+
+	kid := func(token string) string {
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		s.Require().NoError(err)
+		return parsed.Header["kid"].(string)
+	}
+	s.NotEqual(kid(s.superAdminToken), kid(ownerToken), "tenants sign tokens with their own keys")
+
+	rec := s.do(http.MethodGet, "/.well-known/jwks.json", "", "", nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var jwks struct {
+		Keys []jwk `json:"keys"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &jwks))
+	s.Len(jwks.Keys, 2)
+}
+
+func (s *TenantHandlersSuite) TestRefreshAndResetKeepTenant() {
+	tenantID, ownerToken := s.createTenant("acme")
+	id, err := strconv.ParseInt(tenantID, 10, 64)
+	s.Require().NoError(err)
+	alice := s.api.createTenantUser(id, "alice", models.RoleUser)
+
+	rec := s.do(http.MethodPost, "/api/v1/auth/signin", "", tenantID, map[string]string{
+		"username": "alice",
+		"password": password,
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var tokens struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &tokens))
+
+	// Refresh and reset tokens are sent without the tenant header.
+	rec = s.do(http.MethodPost, "/api/v1/auth/refresh", "", "", map[string]string{
+		"refresh_token": tokens.RefreshToken,
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	rec = s.do(http.MethodGet, "/api/v1/auth/me", rec.Header().Get("token"), "", nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var me struct {
+		Username string `json:"username"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &me))
+	s.Equal("alice", me.Username, "refreshed token belongs to the tenant of the session")
+
+	rec = s.do(http.MethodPost, fmt.Sprintf("/api/v1/users/%d/password_reset", alice.ID), ownerToken, "", nil)
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var reset struct {
+		ResetToken string `json:"reset_token"`
+	}
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &reset))
+
+	const newPassword = "new_password"
+	rec = s.do(http.MethodPost, "/api/v1/auth/password/reset", "", "", map[string]string{
+		"reset_token":  reset.ResetToken,
+		"new_password": newPassword,
+	})
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/auth/signin", "", tenantID, map[string]string{
+		"username": "alice",
+		"password": newPassword,
+	})
+	s.Equal(http.StatusOK, rec.Code)
+}
+
+func (s *TenantHandlersSuite) TestManageRequiresDefaultTenant() {
+	_, ownerToken := s.createTenant("acme")
+
+	rec := s.do(http.MethodGet, "/api/v1/tenants", ownerToken, "", nil)
+	s.Equal(http.StatusForbidden, rec.Code)
+
+	rec = s.do(http.MethodGet, "/api/v1/tenants", ownerToken, "1", nil)
+	s.Equal(http.StatusForbidden, rec.Code, "the header doesn't override the tenant of the token")
+
+	rec = s.do(http.MethodGet, "/api/v1/tenants", s.superAdminToken, "abc", nil)
+	s.Equal(http.StatusBadRequest, rec.Code)
+}
+
+func TestTenantHandlersSuite(t *testing.T) {
+	suite.Run(t, new(TenantHandlersSuite))
+}
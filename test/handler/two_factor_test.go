@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/auth/totp"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type enrollment struct {
+	Secret        string   `json:"secret"`
+	URI           string   `json:"uri"`
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type challenge struct {
+	ChallengeToken     string `json:"challenge_token"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+type TwoFactorHandlersSuite struct {
+	suite.Suite
+	log    *zap.Logger
+	router http.Handler
+	// now is the clock of TOTP codes.
+	now time.Time
+}
+
+func (s *TwoFactorHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.now = time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+}
+
+func (s *TwoFactorHandlersSuite) TearDownTest() {
+	viper.Set(config.AuthTwoFactorEnforceAdmins, false)
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// start creates the router with the user and the admin.
+func (s *TwoFactorHandlersSuite) start() {
+	otp := totp.New(totp.Config{
+		Issuer: "Banners",
+		Now:    func() time.Time { return s.now },
+	})
+	api := newAPI(s.T(), s.log, apiOptions{otp: otp})
+	api.createUser("admin", models.RoleAdmin)
+	api.createUser("user", "")
+	s.router = api.handler
+}
+
+func (s *TwoFactorHandlersSuite) request(method, target, token string, body any) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&buf).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &buf)
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *TwoFactorHandlersSuite) signIn(username string) *httptest.ResponseRecorder {
+	return s.request(http.MethodPost, "/api/v1/auth/signin", "", map[string]string{
+		"username": username,
+		"password": password,
+	})
+}
+
+// challenge signs in expecting the second step.
+func (s *TwoFactorHandlersSuite) challenge(username string) *challenge {
+	rec := s.signIn(username)
+	s.Require().Equal(http.StatusAccepted, rec.Code)
+	s.Empty(rec.Header().Get("token"))
+
+	var response challenge
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	s.Require().NotEmpty(response.ChallengeToken)
+	return &response
+}
+
+func (s *TwoFactorHandlersSuite) verify(challengeToken string, code map[string]string) *httptest.ResponseRecorder {
+	code["challenge_token"] = challengeToken
+	return s.request(http.MethodPost, "/api/v1/auth/signin/2fa", "", code)
+}
+
+func (s *TwoFactorHandlersSuite) code(secret string) string {
+	code, err := totp.Code(secret, s.now)
+	s.Require().NoError(err)
+	return code
+}
+
+// enroll enables the second factor of the signed in user.
+func (s *TwoFactorHandlersSuite) enroll(username string) *enrollment {
+	rec := s.signIn(username)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	token := rec.Header().Get("token")
+
+	rec = s.request(http.MethodPost, "/api/v1/auth/2fa/enroll", token, nil)
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var response enrollment
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+
+	rec = s.request(http.MethodPost, "/api/v1/auth/2fa/confirm", token, map[string]string{"code": s.code(response.Secret)})
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+	return &response
+}
+
+func (s *TwoFactorHandlersSuite) TestEnrollment() {
+	s.start()
+	rec := s.signIn("user")
+	s.Require().Equal(http.StatusOK, rec.Code)
+	token := rec.Header().Get("token")
+
+	rec = s.request(http.MethodPost, "/api/v1/auth/2fa/enroll", token, nil)
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var response enrollment
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+	s.Len(response.RecoveryCodes, 10)
+
+	uri, err := url.Parse(response.URI)
+	s.Require().NoError(err)
+	s.Equal("otpauth", uri.Scheme)
+	s.Equal("totp", uri.Host)
+	s.Equal("/Banners:user", uri.Path)
+	s.Equal(response.Secret, uri.Query().Get("secret"))
+	s.Equal("Banners", uri.Query().Get("issuer"))
+	s.Equal("6", uri.Query().Get("digits"))
+	s.Equal("30", uri.Query().Get("period"))
+
+	s.Equal(http.StatusOK, s.signIn("user").Code, "pending factor isn't required")
+
+	confirm := func(code string) int {
+		return s.request(http.MethodPost, "/api/v1/auth/2fa/confirm", token, map[string]string{"code": code}).Code
+	}
+	s.Equal(http.StatusBadRequest, confirm("000000"))
+	s.Equal(http.StatusBadRequest, confirm(""))
+	s.Require().Equal(http.StatusNoContent, confirm(s.code(response.Secret)))
+	s.Equal(http.StatusConflict, confirm(s.code(response.Secret)))
+
+	rec = s.request(http.MethodPost, "/api/v1/auth/2fa/enroll", token, nil)
+	s.Equal(http.StatusConflict, rec.Code, "enabled factor isn't replaced")
+}
+
+func (s *TwoFactorHandlersSuite) TestSignIn() {
+	s.start()
+	enrolled := s.enroll("user")
+
+	ch := s.challenge("user")
+	s.False(ch.EnrollmentRequired)
+	s.Equal(http.StatusUnauthorized, s.request(http.MethodGet, "/api/v1/auth/me", ch.ChallengeToken, nil).Code,
+		"challenge token isn't an access token")
+
+	s.Equal(http.StatusBadRequest, s.verify(ch.ChallengeToken, map[string]string{"code": s.code(enrolled.Secret)}).Code,
+		"code used on confirmation is rejected")
+
+	s.now = s.now.Add(totp.Period)
+	code := s.code(enrolled.Secret)
+	rec := s.verify(ch.ChallengeToken, map[string]string{"code": code})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.Equal(http.StatusOK, s.request(http.MethodGet, "/api/v1/auth/me", rec.Header().Get("token"), nil).Code)
+
+	s.Equal(http.StatusBadRequest, s.verify(s.challenge("user").ChallengeToken, map[string]string{"code": code}).Code,
+		"code is replayed")
+
+	s.now = s.now.Add(totp.Period)
+	previous := s.code(enrolled.Secret)
+	s.now = s.now.Add(totp.Period)
+	s.Equal(http.StatusOK, s.verify(s.challenge("user").ChallengeToken, map[string]string{"code": previous}).Code,
+		"code of the previous period is accepted")
+
+	s.now = s.now.Add(3 * totp.Period)
+	s.Equal(http.StatusBadRequest, s.verify(s.challenge("user").ChallengeToken, map[string]string{"code": previous}).Code)
+
+	s.Equal(http.StatusUnauthorized, s.verify("invalid", map[string]string{"code": s.code(enrolled.Secret)}).Code)
+}
+
+func (s *TwoFactorHandlersSuite) TestRecoveryCodes() {
+	s.start()
+	enrolled := s.enroll("user")
+	recoveryCode := enrolled.RecoveryCodes[0]
+	s.Regexp(`^[a-z2-7]{5}-[a-z2-7]{5}$`, recoveryCode)
+
+	rec := s.verify(s.challenge("user").ChallengeToken, map[string]string{
+		"recovery_code": strings.ToUpper(strings.ReplaceAll(recoveryCode, "-", "")),
+	})
+	s.Require().Equal(http.StatusOK, rec.Code)
+
+	ch := s.challenge("user")
+	s.Equal(http.StatusBadRequest, s.verify(ch.ChallengeToken, map[string]string{"recovery_code": recoveryCode}).Code,
+		"recovery code is used once")
+	s.Equal(http.StatusOK, s.verify(ch.ChallengeToken, map[string]string{"recovery_code": enrolled.RecoveryCodes[1]}).Code)
+}
+
+func (s *TwoFactorHandlersSuite) TestTooManyAttempts() {
+	s.start()
+	enrolled := s.enroll("user")
+	s.now = s.now.Add(totp.Period)
+
+	ch := s.challenge("user")
+	for i := 0; i < 4; i++ {
+		s.Equal(http.StatusBadRequest, s.verify(ch.ChallengeToken, map[string]string{"code": "000000"}).Code)
+	}
+	s.Equal(http.StatusTooManyRequests, s.verify(ch.ChallengeToken, map[string]string{"code": s.code(enrolled.Secret)}).Code)
+	s.Equal(http.StatusTooManyRequests, s.verify(s.challenge("user").ChallengeToken,
+		map[string]string{"code": s.code(enrolled.Secret)}).Code, "right password doesn't lift the block")
+}
+
+func (s *TwoFactorHandlersSuite) TestEnforcedForAdmins() {
+	viper.Set(config.AuthTwoFactorEnforceAdmins, true)
+	s.start()
+
+	s.Equal(http.StatusOK, s.signIn("user").Code, "users aren't affected")
+
+	ch := s.challenge("admin")
+	s.True(ch.EnrollmentRequired)
+	s.Equal(http.StatusBadRequest, s.verify(ch.ChallengeToken, map[string]string{"code": "000000"}).Code)
+
+	rec := s.request(http.MethodPost, "/api/v1/auth/signin/2fa/enroll", "",
+		map[string]string{"challenge_token": ch.ChallengeToken})
+	s.Require().Equal(http.StatusCreated, rec.Code)
+	var enrolled enrollment
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &enrolled))
+
+	rec = s.verify(ch.ChallengeToken, map[string]string{"code": s.code(enrolled.Secret)})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	token := rec.Header().Get("token")
+
+	ch = s.challenge("admin")
+	s.False(ch.EnrollmentRequired)
+	rec = s.request(http.MethodPost, "/api/v1/auth/signin/2fa/enroll", "",
+		map[string]string{"challenge_token": ch.ChallengeToken})
+	s.Equal(http.StatusUnauthorized, rec.Code, "enrolled factor isn't replaced on sign in")
+
+	s.now = s.now.Add(totp.Period)
+	rec = s.request(http.MethodDelete, "/api/v1/auth/2fa", token, map[string]string{"code": s.code(enrolled.Secret)})
+	s.Equal(http.StatusForbidden, rec.Code)
+}
+
+func (s *TwoFactorHandlersSuite) TestDisable() {
+	s.start()
+	rec := s.signIn("user")
+	s.Require().Equal(http.StatusOK, rec.Code)
+	token := rec.Header().Get("token")
+
+	enrolled := s.enroll("user")
+	s.now = s.now.Add(totp.Period)
+
+	disable := func(code map[string]string) int {
+		return s.request(http.MethodDelete, "/api/v1/auth/2fa", token, code).Code
+	}
+	s.Equal(http.StatusBadRequest, disable(map[string]string{"code": "000000"}))
+	s.Require().Equal(http.StatusNoContent, disable(map[string]string{"recovery_code": enrolled.RecoveryCodes[0]}))
+	s.Equal(http.StatusOK, s.signIn("user").Code)
+	s.Equal(http.StatusBadRequest, disable(map[string]string{"code": s.code(enrolled.Secret)}))
+}
+
+func TestTwoFactorHandlersSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorHandlersSuite))
+}
@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	pUser "github.com/SlavaShagalov/avito-intern-task/internal/user"
+	userMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/user/repository/memory"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type managedUser struct {
+	ID          int64    `json:"user_id"`
+	Username    string   `json:"username"`
+	Password    string   `json:"password"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+	IsDisabled  bool     `json:"is_disabled"`
+}
+
+// tenantRoles replaces the permission matrix of the tenant, like the roles
+// and role_permissions rows of a tenant in Postgres.
+type tenantRoles struct {
+	pUser.Repository
+	roles map[string][]string
+}
+
+func (r *tenantRoles) RolePermissions(ctx context.Context, role string) ([]string, error) {
+	if r.roles == nil {
+		return r.Repository.RolePermissions(ctx, role)
+	}
+	permissions, exists := r.roles[role]
+	if !exists {
+		return nil, pErrors.ErrBadRoleField
+	}
+	return permissions, nil
+}
+
+type UserHandlersSuite struct {
+	suite.Suite
+	log        *zap.Logger
+	roles      *tenantRoles
+	router     http.Handler
+	adminID    int64
+	adminToken string
+	users      map[string]int64
+}
+
+func (s *UserHandlersSuite) SetupTest() {
+	s.log = pLog.NewDev()
+
+	s.roles = &tenantRoles{Repository: userMemoryRepository.New(s.log)}
+	api := newAPI(s.T(), s.log, apiOptions{usersRepo: s.roles})
+	s.users = make(map[string]int64)
+	for username, role := range map[string]string{
+		"admin":      models.RoleAdmin,
+		"superadmin": models.RoleSuperAdmin,
+		"alice":      models.RoleUser,
+		"bob":        models.RoleEditor,
+	} {
+		s.users[username] = api.createUser(username, role).ID
+	}
+	s.adminID = s.users["admin"]
+	s.router = api.handler
+
+	s.adminToken = s.signIn("admin", http.StatusOK)
+}
+
+func (s *UserHandlersSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *UserHandlersSuite) do(method, target, token string, body any) *httptest.ResponseRecorder {
+	var reqBody bytes.Buffer
+	if body != nil {
+		s.Require().NoError(json.NewEncoder(&reqBody).Encode(body))
+	}
+	req := httptest.NewRequest(method, target, &reqBody)
+	if token != "" {
+		req.Header.Set("token", token)
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *UserHandlersSuite) signIn(username string, code int) string {
+	return s.signInWith(username, password, code)
+}
+
+func (s *UserHandlersSuite) signInWith(username, password string, code int) string {
+	rec := s.do(http.MethodPost, "/api/v1/auth/signin", "", map[string]string{
+		"username": username,
+		"password": password,
+	})
+	s.Require().Equal(code, rec.Code)
+	return rec.Header().Get("token")
+}
+
+func (s *UserHandlersSuite) update(username string, body map[string]any) *httptest.ResponseRecorder {
+	return s.do(http.MethodPatch, fmt.Sprintf("/api/v1/users/%d", s.users[username]), s.adminToken, body)
+}
+
+func (s *UserHandlersSuite) list(query string) []managedUser {
+	rec := s.do(http.MethodGet, "/api/v1/users"+query, s.adminToken, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var users []managedUser
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &users))
+	return users
+}
+
+func (s *UserHandlersSuite) TestList() {
+	users := s.list("")
+	s.Require().Len(users, 4)
+	for _, user := range users {
+		s.Empty(user.Password)
+	}
+
+	users = s.list("?query=LIC")
+	s.Require().Len(users, 1)
+	s.Equal("alice", users[0].Username)
+
+	users = s.list("?role=" + models.RoleEditor)
+	s.Require().Len(users, 1)
+	s.Equal("bob", users[0].Username)
+	s.Contains(users[0].Permissions, models.PermissionBannerUpdate)
+
+	s.Len(s.list("?limit=2&offset=1"), 2)
+
+	rec := s.do(http.MethodGet, "/api/v1/users?limit=x", s.adminToken, nil)
+	s.Equal(http.StatusBadRequest, rec.Code)
+
+	rec = s.do(http.MethodGet, fmt.Sprintf("/api/v1/users/%d", s.users["bob"]), s.adminToken, nil)
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var user managedUser
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &user))
+	s.Equal("bob", user.Username)
+
+	rec = s.do(http.MethodGet, "/api/v1/users/1000", s.adminToken, nil)
+	s.Equal(http.StatusNotFound, rec.Code)
+}
+
+func (s *UserHandlersSuite) TestChangeRole() {
+	token := s.signIn("alice", http.StatusOK)
+
+	rec := s.update("alice", map[string]any{"role": models.RolePublisher})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	var user managedUser
+	s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &user))
+	s.Equal(models.RolePublisher, user.Role)
+	s.Contains(user.Permissions, models.PermissionBannerPublish)
+	rec = s.do(http.MethodPost, "/api/v1/auth/logout", token, nil)
+	s.Equal(http.StatusUnauthorized, rec.Code, "tokens with the old role are revoked")
+
+	rec = s.update("alice", map[string]any{"role": "owner"})
+	s.Equal(http.StatusBadRequest, rec.Code)
+	rec = s.update("alice", map[string]any{"role": models.RoleSuperAdmin})
+	s.Equal(http.StatusForbidden, rec.Code, "admin can't grant permissions they don't have")
+	rec = s.update("superadmin", map[string]any{"role": models.RoleUser})
+	s.Equal(http.StatusForbidden, rec.Code, "admin can't manage superadmin")
+	rec = s.update("admin", map[string]any{"role": models.RoleUser})
+	s.Equal(http.StatusForbidden, rec.Code, "admin can't manage themselves")
+}
+
+func (s *UserHandlersSuite) TestTenantRoles() {
+	s.roles.roles = map[string][]string{
+		models.RoleUser:   {},
+		models.RoleViewer: {models.PermissionBannerRead, models.PermissionTenantManage},
+	}
+
+	rec := s.update("alice", map[string]any{"role": models.RolePublisher})
+	s.Equal(http.StatusBadRequest, rec.Code, "the tenant has no such role")
+	rec = s.update("alice", map[string]any{"role": models.RoleViewer})
+	s.Equal(http.StatusForbidden, rec.Code, "viewer of the tenant has permissions admin doesn't")
+}
+
+func (s *UserHandlersSuite) TestDisable() {
+	token := s.signIn("alice", http.StatusOK)
+	s.Require().NotEmpty(token)
+
+	rec := s.update("alice", map[string]any{"is_disabled": true})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.signIn("alice", http.StatusForbidden)
+	rec = s.do(http.MethodPost, "/api/v1/auth/logout", token, nil)
+	s.Equal(http.StatusUnauthorized, rec.Code, "issued tokens are revoked")
+
+	rec = s.update("alice", map[string]any{"is_disabled": false})
+	s.Require().Equal(http.StatusOK, rec.Code)
+	s.signIn("alice", http.StatusOK)
+}
+
+func (s *UserHandlersSuite) TestDelete() {
+	token := s.signIn("bob", http.StatusOK)
+
+	rec := s.do(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", s.users["bob"]), s.adminToken, nil)
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+	rec = s.do(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", s.users["bob"]), s.adminToken, nil)
+	s.Equal(http.StatusNotFound, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/auth/logout", token, nil)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+	s.signIn("bob", http.StatusBadRequest)
+
+	rec = s.do(http.MethodDelete, fmt.Sprintf("/api/v1/users/%d", s.adminID), s.adminToken, nil)
+	s.Equal(http.StatusForbidden, rec.Code)
+}
+
+func (s *UserHandlersSuite) TestChangePassword() {
+	const newPassword = "new_password"
+	other := s.signIn("alice", http.StatusOK)
+	current := s.signIn("alice", http.StatusOK)
+
+	rec := s.do(http.MethodPost, "/api/v1/auth/password", current, map[string]string{
+		"old_password": "wrong",
+		"new_password": newPassword,
+	})
+	s.Equal(http.StatusForbidden, rec.Code)
+	rec = s.do(http.MethodPost, "/api/v1/auth/password", current, map[string]string{
+		"old_password": password,
+	})
+	s.Equal(http.StatusBadRequest, rec.Code)
+	rec = s.do(http.MethodPost, "/api/v1/auth/password", "", map[string]string{
+		"old_password": password,
+		"new_password": newPassword,
+	})
+	s.Equal(http.StatusUnauthorized, rec.Code)
+
+	rec = s.do(http.MethodPost, "/api/v1/auth/password", current, map[string]string{
+		"old_password": password,
+		"new_password": newPassword,
+	})
+	s.Require().Equal(http.StatusNoContent, rec.Code)
+
+	s.signIn("alice", http.StatusBadRequest)
+	s.signInWith("alice", newPassword, http.StatusOK)
+	rec = s.do(http.MethodPost, "/api/v1/auth/logout", other, nil)
+	s.Equal(http.StatusUnauthorized, rec.Code, "other sessions are revoked")
+	rec = s.do(http.MethodPost, "/api/v1/auth/logout", current, nil)
+	s.Equal(http.StatusNoContent, rec.Code, "current session is kept")
+}
+
+func (s *UserHandlersSuite) TestResetPassword() {
+	const newPassword = "new_password"
+	token := s.signIn("alice", http.StatusOK)
+
+	issue := func() string {
+		rec := s.do(http.MethodPost, fmt.Sprintf("/api/v1/users/%d/password_reset", s.users["alice"]), s.adminToken, nil)
+		s.Require().Equal(http.StatusCreated, rec.Code)
+		var response struct {
+			ResetToken string `json:"reset_token"`
+		}
+		s.Require().NoError(json.Unmarshal(rec.Body.Bytes(), &response))
+		s.Require().NotEmpty(response.ResetToken)
+		return response.ResetToken
+	}
+	reset := func(resetToken string) int {
+		rec := s.do(http.MethodPost, "/api/v1/auth/password/reset", "", map[string]string{
+			"reset_token":  resetToken,
+			"new_password": newPassword,
+		})
+		return rec.Code
+	}
+
+	replaced := issue()
+	resetToken := issue()
+	s.Equal(http.StatusBadRequest, reset(replaced), "new token invalidates the previous one")
+	s.Equal(http.StatusBadRequest, reset("unknown"))
+
+	s.Require().Equal(http.StatusNoContent, reset(resetToken))
+	s.Equal(http.StatusBadRequest, reset(resetToken), "token is one-time")
+	s.signInWith("alice", newPassword, http.StatusOK)
+	rec := s.do(http.MethodPost, "/api/v1/auth/logout", token, nil)
+	s.Equal(http.StatusUnauthorized, rec.Code, "all sessions are revoked")
+
+	rec = s.do(http.MethodPost, fmt.Sprintf("/api/v1/users/%d/password_reset", s.users["superadmin"]), s.adminToken, nil)
+	s.Equal(http.StatusForbidden, rec.Code)
+}
+
+func (s *UserHandlersSuite) TestForbidden() {
+	token := s.signIn("bob", http.StatusOK)
+
+	rec := s.do(http.MethodGet, "/api/v1/users", token, nil)
+	s.Equal(http.StatusForbidden, rec.Code)
+	rec = s.do(http.MethodGet, "/api/v1/users", "", nil)
+	s.Equal(http.StatusUnauthorized, rec.Code)
+}
+
+func TestUserHandlersSuite(t *testing.T) {
+	suite.Run(t, new(UserHandlersSuite))
+}
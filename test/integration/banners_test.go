@@ -11,6 +11,7 @@ import (
 	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
 	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
 	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/storage/postgres"
+	teamRepository "github.com/SlavaShagalov/avito-intern-task/internal/team/repository/pgx"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -58,6 +59,8 @@ type BannerSuite struct {
 	log     *zap.Logger
 	uc      pBanner.Usecase
 	ctx     context.Context
+	// principal bypasses feature ownership.
+	principal *models.Principal
 }
 
 func (s *BannerSuite) SetupSuite() {
@@ -72,7 +75,8 @@ func (s *BannerSuite) SetupSuite() {
 	s.Require().NoError(err)
 
 	bannerRepo := bannerRepository.New(s.pgxPool, s.log)
-	s.uc = bannerUsecase.New(bannerRepo, s.log)
+	s.uc = bannerUsecase.New(bannerRepo, teamRepository.New(s.pgxPool, s.log), s.log)
+	s.principal = &models.Principal{Permissions: models.DefaultRolePermissions[models.RoleSuperAdmin]}
 }
 
 func (s *BannerSuite) TearDownSuite() {
@@ -168,12 +172,12 @@ func (s *BannerSuite) TestCreate() {
 
 	for name, test := range tests {
 		s.Run(name, func() {
-			bannerID, err := s.uc.Create(context.Background(), test.params)
+			bannerID, err := s.uc.Create(context.Background(), s.principal, test.params)
 			assert.ErrorIs(s.T(), err, test.err, "unexpected error")
 
 			if err == nil {
 				// check banner in db
-				banners, err := s.uc.List(context.Background(), &pBannerRepo.FilterParams{
+				banners, err := s.uc.List(context.Background(), s.principal, &pBannerRepo.FilterParams{
 					FeatureID: test.params.FeatureID,
 					TagID:     test.params.TagIDs[0],
 				})
@@ -185,7 +189,7 @@ func (s *BannerSuite) TestCreate() {
 				assert.Equal(s.T(), test.params.IsActive, banners[0].IsActive, "incorrect IsActive")
 
 				// reset changes in db
-				err = s.uc.Delete(context.Background(), bannerID)
+				err = s.uc.Delete(context.Background(), s.principal, bannerID)
 				assert.NoError(s.T(), err, "failed to delete created banner")
 			}
 		})
@@ -239,7 +243,7 @@ func (s *BannerSuite) TestList() {
 	for name, test := range tests {
 		s.Run(name, func() {
 
-			banners, err := s.uc.List(context.Background(), test.params)
+			banners, err := s.uc.List(context.Background(), s.principal, test.params)
 
 			assert.ErrorIs(s.T(), err, test.err, "unexpected error")
 
@@ -437,12 +441,12 @@ func (s *BannerSuite) TestPartialUpdate() {
 
 	for name, test := range tests {
 		s.Run(name, func() {
-			err := s.uc.PartialUpdate(context.Background(), test.params)
+			err := s.uc.PartialUpdate(context.Background(), s.principal, test.params)
 			assert.ErrorIs(s.T(), err, test.err, "unexpected error")
 
 			if err == nil {
 				// check banner in db
-				banners, err := s.uc.List(context.Background(), &pBannerRepo.FilterParams{
+				banners, err := s.uc.List(context.Background(), s.principal, &pBannerRepo.FilterParams{
 					FeatureID: test.banner.FeatureID,
 					TagID:     test.banner.TagIDs[0],
 				})
@@ -454,7 +458,7 @@ func (s *BannerSuite) TestPartialUpdate() {
 				assert.Equal(s.T(), test.banner.IsActive, banners[0].IsActive, "incorrect IsActive")
 
 				// reset banner
-				err = s.uc.PartialUpdate(context.Background(), &resetParams)
+				err = s.uc.PartialUpdate(context.Background(), s.principal, &resetParams)
 				assert.NoError(s.T(), err, "failed to reset banner in db")
 			}
 		})
@@ -474,7 +478,7 @@ func (s *BannerSuite) TestDelete() {
 			featureID: 5,
 			tagID:     1,
 			setupBanner: func() (int64, error) {
-				return s.uc.Create(context.Background(), &pBannerRepo.CreateParams{
+				return s.uc.Create(context.Background(), s.principal, &pBannerRepo.CreateParams{
 					TagIDs:    []int64{1, 2, 3},
 					FeatureID: 5,
 					Content:   map[string]any{"title": "tmp banner"},
@@ -496,7 +500,7 @@ func (s *BannerSuite) TestDelete() {
 			id, err := test.setupBanner()
 			s.Require().NoError(err)
 
-			err = s.uc.Delete(context.Background(), id)
+			err = s.uc.Delete(context.Background(), s.principal, id)
 			assert.ErrorIs(s.T(), err, test.err, "unexpected error")
 
 			if test.err == nil {
@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+	"time"
+
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	bannerRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/pgx"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/snapshot"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/config"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/SlavaShagalov/avito-intern-task/internal/pkg/storage/postgres"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type SnapshotSuite struct {
+	suite.Suite
+	pgxPool *pgxpool.Pool
+	log     *zap.Logger
+	repo    pBannerRepo.Repository
+	snap    *snapshot.Snapshot
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func (s *SnapshotSuite) SetupSuite() {
+	s.log = pLog.NewDev()
+
+	config.SetTestPostgresConfig()
+	var err error
+	s.pgxPool, err = postgres.NewPgx(s.log)
+	s.Require().NoError(err)
+	s.repo = bannerRepository.New(s.pgxPool, s.log)
+}
+
+func (s *SnapshotSuite) TearDownSuite() {
+	s.pgxPool.Close()
+
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+// SetupTest starts a snapshot that is only refreshed by notifications.
+func (s *SnapshotSuite) SetupTest() {
+	s.snap = snapshot.New(s.repo, s.pgxPool, time.Hour, s.log)
+
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		s.snap.Run(ctx)
+	}()
+
+	select {
+	case <-s.snap.Loaded():
+	case <-time.After(5 * time.Second):
+		s.FailNow("snapshot isn't loaded")
+	}
+}
+
+func (s *SnapshotSuite) TearDownTest() {
+	s.cancel()
+	<-s.done
+}
+
+func (s *SnapshotSuite) TestNotify() {
+	ctx := context.Background()
+	get := func() (string, error) {
+		banner, err := s.snap.Get(ctx, &pBannerRepo.GetParams{FeatureID: 5, TagID: 5})
+		if err != nil {
+			return "", err
+		}
+		return banner.Content["title"].(string), nil
+	}
+	title := func(expected string) func() bool {
+		return func() bool {
+			actual, err := get()
+			return err == nil && actual == expected
+		}
+	}
+
+	id, err := s.repo.Create(ctx, &pBannerRepo.CreateParams{
+		TagIDs:    []int64{5},
+		FeatureID: 5,
+		Content:   map[string]any{"title": "snapshot banner"},
+		IsActive:  true,
+	})
+	s.Require().NoError(err)
+	s.Eventually(title("snapshot banner"), 5*time.Second, 10*time.Millisecond)
+
+	err = s.repo.PartialUpdate(ctx, &pBannerRepo.PartialUpdateParams{
+		ID:      id,
+		Content: map[string]any{"title": "updated banner"},
+	})
+	s.Require().NoError(err)
+	s.Eventually(title("updated banner"), 5*time.Second, 10*time.Millisecond)
+
+	s.Require().NoError(s.repo.Delete(ctx, id))
+	s.Eventually(func() bool {
+		_, err := get()
+		return errors.Is(err, pErrors.ErrBannerNotFound)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSnapshotSuite(t *testing.T) {
+	suite.Run(t, new(SnapshotSuite))
+}
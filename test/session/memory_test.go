@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	pSession "github.com/SlavaShagalov/avito-intern-task/internal/session"
+	sessionMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/session/repository/memory"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const (
+	sweepInterval = 10 * time.Millisecond
+	tokenLifetime = 20 * time.Millisecond
+)
+
+type MemorySessionSuite struct {
+	suite.Suite
+	log  *zap.Logger
+	repo pSession.Repository
+}
+
+func (s *MemorySessionSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.repo = sessionMemoryRepository.NewWithSweepInterval(sweepInterval, s.log)
+}
+
+func (s *MemorySessionSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *MemorySessionSuite) create(userID int64, token string, expiresAt time.Time) int64 {
+	session, err := s.repo.Create(context.Background(), &pSession.CreateParams{
+		UserID:           userID,
+		RefreshTokenHash: token,
+		ExpiresAt:        expiresAt,
+	})
+	s.Require().NoError(err)
+	return session.ID
+}
+
+func (s *MemorySessionSuite) rotate(token, next string) error {
+	_, err := s.repo.Rotate(context.Background(), &pSession.RotateParams{
+		RefreshTokenHash:    token,
+		NewRefreshTokenHash: next,
+		ExpiresAt:           time.Now().Add(time.Hour),
+	})
+	return err
+}
+
+func (s *MemorySessionSuite) TestSweep() {
+	s.create(1, "expiring", time.Now().Add(tokenLifetime))
+	refreshed := s.create(1, "used", time.Now().Add(tokenLifetime))
+	s.Require().NoError(s.rotate("used", "current"))
+	s.Require().ErrorIs(s.rotate("used", "other"), pErrors.ErrRefreshTokenReused)
+
+	time.Sleep(2 * tokenLifetime)
+	s.create(2, "sweeping", time.Now().Add(time.Hour))
+
+	s.ErrorIs(s.rotate("used", "other"), pErrors.ErrInvalidRefreshToken, "expired tokens are dropped")
+	s.Require().NoError(s.rotate("current", "next"))
+
+	ids, err := s.repo.RevokeByUser(context.Background(), 1, 0)
+	s.Require().NoError(err)
+	s.Equal([]int64{refreshed}, ids, "sessions without tokens are dropped")
+}
+
+func TestMemorySessionSuite(t *testing.T) {
+	suite.Run(t, new(MemorySessionSuite))
+}
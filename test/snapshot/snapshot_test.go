@@ -0,0 +1,235 @@
+package snapshot
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pBannerRepo "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository"
+	bannerMemoryRepository "github.com/SlavaShagalov/avito-intern-task/internal/banner/repository/memory"
+	"github.com/SlavaShagalov/avito-intern-task/internal/banner/snapshot"
+	"github.com/SlavaShagalov/avito-intern-task/internal/models"
+	pErrors "github.com/SlavaShagalov/avito-intern-task/internal/pkg/errors"
+	pLog "github.com/SlavaShagalov/avito-intern-task/internal/pkg/log/zap"
+	"github.com/SlavaShagalov/avito-intern-task/internal/tenant"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+const (
+	reloadInterval = 20 * time.Millisecond
+	waitTimeout    = 5 * time.Second
+	waitTick       = 10 * time.Millisecond
+)
+
+// failingRepository fails to list banners while failing is set.
+type failingRepository struct {
+	pBannerRepo.Repository
+	failing  atomic.Bool
+	failures atomic.Int32
+}
+
+func (r *failingRepository) List(ctx context.Context, params *pBannerRepo.FilterParams) ([]models.Banner, error) {
+	if r.failing.Load() {
+		r.failures.Add(1)
+		return nil, pErrors.ErrDb
+	}
+	return r.Repository.List(ctx, params)
+}
+
+type SnapshotSuite struct {
+	suite.Suite
+	log  *zap.Logger
+	repo *failingRepository
+	snap *snapshot.Snapshot
+}
+
+func (s *SnapshotSuite) SetupTest() {
+	s.log = pLog.NewDev()
+	s.repo = &failingRepository{Repository: bannerMemoryRepository.New(s.log)}
+	s.snap = snapshot.New(s.repo, nil, reloadInterval, s.log)
+}
+
+func (s *SnapshotSuite) TearDownTest() {
+	err := s.log.Sync()
+	if err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *SnapshotSuite) create(ctx context.Context, featureID, tagID int64) int64 {
+	id, err := s.repo.Create(ctx, &pBannerRepo.CreateParams{
+		TagIDs:    []int64{tagID},
+		FeatureID: featureID,
+		Content:   map[string]any{"title": "banner"},
+		IsActive:  true,
+	})
+	s.Require().NoError(err)
+	return id
+}
+
+func (s *SnapshotSuite) get(ctx context.Context, featureID, tagID int64) (*models.Banner, error) {
+	return s.snap.Get(ctx, &pBannerRepo.GetParams{FeatureID: featureID, TagID: tagID})
+}
+
+func (s *SnapshotSuite) loaded() bool {
+	select {
+	case <-s.snap.Loaded():
+		return true
+	default:
+		return false
+	}
+}
+
+// run refreshes the snapshot in the background until the test ends.
+func (s *SnapshotSuite) run() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.snap.Run(ctx)
+	}()
+	s.T().Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func (s *SnapshotSuite) TestLoad() {
+	ctx := context.Background()
+	otherTenant := tenant.WithID(ctx, 2)
+	id := s.create(ctx, 1, 1)
+	otherID := s.create(otherTenant, 1, 1)
+
+	s.False(s.loaded())
+	banner, err := s.get(ctx, 1, 1)
+	s.Require().NoError(err, "the repository is used until the first load")
+	s.Equal(id, banner.ID)
+
+	s.Require().NoError(s.snap.Reload(ctx))
+	s.True(s.loaded())
+
+	banner, err = s.get(ctx, 1, 1)
+	s.Require().NoError(err)
+	s.Equal(id, banner.ID)
+	banner, err = s.get(otherTenant, 1, 1)
+	s.Require().NoError(err)
+	s.Equal(otherID, banner.ID, "banners of all tenants are loaded")
+
+	_, err = s.get(ctx, 1, 2)
+	s.ErrorIs(err, pErrors.ErrBannerNotFound)
+}
+
+func (s *SnapshotSuite) TestPeriodicReload() {
+	ctx := context.Background()
+	s.create(ctx, 1, 1)
+
+	s.run()
+	s.Eventually(s.loaded, waitTimeout, waitTick)
+
+	id := s.create(ctx, 2, 1)
+	s.Eventually(func() bool {
+		banner, err := s.get(ctx, 2, 1)
+		return err == nil && banner.ID == id
+	}, waitTimeout, waitTick)
+
+	s.Require().NoError(s.repo.Delete(ctx, id))
+	s.Eventually(func() bool {
+		_, err := s.get(ctx, 2, 1)
+		return err != nil
+	}, waitTimeout, waitTick)
+}
+
+func (s *SnapshotSuite) TestFailingReload() {
+	ctx := context.Background()
+	id := s.create(ctx, 1, 1)
+	s.Require().NoError(s.snap.Reload(ctx))
+
+	s.repo.failing.Store(true)
+	s.create(ctx, 2, 1)
+	s.Require().ErrorIs(s.snap.Reload(ctx), pErrors.ErrDb)
+
+	banner, err := s.get(ctx, 1, 1)
+	s.Require().NoError(err, "the previous snapshot is kept")
+	s.Equal(id, banner.ID)
+	_, err = s.get(ctx, 2, 1)
+	s.ErrorIs(err, pErrors.ErrBannerNotFound)
+}
+
+func (s *SnapshotSuite) TestFailingFirstLoad() {
+	s.repo.failing.Store(true)
+	s.run()
+
+	time.Sleep(5 * reloadInterval)
+	s.False(s.loaded())
+
+	s.repo.failing.Store(false)
+	s.Eventually(s.loaded, waitTimeout, waitTick, "the load is retried")
+}
+
+func (s *SnapshotSuite) TestBackoffReset() {
+	ctx := context.Background()
+	s.repo.failing.Store(true)
+	s.run()
+
+	// Retries after 100, 200, 400 and 800ms, the next one would be after 1.6s.
+	s.Eventually(func() bool { return s.repo.failures.Load() >= 4 }, waitTimeout, waitTick)
+	s.repo.failing.Store(false)
+	s.Eventually(s.loaded, waitTimeout, waitTick)
+
+	id := s.create(ctx, 1, 1)
+	failures := s.repo.failures.Load()
+	s.repo.failing.Store(true)
+	s.Eventually(func() bool { return s.repo.failures.Load() > failures }, waitTimeout, time.Millisecond)
+	s.repo.failing.Store(false)
+
+	s.Eventually(func() bool {
+		banner, err := s.get(ctx, 1, 1)
+		return err == nil && banner.ID == id
+	}, time.Second, waitTick, "the next retry uses the initial backoff")
+}
+
+func (s *SnapshotSuite) TestConcurrentReload() {
+	const banners = 50
+	ctx := context.Background()
+	for tagID := int64(1); tagID <= banners; tagID++ {
+		s.create(ctx, 1, tagID)
+	}
+	s.Require().NoError(s.snap.Reload(ctx))
+
+	var (
+		wg   sync.WaitGroup
+		stop atomic.Bool
+	)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				for tagID := int64(1); tagID <= banners; tagID++ {
+					banner, err := s.get(ctx, 1, tagID)
+					if !s.NoError(err) || !s.Equal([]int64{tagID}, banner.TagIDs) {
+						return
+					}
+				}
+				list, err := s.snap.List(ctx, &pBannerRepo.FilterParams{})
+				if !s.NoError(err) || !s.Len(list, banners) {
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 100; i++ {
+		s.Require().NoError(s.snap.Reload(ctx))
+	}
+	stop.Store(true)
+	wg.Wait()
+}
+
+func TestSnapshotSuite(t *testing.T) {
+	suite.Run(t, new(SnapshotSuite))
+}